@@ -0,0 +1,564 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/archive/internal"
+	fmi "github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+const (
+	// DefaultMaxEntries is the number of entries an archive may have when
+	// Options.MaxEntries is zero.
+	DefaultMaxEntries uint = 1 << 20
+
+	// DefaultMaxSize is the total size, in bytes, the content of an archive may
+	// have once extracted when Options.MaxSize is zero.
+	DefaultMaxSize int64 = 1 << 32
+)
+
+// Options are the options of the creation and extraction of archives. The
+// zero value is valid.
+type Options struct {
+	// Include, if not empty, restricts the entries to the ones whose path, one
+	// of whose parents, or whose base name matches one of the path.Match
+	// patterns.
+	Include []string
+
+	// Exclude discards the entries whose path, one of whose parents, or whose
+	// base name matches one of the path.Match patterns. It has precedence over
+	// Include.
+	Exclude []string
+
+	// MaxEntries is the maximum number of entries an extracted archive may
+	// have. If zero, DefaultMaxEntries is used.
+	MaxEntries uint
+
+	// MaxSize is the maximum total size, in bytes, of the content of an
+	// extracted archive. If zero, DefaultMaxSize is used.
+	MaxSize int64
+}
+
+// normalize returns the options with their defaults filled in.
+//
+// Returns:
+//   - Options: The normalized options.
+func (o *Options) normalize() Options {
+	var opts Options
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.MaxEntries == 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+
+	return opts
+}
+
+// keep checks whether an entry is selected by the include and exclude patterns.
+//
+// Parameters:
+//   - rel: The slash-separated path of the entry.
+//   - is_dir: Whether the entry is a directory.
+//
+// Returns:
+//   - bool: True if the entry is kept, false otherwise.
+func (o Options) keep(rel string, is_dir bool) bool {
+	if internal.Match(o.Exclude, rel) {
+		return false
+	}
+
+	if len(o.Include) == 0 || is_dir {
+		return true
+	}
+
+	return internal.Match(o.Include, rel)
+}
+
+// walk calls fn for every kept entry of the tree rooted at root, parents
+// before their children. Excluded directories are not walked.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - root: The root of the tree.
+//   - opts: The options of the walk.
+//   - fn: The function to call with the path of the entry relative to root,
+//     its information and, for symbolic links, its target.
+//
+// Returns:
+//   - error: The first error encountered.
+func walk(fsys vfs.FS, root string, opts Options, fn func(rel string, info fs.FileInfo, target string) error) error {
+	// fs.WalkDir cleans the names it reports, so the root must be clean too.
+	root = path.Clean(root)
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == root {
+			return nil
+		}
+
+		rel := fmi.RelTo(root, name)
+
+		if !opts.keep(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var target string
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, err = fsys.ReadLink(name)
+			if err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			// Devices, sockets and pipes cannot be archived portably.
+			return nil
+		}
+
+		err = fn(rel, info, target)
+		return err
+	})
+
+	return err
+}
+
+// dirMeta is the metadata applied to a directory once the extraction is over.
+type dirMeta struct {
+	// loc is the location of the directory.
+	loc string
+
+	// mode is the mode of the directory.
+	mode fs.FileMode
+
+	// mtime is the modification time of the directory.
+	mtime time.Time
+}
+
+// extractor writes the entries of an archive into a file system.
+type extractor struct {
+	// fsys is the file system to extract into.
+	fsys vfs.FS
+
+	// dest is the directory to extract into.
+	dest string
+
+	// opts are the options of the extraction.
+	opts Options
+
+	// entries is the number of entries seen so far.
+	entries uint
+
+	// written is the number of bytes written so far.
+	written int64
+
+	// dirs are the directories whose metadata must be applied at the end.
+	dirs []dirMeta
+
+	// links are the targets of the symbolic links to create at the end, by
+	// the cleaned name of the link.
+	links map[string]string
+}
+
+// newExtractor creates the destination directory and an extractor for it.
+//
+// Parameters:
+//   - fsys: The file system to extract into. If nil, the native file system is used.
+//   - dest: The directory to extract into.
+//   - opts: The options of the extraction.
+//
+// Returns:
+//   - *extractor: The extractor.
+//   - error: An error if the destination could not be created.
+func newExtractor(fsys vfs.FS, dest string, opts *Options) (*extractor, error) {
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	err := fsys.MkdirAll(dest, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &extractor{
+		fsys:  fsys,
+		dest:  dest,
+		opts:  opts.normalize(),
+		links: make(map[string]string),
+	}
+
+	return x, nil
+}
+
+// entry validates the name of the next entry of the archive.
+//
+// Parameters:
+//   - name: The name of the entry, as stored in the archive.
+//   - is_dir: Whether the entry is a directory.
+//
+// Returns:
+//   - string: The cleaned name of the entry.
+//   - bool: False if the entry is filtered out.
+//   - error: An error if the entry must not be extracted.
+//
+// Errors:
+//   - ErrTooManyEntries: If the archive has too many entries.
+//   - *file_manager.ErrEscape: If the entry would be written outside of the destination.
+func (x *extractor) entry(name string, is_dir bool) (string, bool, error) {
+	x.entries++
+	if x.entries > x.opts.MaxEntries {
+		return "", false, ErrTooManyEntries
+	}
+
+	rel, ok := internal.SafeName(name)
+	if !ok {
+		return "", false, fm.NewErrEscape(x.dest, name)
+	}
+
+	if !x.opts.keep(rel, is_dir) {
+		return "", false, nil
+	}
+
+	// A symbolic link, whether it already exists or is yet to be created,
+	// must never be followed.
+	for p := path.Dir(rel); p != "."; p = path.Dir(p) {
+		_, ok := x.links[p]
+		if ok {
+			return "", false, fm.NewErrEscape(x.dest, name)
+		}
+
+		info, err := x.fsys.Lstat(path.Join(x.dest, p))
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return "", false, fm.NewErrEscape(x.dest, name)
+		}
+	}
+
+	// A later entry replaces an earlier one of the same name.
+	delete(x.links, rel)
+
+	return rel, true, nil
+}
+
+// resolve resolves a name relative to the destination through the symbolic
+// links it contains, without ever leaving it.
+//
+// Parameters:
+//   - name: The slash-separated name to resolve.
+//
+// Returns:
+//   - string: The location of the resolved entry, inside the destination.
+//   - error: An error if the name cannot be resolved.
+//
+// Errors:
+//   - *file_manager.ErrEscape: If the name, or a link it goes through, leaves the destination.
+//   - any other error: If an entry could not be inspected.
+func (x *extractor) resolve(name string) (string, error) {
+	lstat := func(rel string) (fs.FileInfo, error) {
+		return x.fsys.Lstat(path.Join(x.dest, rel))
+	}
+
+	readlink := func(rel string) (string, error) {
+		return x.fsys.ReadLink(path.Join(x.dest, rel))
+	}
+
+	rel, err := internal.Resolve(name, lstat, readlink)
+	if err == internal.ErrOutside {
+		return "", fm.NewErrEscape(x.dest, name)
+	} else if err != nil {
+		return "", err
+	}
+
+	return path.Join(x.dest, rel), nil
+}
+
+// prepare creates the parent directories of an entry and removes whatever
+// non-directory entry already exists in its place.
+//
+// Parameters:
+//   - rel: The cleaned name of the entry.
+//
+// Returns:
+//   - string: The location of the entry.
+//   - error: An error if the location could not be prepared.
+func (x *extractor) prepare(rel string) (string, error) {
+	loc := path.Join(x.dest, rel)
+
+	err := x.fsys.MkdirAll(path.Dir(loc), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := x.fsys.Lstat(loc)
+	if err == nil && !info.IsDir() {
+		err = x.fsys.Remove(loc)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return loc, nil
+}
+
+// dir extracts a directory. Its mode and modification time are applied by
+// finish.
+//
+// Parameters:
+//   - rel: The cleaned name of the directory.
+//   - mode: The mode of the directory.
+//   - mtime: The modification time of the directory.
+//
+// Returns:
+//   - error: An error if the directory could not be created.
+func (x *extractor) dir(rel string, mode fs.FileMode, mtime time.Time) error {
+	loc, err := x.prepare(rel)
+	if err != nil {
+		return err
+	}
+
+	err = x.fsys.MkdirAll(loc, 0o755)
+	if err != nil {
+		return err
+	}
+
+	x.dirs = append(x.dirs, dirMeta{
+		loc:   loc,
+		mode:  mode.Perm(),
+		mtime: mtime,
+	})
+
+	return nil
+}
+
+// file extracts a regular file.
+//
+// Parameters:
+//   - rel: The cleaned name of the file.
+//   - mode: The mode of the file.
+//   - mtime: The modification time of the file.
+//   - r: The content of the file.
+//
+// Returns:
+//   - error: An error if the file could not be written.
+//
+// Errors:
+//   - ErrTooLarge: If the content of the archive is too large.
+//   - any other error: If the file could not be written.
+func (x *extractor) file(rel string, mode fs.FileMode, mtime time.Time, r io.Reader) error {
+	loc, err := x.prepare(rel)
+	if err != nil {
+		return err
+	}
+
+	f, err := x.fsys.OpenFile(loc, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, x.opts.MaxSize-x.written+1))
+	x.written += n
+
+	err2 := f.Close()
+	if err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if x.written > x.opts.MaxSize {
+		return ErrTooLarge
+	}
+
+	err = x.fsys.Chmod(loc, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	err = x.fsys.Chtimes(loc, mtime, mtime)
+	return err
+}
+
+// link extracts a hard link as a copy of the file it points to, which must
+// have been extracted before. The target is resolved inside the destination,
+// so that a hard link cannot copy a file from outside of it.
+//
+// Parameters:
+//   - rel: The cleaned name of the link.
+//   - target: The name of the file it points to, as stored in the archive.
+//   - mode: The mode of the file.
+//   - mtime: The modification time of the file.
+//
+// Returns:
+//   - error: An error if the link could not be extracted.
+func (x *extractor) link(rel, target string, mode fs.FileMode, mtime time.Time) error {
+	clean_target, ok := internal.SafeName(target)
+	if !ok {
+		return fm.NewErrEscape(x.dest, target)
+	}
+
+	loc, err := x.resolve(clean_target)
+	if err != nil {
+		return err
+	}
+
+	info, err := x.fsys.Lstat(loc)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return &fs.PathError{Op: "link", Path: target, Err: fs.ErrInvalid}
+	}
+
+	f, err := x.fsys.Open(loc)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = x.file(rel, mode, mtime, f)
+	return err
+}
+
+// symlink records a symbolic link, to be created by finish once every other
+// entry is extracted. Targets that are absolute or climb out of the
+// destination on their own are refused right away.
+//
+// Parameters:
+//   - rel: The cleaned name of the link.
+//   - target: The target of the link.
+//
+// Returns:
+//   - error: An error if the link is refused.
+//
+// Errors:
+//   - *file_manager.ErrEscape: If the target leaves the destination.
+func (x *extractor) symlink(rel, target string) error {
+	ok := internal.SafeLink(rel, target)
+	if !ok {
+		return fm.NewErrEscape(x.dest, rel+" -> "+target)
+	}
+
+	x.links[rel] = target
+
+	return nil
+}
+
+// createLinks creates the recorded symbolic links, then checks that every one
+// of them resolves inside the destination. Links are checked only once they
+// all exist, since a link may go through another one extracted after it;
+// the links that escape are removed.
+//
+// Returns:
+//   - error: An error if a link could not be created or escapes.
+//
+// Errors:
+//   - *file_manager.ErrEscape: If a link resolves outside of the destination.
+//   - any other error: If a link could not be created.
+func (x *extractor) createLinks() error {
+	rels := make([]string, 0, len(x.links))
+
+	for rel := range x.links {
+		rels = append(rels, rel)
+	}
+
+	slices.Sort(rels)
+
+	for _, rel := range rels {
+		loc, err := x.prepare(rel)
+		if err != nil {
+			return err
+		}
+
+		err = x.fsys.Symlink(x.links[rel], loc)
+		if err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+
+	for _, rel := range rels {
+		_, err := x.resolve(rel)
+		if err == nil {
+			continue
+		}
+
+		errs = append(errs, err)
+
+		var escape *fm.ErrEscape
+
+		ok := errors.As(err, &escape)
+		if !ok {
+			continue
+		}
+
+		err = x.fsys.Remove(path.Join(x.dest, rel))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// finish creates the symbolic links, then applies the mode and modification
+// time of the directories, deepest first so that filling a directory does not
+// alter its parent again.
+//
+// Returns:
+//   - error: An error if a link could not be created or escapes, or the
+//     metadata could not be applied.
+func (x *extractor) finish() error {
+	err := x.createLinks()
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(x.dirs, func(a, b dirMeta) int {
+		return strings.Compare(b.loc, a.loc)
+	})
+
+	var errs []error
+
+	for _, d := range x.dirs {
+		err := x.fsys.Chmod(d.loc, d.mode)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		err = x.fsys.Chtimes(d.loc, d.mtime, d.mtime)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,180 @@
+//go:build unix
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+)
+
+// tarEntry is an entry of a test archive.
+type tarEntry struct {
+	name, link string
+	flag       byte
+	data       string
+}
+
+// makeTar builds a tar archive from the given entries.
+func makeTar(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Linkname: e.link,
+			Typeflag: e.flag,
+			Mode:     0o644,
+			Size:     int64(len(e.data)),
+		}
+
+		err := tw.WriteHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = tw.Write([]byte(e.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+// setup creates a destination directory next to a secret file.
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+
+	base := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(base, "secret"), []byte("secret"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base, filepath.Join(base, "dest")
+}
+
+// TestExtractTarChainedLinks tests that links that only escape through other
+// links are refused, in whatever order they are stored.
+func TestExtractTarChainedLinks(t *testing.T) {
+	orders := [][]tarEntry{
+		{
+			{name: "a/b/s", link: "../../c", flag: tar.TypeSymlink},
+			{name: "a/b/l", link: "s/../../secret", flag: tar.TypeSymlink},
+		},
+		{
+			{name: "a/b/l", link: "s/../../secret", flag: tar.TypeSymlink},
+			{name: "a/b/s", link: "../../c", flag: tar.TypeSymlink},
+		},
+	}
+
+	for i, entries := range orders {
+		_, dest := setup(t)
+
+		err := ExtractTar(makeTar(t, entries...), false, nil, dest, nil)
+
+		var escape *fm.ErrEscape
+		if !errors.As(err, &escape) {
+			t.Errorf("order %d: expected *file_manager.ErrEscape, got %v", i, err)
+		}
+
+		_, err = os.Lstat(filepath.Join(dest, "a", "b", "l"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("order %d: expected the escaping link to be removed, got %v", i, err)
+		}
+
+		_, err = os.ReadFile(filepath.Join(dest, "a", "b", "l"))
+		if err == nil {
+			t.Errorf("order %d: the secret is readable through the destination", i)
+		}
+	}
+}
+
+// TestExtractTarHardLinkEscape tests that a hard link cannot copy a file from
+// outside of the destination through a symbolic link.
+func TestExtractTarHardLinkEscape(t *testing.T) {
+	base, dest := setup(t)
+
+	err := os.MkdirAll(dest, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink(base, filepath.Join(dest, "pre"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := makeTar(t, tarEntry{name: "copy", link: "pre/secret", flag: tar.TypeLink})
+
+	err = ExtractTar(archive, false, nil, dest, nil)
+
+	var escape *fm.ErrEscape
+	if !errors.As(err, &escape) {
+		t.Errorf("expected *file_manager.ErrEscape, got %v", err)
+	}
+
+	_, err = os.Stat(filepath.Join(dest, "copy"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no copy of the secret, got %v", err)
+	}
+}
+
+// TestExtractTarThroughLink tests that an entry cannot be written through a
+// symbolic link stored earlier in the archive.
+func TestExtractTarThroughLink(t *testing.T) {
+	_, dest := setup(t)
+
+	archive := makeTar(t,
+		tarEntry{name: "s", link: "sub", flag: tar.TypeSymlink},
+		tarEntry{name: "s/x", flag: tar.TypeReg, data: "x"},
+	)
+
+	err := ExtractTar(archive, false, nil, dest, nil)
+
+	var escape *fm.ErrEscape
+	if !errors.As(err, &escape) {
+		t.Errorf("expected *file_manager.ErrEscape, got %v", err)
+	}
+}
+
+// TestExtractTarLinks tests that links that stay inside the destination are
+// extracted.
+func TestExtractTarLinks(t *testing.T) {
+	_, dest := setup(t)
+
+	archive := makeTar(t,
+		tarEntry{name: "dir/file", flag: tar.TypeReg, data: "hello"},
+		tarEntry{name: "up", link: "dir/../dir", flag: tar.TypeSymlink},
+		tarEntry{name: "dir/self", link: "../up/file", flag: tar.TypeSymlink},
+		tarEntry{name: "copy", link: "dir/file", flag: tar.TypeLink},
+	)
+
+	err := ExtractTar(archive, false, nil, dest, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"up/file", "dir/self", "copy"} {
+		data, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(data) != "hello" {
+			t.Errorf("%s: expected %q, got %q (%v)", name, "hello", data, err)
+		}
+	}
+}
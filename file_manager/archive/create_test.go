@@ -0,0 +1,57 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// TestCreateTarRoot tests that entries are named relative to the root,
+// however it is spelled.
+func TestCreateTarRoot(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("src/sub", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vfs.WriteFile(fsys, "src/sub/f.txt", []byte("f"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"sub/", "sub/f.txt"}
+
+	for _, root := range []string{"src", "./src/", "src//", "src/sub/.."} {
+		var buf bytes.Buffer
+
+		err := CreateTar(&buf, false, fsys, root, nil)
+		if err != nil {
+			t.Fatalf("root %q: unexpected error: %v", root, err)
+		}
+
+		var names []string
+
+		tr := tar.NewReader(&buf)
+
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("root %q: unexpected error: %v", root, err)
+			}
+
+			names = append(names, hdr.Name)
+		}
+
+		if !slices.Equal(names, want) {
+			t.Errorf("root %q: expected %v, got %v", root, want, names)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package archive
+
+import "errors"
+
+var (
+	// ErrTooManyEntries occurs when an archive has more entries than allowed.
+	// This error can be checked with the == operator.
+	//
+	// Format:
+	// 	"archive has too many entries"
+	ErrTooManyEntries error
+
+	// ErrTooLarge occurs when the content of an archive is larger than allowed.
+	// This error can be checked with the == operator.
+	//
+	// Format:
+	// 	"archive content is too large"
+	ErrTooLarge error
+)
+
+func init() {
+	ErrTooManyEntries = errors.New("archive has too many entries")
+	ErrTooLarge = errors.New("archive content is too large")
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"path"
+	"strings"
+)
+
+// SafeName cleans the name of an archive entry and checks that it stays inside
+// the extraction directory.
+//
+// Parameters:
+//   - name: The name of the entry, as stored in the archive.
+//
+// Returns:
+//   - string: The cleaned, slash-separated name.
+//   - bool: False if the name is empty, absolute, contains a backslash or a
+//     volume name, or climbs above the extraction directory.
+func SafeName(name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsRune(name, '\\') {
+		return "", false
+	}
+
+	if len(name) >= 2 && name[1] == ':' {
+		return "", false
+	}
+
+	cleaned := path.Clean(name)
+
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+
+	return cleaned, true
+}
+
+// SafeLink checks that a symbolic link stored at name and pointing at target
+// cannot be used to reach outside of the extraction directory.
+//
+// Parameters:
+//   - name: The cleaned name of the link.
+//   - target: The target of the link.
+//
+// Returns:
+//   - bool: True if the link stays inside the extraction directory, false otherwise.
+func SafeLink(name, target string) bool {
+	if target == "" || strings.HasPrefix(target, "/") || strings.ContainsRune(target, '\\') {
+		return false
+	}
+
+	_, ok := SafeName(path.Join(path.Dir(name), target))
+	return ok
+}
+
+// Match checks whether the given path, one of its parents or its base name
+// matches one of the path.Match patterns.
+//
+// Parameters:
+//   - patterns: The patterns to match against.
+//   - rel: The slash-separated path to check.
+//
+// Returns:
+//   - bool: True if one of the patterns matches, false otherwise.
+func Match(patterns []string, rel string) bool {
+	base := path.Base(rel)
+
+	for _, pattern := range patterns {
+		ok, _ := path.Match(pattern, base)
+		if ok {
+			return true
+		}
+
+		for p := rel; p != "."; p = path.Dir(p) {
+			ok, _ := path.Match(pattern, p)
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package internal
+
+import "testing"
+
+// TestSafeName tests the SafeName function.
+func TestSafeName(t *testing.T) {
+	valid := map[string]string{
+		"a/b":      "a/b",
+		"./a/":     "a",
+		"a/../b/c": "b/c",
+	}
+
+	for name, expected := range valid {
+		got, ok := SafeName(name)
+		if !ok || got != expected {
+			t.Errorf("%q: expected %q, got %q (%t)", name, expected, got, ok)
+		}
+	}
+
+	for _, name := range []string{"", ".", "..", "/etc/passwd", "../x", "a/../../x", `a\..\x`, "C:/x"} {
+		_, ok := SafeName(name)
+		if ok {
+			t.Errorf("%q: expected to be rejected", name)
+		}
+	}
+
+	if !SafeLink("a/b/link", "../c") {
+		t.Errorf("expected link to stay inside")
+	}
+
+	if SafeLink("a/link", "../../c") || SafeLink("link", "/etc") {
+		t.Errorf("expected link to be rejected")
+	}
+}
+
+// TestMatch tests the Match function.
+func TestMatch(t *testing.T) {
+	patterns := []string{"*.o", "vendor", "docs/*.md"}
+
+	for _, rel := range []string{"main.o", "src/x.o", "vendor/a/b.go", "docs/readme.md"} {
+		if !Match(patterns, rel) {
+			t.Errorf("%q: expected a match", rel)
+		}
+	}
+
+	for _, rel := range []string{"main.go", "src/vendored.go", "docs/a/b.md"} {
+		if Match(patterns, rel) {
+			t.Errorf("%q: expected no match", rel)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"syscall"
+)
+
+const (
+	// MaxSymlinks is the maximum number of symbolic links followed while
+	// resolving a single name.
+	MaxSymlinks int = 40
+)
+
+// ErrOutside occurs when a name resolves outside of the extraction directory.
+var ErrOutside error = errors.New("name resolves outside of the extraction directory")
+
+// Resolve resolves a name relative to the extraction directory one element at
+// a time, following every symbolic link, including a trailing one, the way
+// the kernel would: a ".." that follows a link climbs from the link's target,
+// not from the link itself.
+//
+// Parameters:
+//   - name: The slash-separated name to resolve.
+//   - lstat: Describes an entry, given its name relative to the extraction directory.
+//   - readlink: Reads a symbolic link, given its name relative to the extraction directory.
+//
+// Returns:
+//   - string: The resolved name, relative to the extraction directory. "." for the directory itself.
+//   - error: An error if the name cannot be resolved.
+//
+// Errors:
+//   - ErrOutside: If the name, or a link it goes through, leaves the extraction directory.
+//   - syscall.ELOOP: If too many symbolic links were followed.
+//   - any other error: If an entry could not be inspected.
+//
+// Elements that do not exist are resolved lexically, until a ".." pops them.
+func Resolve(name string, lstat func(string) (fs.FileInfo, error), readlink func(string) (string, error)) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return "", ErrOutside
+	}
+
+	parts := strings.Split(name, "/")
+
+	var resolved []string
+
+	hops := 0
+
+	// missing is the depth of the first element of resolved that does not
+	// exist, or -1.
+	missing := -1
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", ErrOutside
+			}
+
+			resolved = resolved[:len(resolved)-1]
+
+			if missing >= len(resolved) {
+				missing = -1
+			}
+
+			continue
+		}
+
+		if missing >= 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		rel := path.Join(append(resolved, part)...)
+
+		info, err := lstat(rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = len(resolved)
+			resolved = append(resolved, part)
+
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		hops++
+		if hops > MaxSymlinks {
+			return "", &fs.PathError{Op: "resolve", Path: name, Err: syscall.ELOOP}
+		}
+
+		target, err := readlink(rel)
+		if err != nil {
+			return "", err
+		}
+
+		if target == "" || strings.HasPrefix(target, "/") || strings.ContainsRune(target, '\\') {
+			return "", ErrOutside
+		}
+
+		parts = append(strings.Split(target, "/"), parts...)
+	}
+
+	return path.Join(append([]string{"."}, resolved...)...), nil
+}
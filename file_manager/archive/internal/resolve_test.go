@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// linkInfo describes a symbolic link.
+type linkInfo struct {
+	fs.FileInfo
+}
+
+// Mode implements fs.FileInfo.
+func (linkInfo) Mode() fs.FileMode {
+	return fs.ModeSymlink | 0o777
+}
+
+// TestResolve tests Resolve against chains of symbolic links.
+func TestResolve(t *testing.T) {
+	links := map[string]string{
+		"a/b/s":   "../../c",
+		"a/b/l":   "s/../../x",
+		"a/b/in":  "s/../y",
+		"loop":    "loop",
+		"a/b/abs": "/etc",
+	}
+
+	tree := fstest.MapFS{
+		"a/b/file": {},
+		"c/x":      {},
+	}
+
+	for name := range links {
+		tree[name] = &fstest.MapFile{}
+	}
+
+	lstat := func(name string) (fs.FileInfo, error) {
+		info, err := fs.Stat(tree, name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, ok := links[name]
+		if ok {
+			return linkInfo{info}, nil
+		}
+
+		return info, nil
+	}
+
+	readlink := func(name string) (string, error) {
+		return links[name], nil
+	}
+
+	valid := map[string]string{
+		"a/b/file":             "a/b/file",
+		"a/b/s/x":              "c/x",
+		"a/b/in":               "y",
+		"missing/../a/b/s":     "c",
+		"a/missing/../../c":    "c",
+		"a/b/s/../a/b/s/../c":  "c",
+		"a/b/../b/./file/../s": "c",
+	}
+
+	for name, expected := range valid {
+		got, err := Resolve(name, lstat, readlink)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", name, err)
+		} else if got != expected {
+			t.Errorf("%q: expected %q, got %q", name, expected, got)
+		}
+	}
+
+	for _, name := range []string{"..", "a/b/l", "missing/../a/b/l", "a/b/abs", "/c"} {
+		_, err := Resolve(name, lstat, readlink)
+		if err != ErrOutside {
+			t.Errorf("%q: expected ErrOutside, got %v", name, err)
+		}
+	}
+
+	_, err := Resolve("loop", lstat, readlink)
+	if !errors.Is(err, syscall.ELOOP) {
+		t.Errorf("expected syscall.ELOOP, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// CreateTar writes the tree rooted at root as a tar archive, optionally
+// compressed with gzip. Modes, modification times and symbolic links are
+// preserved; devices, sockets and pipes are skipped.
+//
+// Parameters:
+//   - w: The writer to write the archive to. Must not be nil.
+//   - compress: Whether to compress the archive with gzip.
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - root: The root of the tree. It is not part of the archive itself.
+//   - opts: The include and exclude patterns. If nil, every entry is archived.
+//
+// Returns:
+//   - error: An error if the archive could not be written.
+//
+// Errors:
+//   - *errors.ErrBadParam: If w is nil.
+//   - any other error: If the tree could not be read or the archive written.
+func CreateTar(w io.Writer, compress bool, fsys vfs.FS, root string, opts *Options) error {
+	if w == nil {
+		return gers.NewErrNilParam("w")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	var gz *gzip.Writer
+
+	if compress {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+
+	err := walk(fsys, root, opts.normalize(), func(rel string, info fs.FileInfo, target string) error {
+		hdr, err := tar.FileInfoHeader(info, target)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = rel
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		hdr.Uname = ""
+		hdr.Gname = ""
+		hdr.Format = tar.FormatPAX
+
+		err = tw.WriteHeader(hdr)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		f, err := fsys.Open(path.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+
+	if gz != nil {
+		err = gz.Close()
+	}
+
+	return err
+}
+
+// ExtractTar extracts a tar archive, optionally compressed with gzip, into
+// dest. Modes, modification times and symbolic links are restored; hard links
+// are extracted as copies.
+//
+// Entries whose name, or whose link target, would escape dest are refused,
+// and so are entries that would be written through a symbolic link. Symbolic
+// links are created last, once they can all be resolved together, and any
+// that leads outside of dest, possibly through other links, is removed. The
+// extraction stops at the first error, possibly leaving a partial tree.
+//
+// Parameters:
+//   - r: The reader to read the archive from. Must not be nil.
+//   - compressed: Whether the archive is compressed with gzip.
+//   - fsys: The file system to extract into. If nil, the native file system is used.
+//   - dest: The directory to extract into. It is created if needed.
+//   - opts: The filters and limits of the extraction. If nil, the defaults are used.
+//
+// Returns:
+//   - error: An error if the archive could not be extracted.
+//
+// Errors:
+//   - *errors.ErrBadParam: If r is nil.
+//   - *file_manager.ErrEscape: If an entry would be written outside of dest.
+//   - ErrTooManyEntries: If the archive has more entries than allowed.
+//   - ErrTooLarge: If the content of the archive is larger than allowed.
+//   - any other error: If the archive is malformed or could not be written.
+func ExtractTar(r io.Reader, compressed bool, fsys vfs.FS, dest string, opts *Options) error {
+	if r == nil {
+		return gers.NewErrNilParam("r")
+	}
+
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		r = gz
+	}
+
+	x, err := newExtractor(fsys, dest, opts)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		is_dir := hdr.Typeflag == tar.TypeDir
+
+		rel, ok, err := x.entry(hdr.Name, is_dir)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+
+		mode := fs.FileMode(hdr.Mode).Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(rel, mode, hdr.ModTime)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.file(rel, mode, hdr.ModTime, tr)
+		case tar.TypeLink:
+			err = x.link(rel, hdr.Linkname, mode, hdr.ModTime)
+		case tar.TypeSymlink:
+			err = x.symlink(rel, hdr.Linkname)
+		default:
+			// Devices, pipes and extended headers are not extracted.
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = x.finish()
+	return err
+}
@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// CreateZip writes the tree rooted at root as a zip archive. Modes,
+// modification times and symbolic links are preserved; devices, sockets and
+// pipes are skipped.
+//
+// Parameters:
+//   - w: The writer to write the archive to. Must not be nil.
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - root: The root of the tree. It is not part of the archive itself.
+//   - opts: The include and exclude patterns. If nil, every entry is archived.
+//
+// Returns:
+//   - error: An error if the archive could not be written.
+//
+// Errors:
+//   - *errors.ErrBadParam: If w is nil.
+//   - any other error: If the tree could not be read or the archive written.
+func CreateZip(w io.Writer, fsys vfs.FS, root string, opts *Options) error {
+	if w == nil {
+		return gers.NewErrNilParam("w")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	zw := zip.NewWriter(w)
+
+	err := walk(fsys, root, opts.normalize(), func(rel string, info fs.FileInfo, target string) error {
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = rel
+
+		switch {
+		case info.IsDir():
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+		case info.Mode().IsRegular():
+			hdr.Method = zip.Deflate
+		default:
+			hdr.Method = zip.Store
+		}
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		if target != "" {
+			_, err := io.WriteString(fw, target)
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := fsys.Open(path.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = zw.Close()
+	return err
+}
+
+// ExtractZip extracts a zip archive into dest. Modes, modification times and
+// symbolic links are restored.
+//
+// Entries whose name, or whose link target, would escape dest are refused,
+// and so are entries that would be written through a symbolic link. Symbolic
+// links are created last, once they can all be resolved together, and any
+// that leads outside of dest, possibly through other links, is removed. The
+// extraction stops at the first error, possibly leaving a partial tree.
+//
+// Parameters:
+//   - r: The reader to read the archive from. Must not be nil.
+//   - size: The size of the archive, in bytes.
+//   - fsys: The file system to extract into. If nil, the native file system is used.
+//   - dest: The directory to extract into. It is created if needed.
+//   - opts: The filters and limits of the extraction. If nil, the defaults are used.
+//
+// Returns:
+//   - error: An error if the archive could not be extracted.
+//
+// Errors:
+//   - *errors.ErrBadParam: If r is nil.
+//   - *file_manager.ErrEscape: If an entry would be written outside of dest.
+//   - ErrTooManyEntries: If the archive has more entries than allowed.
+//   - ErrTooLarge: If the content of the archive is larger than allowed.
+//   - any other error: If the archive is malformed or could not be written.
+func ExtractZip(r io.ReaderAt, size int64, fsys vfs.FS, dest string, opts *Options) error {
+	if r == nil {
+		return gers.NewErrNilParam("r")
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	x, err := newExtractor(fsys, dest, opts)
+	if err != nil {
+		return err
+	}
+
+	if uint(len(zr.File)) > x.opts.MaxEntries {
+		return ErrTooManyEntries
+	}
+
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		is_dir := mode.IsDir() || strings.HasSuffix(zf.Name, "/")
+
+		rel, ok, err := x.entry(zf.Name, is_dir)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+
+		err = x.extractZipFile(zf, rel, is_dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = x.finish()
+	return err
+}
+
+// extractZipFile extracts a single entry of a zip archive.
+//
+// Parameters:
+//   - zf: The entry to extract.
+//   - rel: The cleaned name of the entry.
+//   - is_dir: Whether the entry is a directory.
+//
+// Returns:
+//   - error: An error if the entry could not be extracted.
+func (x *extractor) extractZipFile(zf *zip.File, rel string, is_dir bool) error {
+	mode := zf.Mode()
+
+	if is_dir {
+		err := x.dir(rel, mode|0o700, zf.Modified)
+		return err
+	}
+
+	if mode&fs.ModeSymlink == 0 && !mode.IsRegular() {
+		return nil
+	}
+
+	if zf.UncompressedSize64 > uint64(x.opts.MaxSize-x.written) {
+		return ErrTooLarge
+	}
+
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if mode&fs.ModeSymlink == 0 {
+		err := x.file(rel, mode, zf.Modified, rc)
+		return err
+	}
+
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+
+	err = x.symlink(rel, string(target))
+	return err
+}
@@ -0,0 +1,497 @@
+package checksum
+
+import (
+	"bufio"
+	"crypto"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	_ "crypto/md5"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/checksum/internal"
+	fmi "github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// Algorithm is a hash algorithm a manifest can be written with. Only the
+// algorithms of the standard library are available; BLAKE2 manifests, as
+// written by b2sum, are therefore not supported.
+type Algorithm int
+
+const (
+	// SHA256 is the algorithm of sha256sum.
+	SHA256 Algorithm = iota
+
+	// SHA512 is the algorithm of sha512sum.
+	SHA512
+
+	// SHA384 is the algorithm of sha384sum.
+	SHA384
+
+	// SHA224 is the algorithm of sha224sum.
+	SHA224
+
+	// SHA1 is the algorithm of sha1sum. It is not collision resistant.
+	SHA1
+
+	// MD5 is the algorithm of md5sum. It is not collision resistant.
+	MD5
+)
+
+// algorithms maps each algorithm to its tag and its implementation.
+var algorithms = [...]struct {
+	tag  string
+	hash crypto.Hash
+}{
+	SHA256: {"SHA256", crypto.SHA256},
+	SHA512: {"SHA512", crypto.SHA512},
+	SHA384: {"SHA384", crypto.SHA384},
+	SHA224: {"SHA224", crypto.SHA224},
+	SHA1:   {"SHA1", crypto.SHA1},
+	MD5:    {"MD5", crypto.MD5},
+}
+
+// String implements fmt.Stringer. The result is the tag used in BSD-style
+// manifests.
+func (a Algorithm) String() string {
+	ok := a.valid()
+	if !ok {
+		return "Algorithm(" + strconv.Itoa(int(a)) + ")"
+	}
+
+	return algorithms[a].tag
+}
+
+// valid checks whether the algorithm is one of the known ones.
+//
+// Returns:
+//   - bool: True if the algorithm is known, false otherwise.
+func (a Algorithm) valid() bool {
+	return a >= 0 && int(a) < len(algorithms)
+}
+
+// ParseAlgorithm returns the algorithm with the given tag, case insensitively.
+// Both "SHA256" and "SHA-256" are accepted.
+//
+// Parameters:
+//   - tag: The tag of the algorithm.
+//
+// Returns:
+//   - Algorithm: The algorithm.
+//   - bool: False if no algorithm has the given tag.
+func ParseAlgorithm(tag string) (Algorithm, bool) {
+	tag = strings.ReplaceAll(strings.ToUpper(tag), "-", "")
+
+	for a, alg := range algorithms {
+		if alg.tag == tag {
+			return Algorithm(a), true
+		}
+	}
+
+	return 0, false
+}
+
+// Entry is a single file listed in a manifest.
+type Entry struct {
+	// Name is the name of the file, relative to the directory of the manifest.
+	Name string
+
+	// Digest is the lowercase hex-encoded digest of the content of the file.
+	Digest string
+}
+
+// Manifest is a list of files along with the digest of their content.
+type Manifest struct {
+	// Algorithm is the algorithm the digests are computed with.
+	Algorithm Algorithm
+
+	// Entries are the files of the manifest.
+	Entries []Entry
+
+	// Tagged makes WriteTo use the BSD style ("SHA256 (name) = digest")
+	// instead of the GNU one ("digest  name").
+	Tagged bool
+}
+
+// hashAll hashes the named files in parallel.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - alg: The algorithm to use. Must be valid.
+//   - names: The names of the files.
+//
+// Returns:
+//   - []string: The digests, in the same order as names.
+//   - []error: The error of each file, in the same order as names.
+func hashAll(fsys vfs.FS, alg Algorithm, names []string) ([]string, []error) {
+	digests, errs := fmi.HashFiles(fsys, names, algorithms[alg].hash.New, runtime.NumCPU())
+	return digests, errs
+}
+
+// Generate creates the manifest of the given files. The files are hashed in
+// parallel and listed in the given order, under the given names.
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - alg: The algorithm to use.
+//   - names: The names of the files.
+//
+// Returns:
+//   - *Manifest: The manifest.
+//   - error: An error if a file could not be hashed.
+//
+// Errors:
+//   - *errors.ErrBadParam: If alg is not a known algorithm.
+//   - any other error: If a file could not be read.
+func Generate(fsys vfs.FS, alg Algorithm, names []string) (*Manifest, error) {
+	if !alg.valid() {
+		return nil, gers.NewErrBadParam("alg", "must be a known algorithm")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	digests, errs := hashAll(fsys, alg, names)
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Algorithm: alg,
+		Entries:   make([]Entry, 0, len(names)),
+	}
+
+	for i, name := range names {
+		m.Entries = append(m.Entries, Entry{Name: name, Digest: digests[i]})
+	}
+
+	return m, nil
+}
+
+// treeFiles lists the regular files of the tree rooted at root.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - root: The root of the tree.
+//
+// Returns:
+//   - []string: The paths of the files, relative to root, in lexical order.
+//   - error: An error if the tree could not be read.
+func treeFiles(fsys vfs.FS, root string) ([]string, error) {
+	// fs.WalkDir cleans the names it reports, so the root must be clean too.
+	root = path.Clean(root)
+
+	ok, err := fm.Exists(fsys, root)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &fs.PathError{Op: "checksum", Path: root, Err: fs.ErrNotExist}
+	}
+
+	var rels []string
+
+	err = fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rels = append(rels, fmi.RelTo(root, name))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rels, nil
+}
+
+// GenerateTree creates the manifest of every regular file of the tree rooted
+// at root. The files are listed in lexical order, relative to root, which
+// makes the manifest suitable to be checked from within root with
+// "sha256sum -c".
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - alg: The algorithm to use.
+//   - root: The root of the tree.
+//
+// Returns:
+//   - *Manifest: The manifest.
+//   - error: An error if the tree could not be read.
+//
+// Errors:
+//   - *errors.ErrBadParam: If alg is not a known algorithm.
+//   - fs.ErrNotExist: If the root does not exist.
+//   - any other error: If a file could not be read.
+func GenerateTree(fsys vfs.FS, alg Algorithm, root string) (*Manifest, error) {
+	if !alg.valid() {
+		return nil, gers.NewErrBadParam("alg", "must be a known algorithm")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	rels, err := treeFiles(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(rels))
+
+	for _, rel := range rels {
+		names = append(names, path.Join(root, rel))
+	}
+
+	m, err := Generate(fsys, alg, names)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range m.Entries {
+		m.Entries[i].Name = rels[i]
+	}
+
+	return m, nil
+}
+
+// Parse reads a manifest in either the GNU or the BSD style, as written by
+// sha256sum with or without --tag. Both styles may be mixed, blank lines are
+// ignored and names escaped with a leading backslash are unescaped.
+//
+// Parameters:
+//   - r: The reader to read from. Must not be nil.
+//   - alg: The algorithm the manifest is written with.
+//
+// Returns:
+//   - *Manifest: The manifest read. Tagged is set if the first line is in the BSD style.
+//   - error: An error if the manifest could not be read.
+//
+// Errors:
+//   - *errors.ErrBadParam: If r is nil or alg is not a known algorithm.
+//   - *ErrMalformedLine: If a line is malformed, is tagged with another
+//     algorithm or has a digest of the wrong length.
+//   - any other error: If the reader fails.
+func Parse(r io.Reader, alg Algorithm) (*Manifest, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParam("r")
+	} else if !alg.valid() {
+		return nil, gers.NewErrBadParam("alg", "must be a known algorithm")
+	}
+
+	size := algorithms[alg].hash.Size() * 2
+
+	m := &Manifest{
+		Algorithm: alg,
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	var num int
+
+	for scanner.Scan() {
+		num++
+
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		line, ok := internal.ParseLine(text)
+		if !ok {
+			return nil, NewErrMalformedLine(num, "not a checksum line")
+		}
+
+		if line.Tag != "" {
+			tagged, ok := ParseAlgorithm(line.Tag)
+			if !ok || tagged != alg {
+				return nil, NewErrMalformedLine(num, "expected algorithm "+alg.String()+", got "+strconv.Quote(line.Tag))
+			}
+		}
+
+		if len(line.Digest) != size {
+			return nil, NewErrMalformedLine(num, "expected a digest of "+strconv.Itoa(size)+" hex digits, got "+strconv.Itoa(len(line.Digest)))
+		}
+
+		if len(m.Entries) == 0 {
+			m.Tagged = line.Tag != ""
+		}
+
+		m.Entries = append(m.Entries, Entry{
+			Name:   line.Name,
+			Digest: strings.ToLower(line.Digest),
+		})
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// WriteTo implements io.WriterTo. Every entry is written on its own line in
+// the style selected by Tagged, escaping names the way sha256sum does.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - *errors.ErrBadParam: If w is nil.
+//   - any other error: If the writer fails.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	if m == nil {
+		return 0, gers.ErrNilReceiver
+	} else if w == nil {
+		return 0, gers.NewErrNilParam("w")
+	}
+
+	var tag string
+
+	if m.Tagged {
+		tag = m.Algorithm.String()
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var total int64
+
+	for _, entry := range m.Entries {
+		n, err := bw.WriteString(internal.FormatLine(tag, entry.Digest, entry.Name) + "\n")
+		total += int64(n)
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	err := bw.Flush()
+	return total, err
+}
+
+// Report is the result of the verification of a manifest.
+type Report struct {
+	// OK are the files whose digest matches.
+	OK []string
+
+	// Missing are the files listed in the manifest that do not exist.
+	Missing []string
+
+	// Mismatched are the files whose digest does not match.
+	Mismatched []string
+
+	// Extra are the files of the tree that are not listed in the manifest.
+	// It is only filled when requested.
+	Extra []string
+}
+
+// Passed checks whether the verification found no discrepancy.
+//
+// Returns:
+//   - bool: True if no file is missing, mismatched or extra, false otherwise.
+func (r Report) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Mismatched) == 0 && len(r.Extra) == 0
+}
+
+// Verify checks the manifest against the files under root. The files are
+// hashed in parallel and each one is reported under its name in the manifest.
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - root: The directory the names of the manifest are relative to.
+//   - extra: Whether to also report the regular files under root that are not listed.
+//
+// Returns:
+//   - *Report: The result of the verification. Never nil when no error is returned.
+//   - error: An error if a file exists but could not be read.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - *errors.ErrBadParam: If the algorithm of the manifest is not known.
+//   - any other error: If a file or the tree could not be read.
+func (m *Manifest) Verify(fsys vfs.FS, root string, extra bool) (*Report, error) {
+	if m == nil {
+		return nil, gers.ErrNilReceiver
+	} else if !m.Algorithm.valid() {
+		return nil, gers.NewErrBadParam("m.Algorithm", "must be a known algorithm")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	names := make([]string, 0, len(m.Entries))
+
+	for _, entry := range m.Entries {
+		name := entry.Name
+		if !path.IsAbs(name) {
+			name = path.Join(root, name)
+		}
+
+		names = append(names, name)
+	}
+
+	digests, errs := hashAll(fsys, m.Algorithm, names)
+
+	report := new(Report)
+
+	for i, entry := range m.Entries {
+		err := errs[i]
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			report.Missing = append(report.Missing, entry.Name)
+		case err != nil:
+			return nil, err
+		case digests[i] == strings.ToLower(entry.Digest):
+			report.OK = append(report.OK, entry.Name)
+		default:
+			report.Mismatched = append(report.Mismatched, entry.Name)
+		}
+	}
+
+	if !extra {
+		return report, nil
+	}
+
+	rels, err := treeFiles(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]struct{}, len(m.Entries))
+
+	for _, entry := range m.Entries {
+		listed[path.Clean(entry.Name)] = struct{}{}
+	}
+
+	for _, rel := range rels {
+		_, ok := listed[rel]
+		if !ok {
+			report.Extra = append(report.Extra, rel)
+		}
+	}
+
+	slices.Sort(report.Extra)
+
+	return report, nil
+}
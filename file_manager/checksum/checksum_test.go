@@ -0,0 +1,233 @@
+package checksum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// TestGenerateTreeRoot tests that files are named relative to the root,
+// however it is spelled.
+func TestGenerateTreeRoot(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("src/sub", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"src/a", "src/sub/b"} {
+		err := vfs.WriteFile(fsys, name, []byte(name), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, root := range []string{"src", "./src/", "src//", "src/sub/.."} {
+		m, err := GenerateTree(fsys, SHA256, root)
+		if err != nil {
+			t.Fatalf("root %q: unexpected error: %v", root, err)
+		}
+
+		if len(m.Entries) != 2 || m.Entries[0].Name != "a" || m.Entries[1].Name != "sub/b" {
+			t.Fatalf("root %q: unexpected entries %+v", root, m.Entries)
+		}
+
+		report, err := m.Verify(fsys, root, true)
+		if err != nil {
+			t.Fatalf("root %q: unexpected error: %v", root, err)
+		}
+
+		if !report.Passed() || len(report.OK) != 2 {
+			t.Errorf("root %q: unexpected report %+v", root, report)
+		}
+	}
+}
+
+// sum returns the hex-encoded SHA-256 digest of the data.
+func sum(data string) string {
+	digest := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(digest[:])
+}
+
+// TestVerifyReport tests that Verify sorts every file into OK, Missing,
+// Mismatched and, when requested, Extra.
+func TestVerifyReport(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("root/sub", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{
+		"root/ok":      "ok",
+		"root/changed": "new",
+		"root/sub/new": "new",
+		"root/extra":   "extra",
+	} {
+		err := vfs.WriteFile(fsys, name, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &Manifest{
+		Algorithm: SHA256,
+		Entries: []Entry{
+			{Name: "ok", Digest: strings.ToUpper(sum("ok"))},
+			{Name: "changed", Digest: sum("old")},
+			{Name: "gone", Digest: sum("gone")},
+			{Name: "./sub/new", Digest: sum("new")},
+		},
+	}
+
+	report, err := m.Verify(fsys, "root", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Report{
+		OK:         []string{"ok", "./sub/new"},
+		Missing:    []string{"gone"},
+		Mismatched: []string{"changed"},
+	}
+
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Verify() = %+v; want %+v", report, want)
+	}
+
+	if report.Passed() {
+		t.Error("Passed() = true; want false")
+	}
+
+	report, err = m.Verify(fsys, "root", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(report.Extra, []string{"extra"}) {
+		t.Errorf("Verify() extra = %q; want [extra]", report.Extra)
+	}
+
+	fsys.InjectFault("root/ok", "open", errors.New("boom"))
+
+	_, err = m.Verify(fsys, "root", false)
+	if err == nil {
+		t.Error("Verify() of an unreadable file succeeded")
+	}
+}
+
+// TestParseMalformed tests that Parse rejects lines that are not checksum
+// lines, are tagged with another algorithm or have a digest of the wrong
+// length, and reports their number.
+func TestParseMalformed(t *testing.T) {
+	digest := sum("a")
+
+	tests := []struct {
+		text string
+		line int
+	}{
+		{"garbage\n", 1},
+		{digest + "  a\n\nnot a line\n", 3},
+		{"SHA512 (a) = " + digest + "\n", 1},
+		{"MD4 (a) = " + digest + "\n", 1},
+		{digest[:10] + "  a\n", 1},
+		{digest + "00  a\n", 1},
+		{"SHA256 (a) = " + digest[:62] + "\n", 1},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.text), SHA256)
+
+		var malformed *ErrMalformedLine
+
+		if !errors.As(err, &malformed) || malformed.Line != test.line {
+			t.Errorf("Parse(%q) error = %v; want *ErrMalformedLine at line %d", test.text, err, test.line)
+		}
+	}
+
+	_, err := Parse(strings.NewReader(""), Algorithm(42))
+	if err == nil {
+		t.Error("Parse() with an unknown algorithm succeeded")
+	}
+
+	_, err = Parse(nil, SHA256)
+	if err == nil {
+		t.Error("Parse(nil) succeeded")
+	}
+}
+
+// TestWriteToParse tests that Parse reads back what WriteTo writes, in both
+// styles and with names that must be escaped.
+func TestWriteToParse(t *testing.T) {
+	entries := []Entry{
+		{Name: "plain", Digest: sum("plain")},
+		{Name: "with space", Digest: sum("space")},
+		{Name: "new\nline", Digest: sum("newline")},
+		{Name: `back\slash`, Digest: sum("backslash")},
+		{Name: "(parens) = x", Digest: sum("parens")},
+	}
+
+	for _, tagged := range []bool{false, true} {
+		m := &Manifest{
+			Algorithm: SHA256,
+			Entries:   entries,
+			Tagged:    tagged,
+		}
+
+		var buf bytes.Buffer
+
+		n, err := m.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		} else if n != int64(buf.Len()) {
+			t.Errorf("tagged = %t: WriteTo() = %d; wrote %d bytes", tagged, n, buf.Len())
+		}
+
+		got, err := Parse(&buf, SHA256)
+		if err != nil {
+			t.Fatalf("tagged = %t: Parse(): %v", tagged, err)
+		}
+
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("tagged = %t: Parse(WriteTo()) = %+v; want %+v", tagged, got, m)
+		}
+	}
+
+	fsys := vfs.NewMemory()
+
+	err := vfs.WriteFile(fsys, "f", []byte("content"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Generate(fsys, SHA1, []string{"f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	_, err = m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&buf, SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := got.Verify(fsys, ".", false)
+	if err != nil || !report.Passed() || len(report.OK) != 1 {
+		t.Errorf("Verify() of a parsed manifest = %+v, %v; want it to pass", report, err)
+	}
+}
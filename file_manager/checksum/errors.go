@@ -0,0 +1,44 @@
+package checksum
+
+import (
+	"strconv"
+)
+
+// ErrMalformedLine occurs when a line of a manifest cannot be parsed.
+type ErrMalformedLine struct {
+	// Line is the 1-based number of the offending line.
+	Line int
+
+	// Reason is why the line is malformed.
+	Reason string
+}
+
+// Error implements error.
+func (e ErrMalformedLine) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Reason
+}
+
+// NewErrMalformedLine creates a new ErrMalformedLine error.
+//
+// Parameters:
+//   - line: The 1-based number of the offending line.
+//   - reason: Why the line is malformed.
+//
+// Returns:
+//   - error: An instance of ErrMalformedLine. Never returns nil.
+//
+// Format:
+//
+//	"line <line>: <reason>"
+//
+// Where:
+//   - <line> is the 1-based number of the offending line.
+//   - <reason> is why the line is malformed.
+func NewErrMalformedLine(line int, reason string) error {
+	e := &ErrMalformedLine{
+		Line:   line,
+		Reason: reason,
+	}
+
+	return e
+}
@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"strings"
+)
+
+// Line is a single parsed line of a checksum manifest.
+type Line struct {
+	// Tag is the algorithm tag of a BSD-style line, or empty for a GNU-style line.
+	Tag string
+
+	// Digest is the hex-encoded digest.
+	Digest string
+
+	// Name is the unescaped name of the file.
+	Name string
+
+	// Binary is true if the line marks the file as read in binary mode.
+	Binary bool
+}
+
+// needsEscape checks whether a name must be escaped in a manifest.
+//
+// Parameters:
+//   - name: The name to check.
+//
+// Returns:
+//   - bool: True if the name contains a backslash, a newline or a carriage return.
+func needsEscape(name string) bool {
+	return strings.ContainsAny(name, "\\\n\r")
+}
+
+// EscapeName escapes a name the way sha256sum does.
+//
+// Parameters:
+//   - name: The name to escape.
+//
+// Returns:
+//   - string: The escaped name.
+//   - bool: True if the name was escaped, in which case the line must start with a backslash.
+func EscapeName(name string) (string, bool) {
+	ok := needsEscape(name)
+	if !ok {
+		return name, false
+	}
+
+	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	return r.Replace(name), true
+}
+
+// UnescapeName reverts EscapeName.
+//
+// Parameters:
+//   - name: The escaped name.
+//
+// Returns:
+//   - string: The unescaped name.
+//   - bool: False if the name contains an invalid escape sequence.
+func UnescapeName(name string) (string, bool) {
+	var builder strings.Builder
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		if c != '\\' {
+			_ = builder.WriteByte(c)
+			continue
+		}
+
+		i++
+
+		if i == len(name) {
+			return "", false
+		}
+
+		switch name[i] {
+		case '\\':
+			_ = builder.WriteByte('\\')
+		case 'n':
+			_ = builder.WriteByte('\n')
+		case 'r':
+			_ = builder.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+
+	return builder.String(), true
+}
+
+// FormatLine formats a line of a manifest, without the trailing newline.
+//
+// Parameters:
+//   - tag: The algorithm tag. If not empty, the BSD style is used.
+//   - digest: The hex-encoded digest.
+//   - name: The name of the file.
+//
+// Returns:
+//   - string: The formatted line.
+func FormatLine(tag, digest, name string) string {
+	escaped, ok := EscapeName(name)
+
+	var builder strings.Builder
+
+	if ok {
+		_ = builder.WriteByte('\\')
+	}
+
+	if tag == "" {
+		_, _ = builder.WriteString(digest)
+		_, _ = builder.WriteString("  ")
+		_, _ = builder.WriteString(escaped)
+	} else {
+		_, _ = builder.WriteString(tag)
+		_, _ = builder.WriteString(" (")
+		_, _ = builder.WriteString(escaped)
+		_, _ = builder.WriteString(") = ")
+		_, _ = builder.WriteString(digest)
+	}
+
+	return builder.String()
+}
+
+// ParseLine parses a line of a manifest in either the GNU style
+// ("<digest>  <name>" or "<digest> *<name>") or the BSD style
+// ("<tag> (<name>) = <digest>").
+//
+// Parameters:
+//   - text: The line, without the trailing newline.
+//
+// Returns:
+//   - Line: The parsed line.
+//   - bool: False if the line is malformed.
+func ParseLine(text string) (Line, bool) {
+	text = strings.TrimSuffix(text, "\r")
+
+	escaped := strings.HasPrefix(text, "\\")
+	if escaped {
+		text = text[1:]
+	}
+
+	line, ok := parseBSD(text)
+	if !ok {
+		line, ok = parseGNU(text)
+	}
+
+	if !ok || line.Name == "" || !isHex(line.Digest) {
+		return Line{}, false
+	}
+
+	if escaped {
+		line.Name, ok = UnescapeName(line.Name)
+		if !ok {
+			return Line{}, false
+		}
+	}
+
+	return line, true
+}
+
+// parseGNU parses a GNU-style line.
+//
+// Parameters:
+//   - text: The line, without the leading escape marker.
+//
+// Returns:
+//   - Line: The parsed line.
+//   - bool: False if the line is not a GNU-style line.
+func parseGNU(text string) (Line, bool) {
+	digest, rest, ok := strings.Cut(text, " ")
+	if !ok || rest == "" {
+		return Line{}, false
+	}
+
+	line := Line{
+		Digest: digest,
+	}
+
+	switch rest[0] {
+	case ' ':
+	case '*':
+		line.Binary = true
+	default:
+		return Line{}, false
+	}
+
+	line.Name = rest[1:]
+
+	return line, true
+}
+
+// parseBSD parses a BSD-style line.
+//
+// Parameters:
+//   - text: The line, without the leading escape marker.
+//
+// Returns:
+//   - Line: The parsed line.
+//   - bool: False if the line is not a BSD-style line.
+func parseBSD(text string) (Line, bool) {
+	tag, rest, ok := strings.Cut(text, " (")
+	if !ok || tag == "" || strings.Contains(tag, " ") {
+		return Line{}, false
+	}
+
+	idx := strings.LastIndex(rest, ") = ")
+	if idx < 0 {
+		return Line{}, false
+	}
+
+	line := Line{
+		Tag:    tag,
+		Name:   rest[:idx],
+		Digest: rest[idx+len(") = "):],
+	}
+
+	return line, true
+}
+
+// isHex checks whether a digest is a non-empty lowercase or uppercase hex string.
+//
+// Parameters:
+//   - digest: The digest to check.
+//
+// Returns:
+//   - bool: True if the digest is valid hex, false otherwise.
+func isHex(digest string) bool {
+	if digest == "" || len(digest)%2 != 0 {
+		return false
+	}
+
+	for _, c := range digest {
+		ok := ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
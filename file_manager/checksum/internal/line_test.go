@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+)
+
+// TestParseLine tests the ParseLine function.
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		text string
+		want Line
+		ok   bool
+	}{
+		{"abcd  a.txt", Line{Digest: "abcd", Name: "a.txt"}, true},
+		{"abcd *dir/b c", Line{Digest: "abcd", Name: "dir/b c", Binary: true}, true},
+		{"SHA256 (x (1).txt) = abcd", Line{Tag: "SHA256", Name: "x (1).txt", Digest: "abcd"}, true},
+		{"\\abcd  a\\nb\\\\c", Line{Digest: "abcd", Name: "a\nb\\c"}, true},
+		{"abcd a.txt", Line{}, false},
+		{"xyz  a.txt", Line{}, false},
+		{"abcd  ", Line{}, false},
+		{"\\abcd  a\\q", Line{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLine(tt.text)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseLine(%q) = %+v, %t; want %+v, %t", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestFormatLine tests that FormatLine round-trips through ParseLine.
+func TestFormatLine(t *testing.T) {
+	for _, tag := range []string{"", "SHA512"} {
+		for _, name := range []string{"plain.txt", "new\nline", "back\\slash", "paren) = x"} {
+			text := FormatLine(tag, "00ff", name)
+
+			got, ok := ParseLine(text)
+			if !ok || got.Name != name || got.Tag != tag || got.Digest != "00ff" {
+				t.Errorf("ParseLine(FormatLine(%q, %q)) = %+v, %t", tag, name, got, ok)
+			}
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/shellwords"
 )
 
 // NewCommand creates a new command from the given name and arguments.
@@ -36,3 +39,35 @@ func NewCommand(name string, args ...string) *exec.Cmd {
 
 	return cmd
 }
+
+// ParseCommand creates a new command from a single command line, such as one
+// read from a configuration file. The line is split with shellwords.SplitEnv
+// and the resulting words are handed to NewCommand.
+//
+// Parameters:
+//   - line: The command line to parse.
+//   - env: The variables to expand. If nil, '$' is taken literally.
+//
+// Returns:
+//   - *exec.Cmd: The newly created command.
+//   - error: An error if the command line is malformed or empty.
+//
+// Errors:
+//   - *errors.ErrBadParam: If the command line contains no words.
+//   - *errors.ErrUnexpected: If a quote, an escape or a "${" is not terminated.
+//
+// Panics:
+//   - If the operating system is not supported. (i.e. Windows and Linux)
+func ParseCommand(line string, env map[string]string) (*exec.Cmd, error) {
+	words, err := shellwords.SplitEnv(line, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(words) == 0 {
+		return nil, gers.NewErrBadParam("line", "must contain a command")
+	}
+
+	cmd := NewCommand(words[0], words[1:]...)
+	return cmd, nil
+}
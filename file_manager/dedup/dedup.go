@@ -0,0 +1,530 @@
+package dedup
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/dedup/internal"
+	fmi "github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// Options are the options of a search for duplicates.
+type Options struct {
+	// MinSize is the size, in bytes, below which files are ignored. If zero,
+	// empty files are ignored and every other file is considered.
+	MinSize int64
+
+	// Verify compares the content of the files byte by byte once their hashes
+	// match, instead of trusting SHA-256.
+	Verify bool
+
+	// Workers is the number of files read concurrently. If zero, the number of
+	// CPUs is used.
+	Workers int
+}
+
+// Group is a set of files with the same content.
+type Group struct {
+	// Size is the size of each file, in bytes.
+	Size int64
+
+	// Paths are the paths of the files, in lexical order. Hard links to the
+	// same file are only listed once.
+	Paths []string
+
+	// ModTimes are the modification times of the files when they were found,
+	// in the same order as Paths.
+	ModTimes []time.Time
+}
+
+// Wasted returns the number of bytes that replacing the duplicates with links
+// would save.
+//
+// Returns:
+//   - int64: The size of every file but the first one.
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// fileID identifies a file across its hard links.
+type fileID struct {
+	// dev is the device the file is on.
+	dev uint64
+
+	// ino is the inode of the file.
+	ino uint64
+}
+
+// collect lists the regular files of the trees, grouped by size. Files that
+// are the same as an already listed one, because they are hard links to it or
+// because the trees overlap, are left out.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - roots: The roots of the trees.
+//   - min_size: The minimum size of a file.
+//
+// Returns:
+//   - map[int64][]string: The files, by size.
+//   - map[string]time.Time: The modification times of the files, by path.
+//   - error: An error if a tree could not be read.
+func collect(fsys vfs.FS, roots []string, min_size int64) (map[int64][]string, map[string]time.Time, error) {
+	by_size := make(map[int64][]string)
+	mod_times := make(map[string]time.Time)
+	seen_ids := make(map[fileID]struct{})
+	seen_names := make(map[string]struct{})
+
+	for _, root := range roots {
+		err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if info.Size() < min_size {
+				return nil
+			}
+
+			_, ok := seen_names[name]
+			if ok {
+				return nil
+			}
+
+			seen_names[name] = struct{}{}
+
+			dev, ino, ok := fmi.FileID(info)
+			if ok {
+				id := fileID{dev: dev, ino: ino}
+
+				_, seen := seen_ids[id]
+				if seen {
+					return nil
+				}
+
+				seen_ids[id] = struct{}{}
+			}
+
+			by_size[info.Size()] = append(by_size[info.Size()], name)
+			mod_times[name] = info.ModTime()
+
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return by_size, mod_times, nil
+}
+
+// refine splits every group by the digest of its files, dropping the files
+// that end up alone.
+//
+// Parameters:
+//   - groups: The groups to split.
+//   - workers: The number of files hashed concurrently.
+//   - hash: The function computing the digest of a file of a group.
+//
+// Returns:
+//   - []Group: The refined groups.
+//   - error: An error if a file could not be hashed.
+func refine(groups []Group, workers int, hash func(g Group, name string) (string, error)) ([]Group, error) {
+	type job struct {
+		group int
+		name  string
+	}
+
+	var jobs []job
+
+	for i, g := range groups {
+		for _, name := range g.Paths {
+			jobs = append(jobs, job{group: i, name: name})
+		}
+	}
+
+	digests := make([]string, len(jobs))
+
+	errs := fmi.Parallel(len(jobs), workers, func(i int) error {
+		var err error
+
+		digests[i], err = hash(groups[jobs[i].group], jobs[i].name)
+		return err
+	})
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var refined []Group
+
+	for start := 0; start < len(jobs); {
+		end := start
+
+		for end < len(jobs) && jobs[end].group == jobs[start].group {
+			end++
+		}
+
+		by_digest := make(map[string][]string)
+
+		var order []string
+
+		for i := start; i < end; i++ {
+			_, ok := by_digest[digests[i]]
+			if !ok {
+				order = append(order, digests[i])
+			}
+
+			by_digest[digests[i]] = append(by_digest[digests[i]], jobs[i].name)
+		}
+
+		for _, digest := range order {
+			paths := by_digest[digest]
+			if len(paths) > 1 {
+				refined = append(refined, Group{Size: groups[jobs[start].group].Size, Paths: paths})
+			}
+		}
+
+		start = end
+	}
+
+	return refined, nil
+}
+
+// verify splits every group into the sets of files whose content is equal
+// byte by byte.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - groups: The groups to verify.
+//   - workers: The number of groups verified concurrently.
+//
+// Returns:
+//   - []Group: The verified groups.
+//   - error: An error if a file could not be read.
+func verify(fsys vfs.FS, groups []Group, workers int) ([]Group, error) {
+	classes := make([][]Group, len(groups))
+
+	errs := fmi.Parallel(len(groups), workers, func(i int) error {
+		g := groups[i]
+
+		var reps []Group
+
+	outer:
+		for _, name := range g.Paths {
+			for j := range reps {
+				ok, err := internal.Equal(fsys, reps[j].Paths[0], name)
+				if err != nil {
+					return err
+				} else if ok {
+					reps[j].Paths = append(reps[j].Paths, name)
+					continue outer
+				}
+			}
+
+			reps = append(reps, Group{Size: g.Size, Paths: []string{name}})
+		}
+
+		classes[i] = reps
+
+		return nil
+	})
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var verified []Group
+
+	for _, reps := range classes {
+		for _, g := range reps {
+			if len(g.Paths) > 1 {
+				verified = append(verified, g)
+			}
+		}
+	}
+
+	return verified, nil
+}
+
+// Find finds the files with the same content across the given trees. The
+// files are grouped by size first, then by a hash of their first and last
+// blocks, then by a hash of their whole content and, if requested, by a byte
+// by byte comparison. Each stage only reads the files that are still
+// candidates, in parallel.
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - roots: The roots of the trees. They may overlap.
+//   - opts: The options of the search. If nil, the defaults are used.
+//
+// Returns:
+//   - []Group: The groups of duplicates, the ones that waste the most space first.
+//   - error: An error if a tree could not be read.
+//
+// Errors:
+//   - *errors.ErrBadParam: If no root is given.
+//   - any other error: If a tree or a file could not be read.
+func Find(fsys vfs.FS, roots []string, opts *Options) ([]Group, error) {
+	if len(roots) == 0 {
+		return nil, gers.NewErrBadParam("roots", "must not be empty")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	var o Options
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MinSize <= 0 {
+		o.MinSize = 1
+	}
+
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+
+	by_size, mod_times, err := collect(fsys, roots, o.MinSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []Group
+
+	for size, paths := range by_size {
+		if len(paths) > 1 {
+			groups = append(groups, Group{Size: size, Paths: paths})
+		}
+	}
+
+	groups, err = refine(groups, o.Workers, func(g Group, name string) (string, error) {
+		return internal.PartialHash(fsys, name, g.Size)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Files no larger than two blocks were already hashed whole.
+	var small, large []Group
+
+	for _, g := range groups {
+		if g.Size <= 2*internal.BlockSize {
+			small = append(small, g)
+		} else {
+			large = append(large, g)
+		}
+	}
+
+	large, err = refine(large, o.Workers, func(g Group, name string) (string, error) {
+		return fmi.HashFile(fsys, name, sha256.New)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups = append(small, large...)
+
+	if o.Verify {
+		groups, err = verify(fsys, groups, o.Workers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, g := range groups {
+		slices.Sort(g.Paths)
+
+		groups[i].ModTimes = make([]time.Time, 0, len(g.Paths))
+
+		for _, name := range g.Paths {
+			groups[i].ModTimes = append(groups[i].ModTimes, mod_times[name])
+		}
+	}
+
+	slices.SortFunc(groups, func(a, b Group) int {
+		c := cmp.Compare(b.Wasted(), a.Wasted())
+		if c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Paths[0], b.Paths[0])
+	})
+
+	return groups, nil
+}
+
+// LinkMode is how Replace replaces the duplicates.
+type LinkMode int
+
+const (
+	// HardLink replaces the duplicates with hard links to the first file. The
+	// files then share their mode, owner and times.
+	HardLink LinkMode = iota
+
+	// Reflink replaces the duplicates with copy-on-write clones of the first
+	// file, on file systems that support it (such as Btrfs and XFS on Linux).
+	// The files keep their own mode and can later diverge.
+	Reflink
+)
+
+// String implements fmt.Stringer.
+func (m LinkMode) String() string {
+	switch m {
+	case HardLink:
+		return "hard link"
+	case Reflink:
+		return "reflink"
+	default:
+		return "LinkMode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
+
+// Replace replaces every file of the group but the first one with a link to
+// the first one. Each replacement is made under a temporary name, then renamed
+// over the duplicate, so a duplicate is never missing. The paths must be native
+// ones, as returned by Find with the native file system.
+//
+// Right before each rename, both the first file and the duplicate are checked
+// to still be regular files of the size of the group and, if the group has
+// ModTimes, to still have the same modification time. Otherwise, the duplicate
+// is left untouched and ErrChanged is returned. This catches most changes made
+// since Find, but not a rewrite that keeps both the size and the time.
+//
+// Parameters:
+//   - g: The group of duplicates.
+//   - mode: How to replace the duplicates.
+//
+// Returns:
+//   - error: An error if a duplicate could not be replaced.
+//
+// Errors:
+//   - *errors.ErrBadParam: If the mode is unknown.
+//   - errors.ErrUnsupported: If reflinks are not supported on this platform.
+//   - ErrChanged: If a file changed since it was found.
+//   - any other error: If a duplicate could not be replaced, for example
+//     because the files are on different file systems.
+func Replace(g Group, mode LinkMode) error {
+	if mode != HardLink && mode != Reflink {
+		return gers.NewErrBadParam("mode", "must be HardLink or Reflink")
+	}
+
+	if len(g.Paths) < 2 {
+		return nil
+	} else if g.ModTimes != nil && len(g.ModTimes) != len(g.Paths) {
+		return gers.NewErrBadParam("g.ModTimes", "must have as many elements as g.Paths")
+	}
+
+	keep := g.Paths[0]
+
+	for i, dup := range g.Paths[1:] {
+		tmp := filepath.Join(filepath.Dir(dup), "."+filepath.Base(dup)+".dedup"+strconv.Itoa(os.Getpid()))
+
+		var err error
+
+		if mode == HardLink {
+			err = os.Link(keep, tmp)
+		} else {
+			err = cloneTo(keep, dup, tmp)
+		}
+
+		if err == nil {
+			err = errors.Join(g.unchanged(0), g.unchanged(i+1))
+		}
+
+		if err == nil {
+			err = os.Rename(tmp, dup)
+		}
+
+		if err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unchanged checks that a file of the group is still a regular file of the
+// size of the group with, if known, the same modification time.
+//
+// Parameters:
+//   - i: The index of the file in the group.
+//
+// Returns:
+//   - error: An error if the file changed or could not be inspected.
+//
+// Errors:
+//   - ErrChanged: If the file changed.
+//   - any other error: If the file could not be inspected.
+func (g Group) unchanged(i int) error {
+	info, err := os.Lstat(g.Paths[i])
+	if err != nil {
+		return err
+	}
+
+	ok := info.Mode().IsRegular() && info.Size() == g.Size
+	if ok && g.ModTimes != nil {
+		ok = info.ModTime().Equal(g.ModTimes[i])
+	}
+
+	if !ok {
+		return &fs.PathError{Op: "dedup", Path: g.Paths[i], Err: ErrChanged}
+	}
+
+	return nil
+}
+
+// cloneTo creates a reflink of src at tmp, with the mode of dup.
+//
+// Parameters:
+//   - src: The file to clone.
+//   - dup: The duplicate to take the mode of.
+//   - tmp: The path of the clone.
+//
+// Returns:
+//   - error: An error if the clone could not be made.
+func cloneTo(src, dup, tmp string) error {
+	info, err := os.Stat(dup)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	err = fmi.Reflink(out, in)
+	err = errors.Join(err, out.Close())
+
+	return err
+}
@@ -0,0 +1,106 @@
+package dedup
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/dedup/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// TestFind tests that files are grouped by size, then by partial hash, then by
+// full hash.
+func TestFind(t *testing.T) {
+	block := int(internal.BlockSize)
+
+	large := bytes.Repeat([]byte("x"), 3*block)
+
+	middle := bytes.Clone(large)
+	middle[len(middle)/2] = 'y'
+
+	files := map[string][]byte{
+		// Same size, different content: split by the partial hash.
+		"tree/size/a": []byte("aaaa"),
+		"tree/size/b": []byte("bbbb"),
+
+		// Same ends, different middle: split by the full hash only.
+		"tree/full/a": large,
+		"tree/full/b": middle,
+
+		// Duplicates.
+		"tree/small/a":   []byte("dup"),
+		"tree/small/b":   []byte("dup"),
+		"other/small/c":  []byte("dup"),
+		"tree/large/a":   large,
+		"tree/unique":    []byte("unique"),
+		"tree/too_small": nil,
+	}
+
+	fsys := vfs.NewMemory()
+
+	for _, dir := range []string{"tree/size", "tree/full", "tree/small", "tree/large", "other/small"} {
+		err := fsys.MkdirAll(dir, 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, data := range files {
+		err := vfs.WriteFile(fsys, name, data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []Group{
+		{Size: int64(len(large)), Paths: []string{"tree/full/a", "tree/large/a"}},
+		{Size: 3, Paths: []string{"other/small/c", "tree/small/a", "tree/small/b"}},
+	}
+
+	for _, verify := range []bool{false, true} {
+		// The trees overlap on purpose.
+		groups, err := Find(fsys, []string{"tree", "other", "tree/small"}, &Options{Verify: verify, Workers: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i := range groups {
+			if len(groups[i].ModTimes) != len(groups[i].Paths) {
+				t.Errorf("expected a modification time per path, got %v", groups[i].ModTimes)
+			}
+
+			groups[i].ModTimes = nil
+		}
+
+		if !reflect.DeepEqual(groups, want) {
+			t.Errorf("verify %t: expected %+v, got %+v", verify, want, groups)
+		}
+	}
+}
+
+// TestFindMinSize tests that small files are ignored.
+func TestFindMinSize(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("tree", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tree/a", "tree/b"} {
+		err := vfs.WriteFile(fsys, name, []byte("dup"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := Find(fsys, []string{"tree"}, &Options{MinSize: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(groups) != 0 {
+		t.Errorf("expected no group, got %+v", groups)
+	}
+}
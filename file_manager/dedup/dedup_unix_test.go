@@ -0,0 +1,163 @@
+//go:build unix
+
+package dedup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// nativeTree creates the given files under a temporary directory.
+func nativeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// sameFile checks whether both paths are the same file.
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	info_a, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info_b, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return os.SameFile(info_a, info_b)
+}
+
+// TestFindHardLinks tests that hard links to the same file are listed once.
+func TestFindHardLinks(t *testing.T) {
+	dir := nativeTree(t, map[string]string{"a": "dup", "b": "dup"})
+
+	err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "a2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := Find(nil, []string{dir}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+
+	if len(groups) != 1 || !reflect.DeepEqual(groups[0].Paths, want) {
+		t.Errorf("expected a single group of %q, got %+v", want, groups)
+	}
+}
+
+// TestReplaceHardLink tests that the duplicates become hard links to the
+// first file.
+func TestReplaceHardLink(t *testing.T) {
+	dir := nativeTree(t, map[string]string{"a": "dup", "b": "dup", "c": "dup"})
+
+	groups, err := Find(nil, []string{dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("expected a single group, got %+v", groups)
+	}
+
+	err = Replace(groups[0], HardLink)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"b", "c"} {
+		if !sameFile(t, filepath.Join(dir, "a"), filepath.Join(dir, name)) {
+			t.Errorf("expected %s to be a link to a", name)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Errorf("expected no temporary file to be left, got %d entries", len(entries))
+	}
+
+	groups, err = Find(nil, []string{dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 0 {
+		t.Errorf("expected no group once replaced, got %+v", groups)
+	}
+}
+
+// TestReplaceChanged tests that a file changed since Find is left untouched.
+func TestReplaceChanged(t *testing.T) {
+	tests := map[string]func(t *testing.T, name string){
+		"size": func(t *testing.T, name string) {
+			err := os.WriteFile(name, []byte("changed"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		},
+		"time": func(t *testing.T, name string) {
+			mtime := time.Now().Add(time.Hour)
+
+			err := os.Chtimes(name, mtime, mtime)
+			if err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+
+	for change, fn := range tests {
+		for _, target := range []string{"a", "b"} {
+			t.Run(change+" of "+target, func(t *testing.T) {
+				dir := nativeTree(t, map[string]string{"a": "dup", "b": "dup"})
+
+				groups, err := Find(nil, []string{dir}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				fn(t, filepath.Join(dir, target))
+
+				err = Replace(groups[0], HardLink)
+				if !errors.Is(err, ErrChanged) {
+					t.Fatalf("expected ErrChanged, got %v", err)
+				}
+
+				if sameFile(t, filepath.Join(dir, "a"), filepath.Join(dir, "b")) {
+					t.Errorf("expected b to be left untouched")
+				}
+
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(entries) != 2 {
+					t.Errorf("expected no temporary file to be left, got %d entries", len(entries))
+				}
+			})
+		}
+	}
+}
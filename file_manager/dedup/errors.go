@@ -0,0 +1,17 @@
+package dedup
+
+import "errors"
+
+var (
+	// ErrChanged occurs when a file of a group changed since it was found, so
+	// that it can no longer be trusted to be a duplicate. This error can be
+	// checked with errors.Is.
+	//
+	// Format:
+	// 	"file changed since it was found"
+	ErrChanged error
+)
+
+func init() {
+	ErrChanged = errors.New("file changed since it was found")
+}
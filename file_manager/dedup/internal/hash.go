@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+)
+
+// BlockSize is the size of the blocks read by PartialHash at both ends of a file.
+const BlockSize int64 = 4096
+
+// PartialHash hashes the first and the last BlockSize bytes of a file. For a
+// file no larger than two blocks, this is a hash of the whole content.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - name: The name of the file.
+//   - size: The size of the file.
+//
+// Returns:
+//   - string: The hex-encoded digest.
+//   - error: An error if the file could not be read.
+func PartialHash(fsys fs.FS, name string, size int64) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	if size <= 2*BlockSize {
+		_, err = io.Copy(h, f)
+	} else {
+		err = hashEnds(h, f, size)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	return digest, nil
+}
+
+// hashEnds writes the first and the last BlockSize bytes of a file to w.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - f: The open file.
+//   - size: The size of the file. Must be larger than 2*BlockSize.
+//
+// Returns:
+//   - error: An error if the file could not be read.
+func hashEnds(w io.Writer, f fs.File, size int64) error {
+	_, err := io.CopyN(w, f, BlockSize)
+	if err != nil {
+		return err
+	}
+
+	if seeker, ok := f.(io.Seeker); ok {
+		_, err = seeker.Seek(size-BlockSize, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, f, size-2*BlockSize)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(w, f, BlockSize)
+	return err
+}
+
+// Equal compares the content of two files byte by byte.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - a: The name of the first file.
+//   - b: The name of the second file.
+//
+// Returns:
+//   - bool: True if both files have the same content, false otherwise.
+//   - error: An error if a file could not be read.
+func Equal(fsys fs.FS, a, b string) (bool, error) {
+	fa, err := fsys.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+
+	fb, err := fsys.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	buf_a := make([]byte, 64*1024)
+	buf_b := make([]byte, 64*1024)
+
+	for {
+		na, err_a := io.ReadFull(fa, buf_a)
+		nb, err_b := io.ReadFull(fb, buf_b)
+
+		if !bytes.Equal(buf_a[:na], buf_b[:nb]) {
+			return false, nil
+		}
+
+		done_a := err_a == io.EOF || err_a == io.ErrUnexpectedEOF
+		done_b := err_b == io.EOF || err_b == io.ErrUnexpectedEOF
+
+		if err_a != nil && !done_a {
+			return false, err_a
+		} else if err_b != nil && !done_b {
+			return false, err_b
+		}
+
+		if done_a || done_b {
+			return done_a == done_b, nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+// TestPartialHash tests that PartialHash only looks at both ends of a file.
+func TestPartialHash(t *testing.T) {
+	big := bytes.Repeat([]byte{'a'}, int(3*BlockSize))
+
+	middle := bytes.Clone(big)
+	middle[BlockSize+1] = 'b'
+
+	end := bytes.Clone(big)
+	end[len(end)-1] = 'b'
+
+	fsys := fstest.MapFS{
+		"big":    {Data: big},
+		"middle": {Data: middle},
+		"end":    {Data: end},
+	}
+
+	hash := func(name string) string {
+		digest, err := PartialHash(fsys, name, int64(len(fsys[name].Data)))
+		if err != nil {
+			t.Fatalf("PartialHash(%q) = %v", name, err)
+		}
+
+		return digest
+	}
+
+	if hash("big") != hash("middle") {
+		t.Errorf("PartialHash looked at the middle of the file")
+	}
+
+	if hash("big") == hash("end") {
+		t.Errorf("PartialHash did not look at the end of the file")
+	}
+
+	ok, err := Equal(fsys, "big", "middle")
+	if err != nil || ok {
+		t.Errorf("Equal(big, middle) = %t, %v; want false, nil", ok, err)
+	}
+
+	ok, err = Equal(fsys, "big", "big")
+	if err != nil || !ok {
+		t.Errorf("Equal(big, big) = %t, %v; want true, nil", ok, err)
+	}
+}
@@ -0,0 +1,436 @@
+package du
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/du/internal"
+	fmi "github.com/PlayerR9/mygo-lib/file_manager/internal"
+	mio "github.com/PlayerR9/mygo-lib/writer"
+)
+
+// Options are the options of a disk usage computation.
+type Options struct {
+	// OneFileSystem skips the directories that are on another file system than
+	// the root, like "du -x". It has no effect when the device of the entries
+	// is not known.
+	OneFileSystem bool
+
+	// Workers is the maximum number of directories read concurrently. If
+	// zero, the number of CPUs is used.
+	Workers int
+}
+
+// Node is the disk usage of a directory and of everything below it.
+type Node struct {
+	// Name is the base name of the directory, or the root as given for the root.
+	Name string
+
+	// Path is the path of the directory in the file system.
+	Path string
+
+	// Apparent is the sum of the sizes of the entries, in bytes.
+	Apparent int64
+
+	// Allocated is the sum of the space allocated to the entries, in bytes.
+	// When the file system does not tell, it is the same as Apparent.
+	Allocated int64
+
+	// Files is the number of entries that are not directories.
+	Files int
+
+	// Children are the subdirectories.
+	Children []*Node
+}
+
+// fileID identifies a file across its hard links.
+type fileID struct {
+	// dev is the device the file is on.
+	dev uint64
+
+	// ino is the inode of the file.
+	ino uint64
+}
+
+// walker computes the disk usage of a tree.
+type walker struct {
+	// fsys is the file system to read from.
+	fsys fs.FS
+
+	// opts are the options of the computation.
+	opts Options
+
+	// sem bounds the number of directories read concurrently.
+	sem chan struct{}
+
+	// root_dev is the device of the root, if known.
+	root_dev uint64
+
+	// has_dev is whether root_dev is known.
+	has_dev bool
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// seen are the files with several hard links already counted.
+	seen map[fileID]struct{}
+
+	// errs are the errors met so far.
+	errs []error
+}
+
+// fail records an error.
+//
+// Parameters:
+//   - err: The error to record.
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.errs = append(w.errs, err)
+}
+
+// first checks whether a file is seen for the first time. Files whose identity
+// is not known are always seen for the first time.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - bool: True if the file must be counted, false otherwise.
+func (w *walker) first(info fs.FileInfo) bool {
+	dev, ino, ok := fmi.FileID(info)
+	if !ok {
+		return true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := fileID{dev: dev, ino: ino}
+
+	_, ok = w.seen[id]
+	if ok {
+		return false
+	}
+
+	w.seen[id] = struct{}{}
+
+	return true
+}
+
+// add adds the size of an entry to a node.
+//
+// Parameters:
+//   - n: The node to add to.
+//   - info: The information about the entry.
+func add(n *Node, info fs.FileInfo) {
+	n.Apparent += info.Size()
+
+	allocated, ok := fmi.FileBlocks(info)
+	if !ok {
+		allocated = info.Size()
+	}
+
+	n.Allocated += allocated
+}
+
+// sameDevice checks whether a directory is on the file system of the root.
+//
+// Parameters:
+//   - info: The information about the directory.
+//
+// Returns:
+//   - bool: False if the directory is known to be on another file system.
+func (w *walker) sameDevice(info fs.FileInfo) bool {
+	if !w.opts.OneFileSystem || !w.has_dev {
+		return true
+	}
+
+	dev, _, ok := fmi.FileID(info)
+	return !ok || dev == w.root_dev
+}
+
+// dir computes the disk usage of the directory of the node. Subdirectories are
+// read concurrently as long as the bound allows it, and in place otherwise.
+//
+// Parameters:
+//   - n: The node of the directory.
+func (w *walker) dir(n *Node) {
+	entries, err := fs.ReadDir(w.fsys, n.Path)
+	if err != nil {
+		w.fail(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			w.fail(err)
+			continue
+		}
+
+		if !entry.IsDir() {
+			n.Files++
+
+			ok := w.first(info)
+			if ok {
+				add(n, info)
+			}
+
+			continue
+		}
+
+		ok := w.sameDevice(info)
+		if !ok {
+			continue
+		}
+
+		child := &Node{
+			Name: entry.Name(),
+			Path: path.Join(n.Path, entry.Name()),
+		}
+
+		add(child, info)
+
+		n.Children = append(n.Children, child)
+
+		select {
+		case w.sem <- struct{}{}:
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				defer func() { <-w.sem }()
+
+				w.dir(child)
+			}()
+		default:
+			w.dir(child)
+		}
+	}
+
+	wg.Wait()
+
+	for _, child := range n.Children {
+		n.Apparent += child.Apparent
+		n.Allocated += child.Allocated
+		n.Files += child.Files
+	}
+}
+
+// Usage computes the disk usage of the tree rooted at root, like "du" does.
+// Hard links to the same file are only counted once, in whichever directory is
+// read first. Symbolic links are not followed.
+//
+// Unreadable entries are skipped rather than aborting the computation: the
+// tree is still returned, along with the errors met.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - root: The root of the tree. Must be a directory.
+//   - opts: The options of the computation. If nil, the defaults are used.
+//
+// Returns:
+//   - *Node: The disk usage of the root.
+//   - error: The errors met, joined.
+//
+// Errors:
+//   - *errors.ErrBadParam: If fsys is nil or root is not a directory. No tree is returned then.
+//   - any other error: If some entries could not be read.
+func Usage(fsys fs.FS, root string, opts *Options) (*Node, error) {
+	if fsys == nil {
+		return nil, gers.NewErrNilParam("fsys")
+	}
+
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, gers.NewErrBadParam("root", "must be a directory")
+	}
+
+	var o Options
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+
+	w := &walker{
+		fsys: fsys,
+		opts: o,
+		sem:  make(chan struct{}, o.Workers-1),
+		seen: make(map[fileID]struct{}),
+	}
+
+	w.root_dev, _, w.has_dev = fmi.FileID(info)
+
+	n := &Node{
+		Name: root,
+		Path: root,
+	}
+
+	add(n, info)
+
+	w.dir(n)
+
+	err = errors.Join(w.errs...)
+	return n, err
+}
+
+// SortKey is the key Sort orders the children by.
+type SortKey int
+
+const (
+	// ByName orders by name, in lexical order.
+	ByName SortKey = iota
+
+	// ByApparent orders by apparent size, the largest first.
+	ByApparent
+
+	// ByAllocated orders by allocated size, the largest first.
+	ByAllocated
+)
+
+// Sort orders the children of the node, and theirs, by the given key. Ties
+// are broken by name.
+//
+// Parameters:
+//   - key: The key to order by.
+func (n *Node) Sort(key SortKey) {
+	if n == nil {
+		return
+	}
+
+	slices.SortFunc(n.Children, func(a, b *Node) int {
+		var c int
+
+		switch key {
+		case ByApparent:
+			c = cmp.Compare(b.Apparent, a.Apparent)
+		case ByAllocated:
+			c = cmp.Compare(b.Allocated, a.Allocated)
+		}
+
+		if c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, child := range n.Children {
+		child.Sort(key)
+	}
+}
+
+// RenderOptions are the options of Render.
+type RenderOptions struct {
+	// MaxDepth is the number of levels of subdirectories rendered below the
+	// root. If zero, every level is rendered.
+	MaxDepth int
+
+	// Human formats the sizes the way "du -h" does instead of in bytes.
+	Human bool
+
+	// Apparent renders the apparent sizes instead of the allocated ones.
+	Apparent bool
+}
+
+// Render writes the tree, one directory per line, each followed by its
+// subdirectories indented by two spaces.
+//
+// Parameters:
+//   - w: The writer to write to. Must not be nil.
+//   - opts: The options of the rendering. If nil, every directory is rendered in bytes.
+//
+// Returns:
+//   - error: An error if the writer fails.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - writer.ErrNoWriter: If w is nil.
+//   - any other error: If the writer fails.
+//
+// Format:
+//
+//	"<size>\t<indent><name>\n"
+func (n *Node) Render(w mio.Writer, opts *RenderOptions) error {
+	if n == nil {
+		return gers.ErrNilReceiver
+	} else if w == nil {
+		return mio.ErrNoWriter
+	}
+
+	var o RenderOptions
+
+	if opts != nil {
+		o = *opts
+	}
+
+	err := n.render(w, &o, 0)
+	return err
+}
+
+// render writes the node and its children.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - opts: The options of the rendering.
+//   - depth: The depth of the node.
+//
+// Returns:
+//   - error: An error if the writer fails.
+func (n *Node) render(w mio.Writer, opts *RenderOptions, depth int) error {
+	size := n.Allocated
+	if opts.Apparent {
+		size = n.Apparent
+	}
+
+	var text string
+
+	if opts.Human {
+		text = internal.FormatSize(size)
+	} else {
+		text = strconv.FormatInt(size, 10)
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(text)
+	_ = builder.WriteByte('\t')
+	_, _ = builder.WriteString(strings.Repeat("  ", depth))
+	_, _ = builder.WriteString(n.Name)
+	_ = builder.WriteByte('\n')
+
+	err := mio.WriteString(w, builder.String())
+	if err != nil {
+		return err
+	}
+
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		return nil
+	}
+
+	for _, child := range n.Children {
+		err := child.render(w, opts, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package du
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestUsageMapFS tests the sizes and the counts of a tree without file
+// identities, which counts every file.
+func TestUsageMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"root/a":          {Data: make([]byte, 10)},
+		"root/big/b":      {Data: make([]byte, 100)},
+		"root/big/deep/c": {Data: make([]byte, 1000)},
+		"root/small/d":    {Data: make([]byte, 1)},
+		"root/empty":      {Mode: fs.ModeDir | 0o755},
+	}
+
+	n, err := Usage(fsys, "root", &Options{Workers: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n.Apparent != 1111 || n.Allocated != 1111 || n.Files != 4 {
+		t.Errorf("expected 1111 bytes in 4 files, got %d (%d allocated) in %d files", n.Apparent, n.Allocated, n.Files)
+	}
+
+	n.Sort(ByApparent)
+
+	var names []string
+
+	for _, child := range n.Children {
+		names = append(names, child.Name)
+	}
+
+	if strings.Join(names, ",") != "big,small,empty" {
+		t.Errorf("expected big,small,empty, got %v", names)
+	}
+
+	var out strings.Builder
+
+	err = n.Render(&out, &RenderOptions{MaxDepth: 1, Apparent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "1111\troot\n1100\t  big\n1\t  small\n0\t  empty\n"
+
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+// TestUsageNotDir tests that the root must be a directory.
+func TestUsageNotDir(t *testing.T) {
+	fsys := fstest.MapFS{"file": {Data: []byte("x")}}
+
+	_, err := Usage(fsys, "file", nil)
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+}
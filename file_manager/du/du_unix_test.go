@@ -0,0 +1,57 @@
+//go:build unix
+
+package du
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestUsageHardLinks tests that hard links to the same file are counted once.
+func TestUsageHardLinks(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "a"), make([]byte, 5000), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a2", "sub/a3"} {
+		err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dirs int64
+
+	for _, name := range []string{".", "sub"} {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dirs += info.Size()
+	}
+
+	for _, workers := range []int{1, 4} {
+		n, err := Usage(os.DirFS(dir), ".", &Options{Workers: workers})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n.Apparent != dirs+5000 {
+			t.Errorf("workers %d: expected %d bytes, got %d", workers, dirs+5000, n.Apparent)
+		}
+
+		if n.Files != 3 {
+			t.Errorf("workers %d: expected 3 files, got %d", workers, n.Files)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"strconv"
+)
+
+// FormatSize formats a size in bytes the way "du -h" does: with one decimal
+// below 10 and none above, rounded up, in powers of 1024.
+//
+// Parameters:
+//   - size: The size, in bytes.
+//
+// Returns:
+//   - string: The formatted size, such as "512", "1.5K" or "12M".
+func FormatSize(size int64) string {
+	const units = "KMGTPE"
+
+	if size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+
+	value := float64(size)
+
+	idx := -1
+
+	for idx+1 < len(units) && value >= 1024 {
+		value /= 1024
+		idx++
+	}
+
+	unit := units[idx]
+
+	if value < 10 {
+		tenths := int64(value * 10)
+		if float64(tenths) < value*10 {
+			tenths++
+		}
+
+		if tenths < 100 {
+			return strconv.FormatInt(tenths/10, 10) + "." + strconv.FormatInt(tenths%10, 10) + string(unit)
+		}
+
+		value = 10
+	}
+
+	whole := int64(value)
+	if float64(whole) < value {
+		whole++
+	}
+
+	if whole >= 1024 && idx+1 < len(units) {
+		return "1.0" + string(units[idx+1])
+	}
+
+	return strconv.FormatInt(whole, 10) + string(unit)
+}
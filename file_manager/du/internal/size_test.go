@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"testing"
+)
+
+// TestFormatSize tests the FormatSize function.
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0",
+		1023:            "1023",
+		1024:            "1.0K",
+		1536:            "1.5K",
+		1537:            "1.6K",
+		10 * 1024:       "10K",
+		10*1024 + 1:     "11K",
+		1024*1024 - 1:   "1.0M",
+		3 * 1024 * 1024: "3.0M",
+		5 << 40:         "5.0T",
+		10*1024 - 1:     "10K",
+		9*1024 + 1024/2: "9.5K",
+	}
+
+	for size, want := range tests {
+		got := FormatSize(size)
+		if got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
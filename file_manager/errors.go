@@ -0,0 +1,127 @@
+package file_manager
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrLocked occurs when a lock is held by someone else. This error can be
+	// checked with errors.Is.
+	//
+	// Format:
+	// 	"lock is held by someone else"
+	ErrLocked error
+)
+
+func init() {
+	ErrLocked = errors.New("lock is held by someone else")
+}
+
+// ErrLockHeld occurs when a lock file is held by another process.
+type ErrLockHeld struct {
+	// Path is the path of the lock file.
+	Path string
+
+	// PID is the PID of the owner, or 0 if it is not known.
+	PID int
+
+	// Hostname is the host the owner runs on, if known.
+	Hostname string
+}
+
+// Error implements error.
+func (e ErrLockHeld) Error() string {
+	msg := "lock " + strconv.Quote(e.Path) + " is held"
+
+	if e.PID == 0 {
+		return msg + " by someone else"
+	}
+
+	msg += " by pid " + strconv.Itoa(e.PID)
+
+	if e.Hostname != "" {
+		msg += " on " + e.Hostname
+	}
+
+	return msg
+}
+
+// NewErrLockHeld creates a new ErrLockHeld error.
+//
+// Parameters:
+//   - path: The path of the lock file.
+//   - pid: The PID of the owner, or 0 if it is not known.
+//   - hostname: The host the owner runs on, if known.
+//
+// Returns:
+//   - error: An instance of ErrLockHeld. Never returns nil.
+//
+// Format:
+//
+//	"lock <path> is held by pid <pid> on <hostname>"
+//
+// Where:
+//   - <path> is the quoted path of the lock file.
+//   - <pid> is the PID of the owner. If 0, "by someone else" replaces the whole owner part.
+//   - <hostname> is the host of the owner. If empty, the " on <hostname>" part is omitted.
+func NewErrLockHeld(path string, pid int, hostname string) error {
+	e := &ErrLockHeld{
+		Path:     path,
+		PID:      pid,
+		Hostname: hostname,
+	}
+
+	return e
+}
+
+// Is implements the errors.Is interface.
+//
+// Parameters:
+//   - target: The error to compare against.
+//
+// Returns:
+//   - bool: True if target is ErrLocked, false otherwise.
+func (e ErrLockHeld) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// ErrEscape occurs when a path resolves outside of the root it must stay in,
+// either through ".." elements or through symbolic links.
+type ErrEscape struct {
+	// Root is the root the path must stay in.
+	Root string
+
+	// Path is the offending path.
+	Path string
+}
+
+// Error implements error.
+func (e ErrEscape) Error() string {
+	return "path " + strconv.Quote(e.Path) + " escapes root " + strconv.Quote(e.Root)
+}
+
+// NewErrEscape creates a new ErrEscape error.
+//
+// Parameters:
+//   - root: The root the path must stay in.
+//   - path: The offending path.
+//
+// Returns:
+//   - error: An instance of ErrEscape. Never returns nil.
+//
+// Format:
+//
+//	"path <path> escapes root <root>"
+//
+// Where:
+//   - <path> is the quoted offending path.
+//   - <root> is the quoted root.
+func NewErrEscape(root, path string) error {
+	e := &ErrEscape{
+		Root: root,
+		Path: path,
+	}
+
+	return e
+}
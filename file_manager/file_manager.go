@@ -2,24 +2,45 @@ package file_manager
 
 import (
 	"errors"
-	"os"
+	"io/fs"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
 )
 
-// Exists checks if the given location exists.
+// orOS returns the given file system, or the native one if it is nil.
 //
 // Parameters:
+//   - fsys: The file system to use.
+//
+// Returns:
+//   - vfs.FS: The file system to use. Never returns nil.
+func orOS(fsys vfs.FS) vfs.FS {
+	if fsys == nil {
+		return vfs.OS{}
+	}
+
+	return fsys
+}
+
+// Exists checks if the given location exists. It follows symbolic links, so a
+// broken link does not exist; use Inspect to also learn what the location is.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
 //   - loc: The location to check.
 //
 // Returns:
 //   - bool: True if the location exists, false otherwise.
 //   - error: An error if something went wrong.
-func Exists(loc string) (bool, error) {
-	_, err := os.Stat(loc)
+func Exists(fsys vfs.FS, loc string) (bool, error) {
+	fsys = orOS(fsys)
+
+	_, err := fsys.Stat(loc)
 	if err == nil {
 		return true, nil
 	}
 
-	ok := errors.Is(err, os.ErrNotExist)
+	ok := errors.Is(err, fs.ErrNotExist)
 	if !ok {
 		return false, err
 	}
@@ -30,6 +51,7 @@ func Exists(loc string) (bool, error) {
 // CreateDirectory creates a directory at the given location with the given mode.
 //
 // Parameters:
+//   - fsys: The file system to create the directory in. If nil, the native file system is used.
 //   - loc: The location to create the directory.
 //   - mode: The file mode to use when creating the directory.
 //   - force: If true, the directory will be overwritten if it already exists.
@@ -38,17 +60,19 @@ func Exists(loc string) (bool, error) {
 //   - error: An error if the directory cannot be created.
 //
 // Errors:
-//   - os.ErrExist: If the directory already exists and force is false.
+//   - fs.ErrExist: If the directory already exists and force is false.
 //   - any other error: If the directory cannot be created.
-func CreateDirectory(loc string, mode os.FileMode, force bool) error {
-	_, err := os.Stat(loc)
+func CreateDirectory(fsys vfs.FS, loc string, mode fs.FileMode, force bool) error {
+	fsys = orOS(fsys)
+
+	_, err := fsys.Stat(loc)
 	if err != nil {
-		ok := errors.Is(err, os.ErrNotExist)
+		ok := errors.Is(err, fs.ErrNotExist)
 		if !ok {
 			return err
 		}
 
-		if err := os.Mkdir(loc, mode); err != nil {
+		if err := fsys.Mkdir(loc, mode); err != nil {
 			return err
 		}
 
@@ -56,14 +80,14 @@ func CreateDirectory(loc string, mode os.FileMode, force bool) error {
 	}
 
 	if !force {
-		return os.ErrExist
+		return fs.ErrExist
 	}
 
-	if err := os.RemoveAll(loc); err != nil {
+	if err := fsys.RemoveAll(loc); err != nil {
 		return err
 	}
 
-	if err := os.Mkdir(loc, mode); err != nil {
+	if err := fsys.Mkdir(loc, mode); err != nil {
 		return err
 	}
 
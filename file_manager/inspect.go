@@ -0,0 +1,318 @@
+package file_manager
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strconv"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// Kind is the kind of an entry of a file system.
+type Kind int
+
+const (
+	// NotExist is the kind of a path that does not exist.
+	NotExist Kind = iota
+
+	// Regular is the kind of a regular file.
+	Regular
+
+	// Directory is the kind of a directory.
+	Directory
+
+	// Symlink is the kind of a symbolic link whose target exists.
+	Symlink
+
+	// BrokenSymlink is the kind of a symbolic link whose target does not exist.
+	BrokenSymlink
+
+	// Other is the kind of any other entry (device, socket, named pipe, ...).
+	Other
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case NotExist:
+		return "not exist"
+	case Regular:
+		return "regular"
+	case Directory:
+		return "directory"
+	case Symlink:
+		return "symlink"
+	case BrokenSymlink:
+		return "broken symlink"
+	case Other:
+		return "other"
+	default:
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// kindOf returns the kind of an entry with the given mode. Symbolic links are
+// reported as Symlink.
+//
+// Parameters:
+//   - mode: The mode of the entry.
+//
+// Returns:
+//   - Kind: The kind of the entry.
+func kindOf(mode fs.FileMode) Kind {
+	switch {
+	case mode.IsRegular():
+		return Regular
+	case mode.IsDir():
+		return Directory
+	case mode&fs.ModeSymlink != 0:
+		return Symlink
+	default:
+		return Other
+	}
+}
+
+// Info describes an entry of a file system, as seen by a single Lstat call
+// and, for a symbolic link, a single Stat call.
+type Info struct {
+	// Path is the path that was inspected.
+	Path string
+
+	// Kind is the kind of the entry itself. A symbolic link is either a
+	// Symlink or a BrokenSymlink.
+	Kind Kind
+
+	// Resolved is the kind of the entry once symbolic links are followed. It is
+	// the same as Kind for anything else than a symbolic link, and NotExist for
+	// a broken one.
+	Resolved Kind
+
+	// Target is the target of a symbolic link, as stored in the link.
+	Target string
+
+	// Size is the size of the entry once symbolic links are followed, or the
+	// size of the link itself if it is broken.
+	Size int64
+
+	// Mode is the mode of the entry once symbolic links are followed, or the
+	// mode of the link itself if it is broken.
+	Mode fs.FileMode
+
+	// ModTime is the modification time of the entry once symbolic links are
+	// followed, or the one of the link itself if it is broken.
+	ModTime time.Time
+
+	// UID is the user ID of the owner, or -1 if it is not known.
+	UID int
+
+	// GID is the group ID of the owner, or -1 if it is not known.
+	GID int
+}
+
+// Exists checks whether the path exists. A broken symbolic link exists.
+//
+// Returns:
+//   - bool: True if the path exists, false otherwise.
+func (i Info) Exists() bool {
+	return i.Kind != NotExist
+}
+
+// fill sets the fields of the information that come from a file info.
+//
+// Parameters:
+//   - fi: The file info to read from.
+func (i *Info) fill(fi fs.FileInfo) {
+	i.Size = fi.Size()
+	i.Mode = fi.Mode()
+	i.ModTime = fi.ModTime()
+	i.UID, i.GID, _ = internal.FileOwner(fi)
+}
+
+// whileInspecting wraps an error so that it names the inspected path.
+//
+// Parameters:
+//   - loc: The inspected path.
+//   - err: The error to wrap.
+//
+// Returns:
+//   - error: The wrapped error.
+func whileInspecting(loc string, err error) error {
+	return gers.NewErrWhile("inspecting "+strconv.Quote(loc), err)
+}
+
+// Inspect describes the given path without following a trailing symbolic link,
+// so that the kind, the target and the attributes are all read at once
+// instead of through successive, racy checks. A path that does not exist is
+// not an error: its kind is NotExist.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - loc: The path to inspect.
+//
+// Returns:
+//   - Info: The description of the path.
+//   - error: An error if the path could not be inspected.
+//
+// Errors:
+//   - *errors.ErrWhile: If the path could not be inspected, for another reason
+//     than not existing. It names the path and wraps the cause.
+func Inspect(fsys vfs.FS, loc string) (Info, error) {
+	fsys = orOS(fsys)
+
+	info := Info{
+		Path: loc,
+		UID:  -1,
+		GID:  -1,
+	}
+
+	fi, err := fsys.Lstat(loc)
+	if errors.Is(err, fs.ErrNotExist) {
+		return info, nil
+	} else if err != nil {
+		return info, whileInspecting(loc, err)
+	}
+
+	info.Kind = kindOf(fi.Mode())
+	info.Resolved = info.Kind
+
+	if info.Kind != Symlink {
+		info.fill(fi)
+		return info, nil
+	}
+
+	info.Target, err = fsys.ReadLink(loc)
+	if err != nil {
+		return info, whileInspecting(loc, err)
+	}
+
+	target, err := fsys.Stat(loc)
+	if errors.Is(err, fs.ErrNotExist) {
+		info.Kind = BrokenSymlink
+		info.Resolved = NotExist
+		info.fill(fi)
+
+		return info, nil
+	} else if err != nil {
+		return info, whileInspecting(loc, err)
+	}
+
+	info.Resolved = kindOf(target.Mode())
+	info.fill(target)
+
+	return info, nil
+}
+
+// IsDir checks whether the path is a directory, following symbolic links.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - loc: The path to check.
+//
+// Returns:
+//   - bool: True if the path is a directory, false otherwise.
+//   - error: An error if the path could not be inspected.
+//
+// Errors:
+//   - *errors.ErrWhile: If the path could not be inspected.
+func IsDir(fsys vfs.FS, loc string) (bool, error) {
+	info, err := Inspect(fsys, loc)
+	if err != nil {
+		return false, err
+	}
+
+	return info.Resolved == Directory, nil
+}
+
+// IsRegular checks whether the path is a regular file, following symbolic links.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - loc: The path to check.
+//
+// Returns:
+//   - bool: True if the path is a regular file, false otherwise.
+//   - error: An error if the path could not be inspected.
+//
+// Errors:
+//   - *errors.ErrWhile: If the path could not be inspected.
+func IsRegular(fsys vfs.FS, loc string) (bool, error) {
+	info, err := Inspect(fsys, loc)
+	if err != nil {
+		return false, err
+	}
+
+	return info.Resolved == Regular, nil
+}
+
+// IsExecutable checks whether the path is a regular file, following symbolic
+// links, with any of the execute permission bits set. On platforms that do not
+// have such bits, such as Windows, this is always false.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - loc: The path to check.
+//
+// Returns:
+//   - bool: True if the path is an executable file, false otherwise.
+//   - error: An error if the path could not be inspected.
+//
+// Errors:
+//   - *errors.ErrWhile: If the path could not be inspected.
+func IsExecutable(fsys vfs.FS, loc string) (bool, error) {
+	info, err := Inspect(fsys, loc)
+	if err != nil {
+		return false, err
+	}
+
+	ok := info.Resolved == Regular && info.Mode&0o111 != 0
+	return ok, nil
+}
+
+// IsEmptyDir checks whether the path is a directory, following symbolic
+// links, that has no entry. Only the first entry is read, whatever the size of
+// the directory.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - loc: The path to check.
+//
+// Returns:
+//   - bool: True if the path is an empty directory, false otherwise.
+//   - error: An error if the path could not be inspected or read.
+//
+// Errors:
+//   - *errors.ErrWhile: If the path could not be inspected or read.
+func IsEmptyDir(fsys vfs.FS, loc string) (bool, error) {
+	fsys = orOS(fsys)
+
+	info, err := Inspect(fsys, loc)
+	if err != nil {
+		return false, err
+	} else if info.Resolved != Directory {
+		return false, nil
+	}
+
+	f, err := fsys.Open(loc)
+	if err != nil {
+		return false, whileInspecting(loc, err)
+	}
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return false, whileInspecting(loc, &fs.PathError{Op: "readdir", Path: loc, Err: errors.ErrUnsupported})
+	}
+
+	entries, err := dir.ReadDir(1)
+	if err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, whileInspecting(loc, err)
+	}
+
+	return len(entries) == 0, nil
+}
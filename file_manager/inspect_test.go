@@ -0,0 +1,167 @@
+package file_manager
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// inspectTree creates, under root, the entries checked by the inspect tests.
+func inspectTree(t *testing.T, fsys vfs.FS, root string) {
+	t.Helper()
+
+	for _, dir := range []string{"empty", "full"} {
+		err := fsys.MkdirAll(path.Join(root, dir), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]struct {
+		data string
+		perm fs.FileMode
+	}{
+		"full/file": {"content", 0o644},
+		"exe":       {"#!/bin/sh\n", 0o755},
+	}
+
+	for name, f := range files {
+		loc := path.Join(root, name)
+
+		err := vfs.WriteFile(fsys, loc, []byte(f.data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = fsys.Chmod(loc, f.perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"to_file":  "full/file",
+		"to_dir":   "empty",
+		"to_exe":   "exe",
+		"broken":   "missing",
+		"to_links": "to_dir",
+	}
+
+	for name, target := range links {
+		err := fsys.Symlink(target, path.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkInspect checks Inspect and the predicates against the tree of
+// inspectTree.
+func checkInspect(t *testing.T, fsys vfs.FS, root string) {
+	t.Helper()
+
+	inspectTree(t, fsys, root)
+
+	tests := map[string]struct {
+		kind, resolved                      Kind
+		target                              string
+		dir, regular, executable, empty_dir bool
+	}{
+		"missing":   {kind: NotExist, resolved: NotExist},
+		"empty":     {kind: Directory, resolved: Directory, dir: true, empty_dir: true},
+		"full":      {kind: Directory, resolved: Directory, dir: true},
+		"full/file": {kind: Regular, resolved: Regular, regular: true},
+		"exe":       {kind: Regular, resolved: Regular, regular: true, executable: true},
+		"to_file":   {kind: Symlink, resolved: Regular, target: "full/file", regular: true},
+		"to_dir":    {kind: Symlink, resolved: Directory, target: "empty", dir: true, empty_dir: true},
+		"to_exe":    {kind: Symlink, resolved: Regular, target: "exe", regular: true, executable: true},
+		"to_links":  {kind: Symlink, resolved: Directory, target: "to_dir", dir: true, empty_dir: true},
+		"broken":    {kind: BrokenSymlink, resolved: NotExist, target: "missing"},
+	}
+
+	for name, tt := range tests {
+		loc := path.Join(root, name)
+
+		info, err := Inspect(fsys, loc)
+		if err != nil {
+			t.Errorf("Inspect(%q): unexpected error: %v", name, err)
+			continue
+		}
+
+		if info.Kind != tt.kind || info.Resolved != tt.resolved || info.Target != tt.target {
+			t.Errorf("Inspect(%q): expected %v, %v and %q, got %v, %v and %q", name, tt.kind, tt.resolved, tt.target, info.Kind, info.Resolved, info.Target)
+		}
+
+		if info.Exists() != (tt.kind != NotExist) {
+			t.Errorf("Inspect(%q).Exists() = %t", name, info.Exists())
+		}
+
+		if name == "to_file" && info.Size != int64(len("content")) {
+			t.Errorf("Inspect(%q): expected the size of the target, got %d", name, info.Size)
+		}
+
+		predicates := map[string]struct {
+			fn   func(vfs.FS, string) (bool, error)
+			want bool
+		}{
+			"IsDir":        {IsDir, tt.dir},
+			"IsRegular":    {IsRegular, tt.regular},
+			"IsExecutable": {IsExecutable, tt.executable && runtime.GOOS != "windows"},
+			"IsEmptyDir":   {IsEmptyDir, tt.empty_dir},
+		}
+
+		for pred_name, pred := range predicates {
+			got, err := pred.fn(fsys, loc)
+			if err != nil {
+				t.Errorf("%s(%q): unexpected error: %v", pred_name, name, err)
+			} else if got != pred.want {
+				t.Errorf("%s(%q): expected %t, got %t", pred_name, name, pred.want, got)
+			}
+		}
+	}
+}
+
+// TestInspectMemory tests Inspect against an in-memory file system.
+func TestInspectMemory(t *testing.T) {
+	checkInspect(t, vfs.NewMemory(), "root")
+}
+
+// TestInspectNative tests Inspect against the native file system.
+func TestInspectNative(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+
+	checkInspect(t, vfs.OS{}, filepath.ToSlash(t.TempDir()))
+}
+
+// TestInspectError tests that a failure other than a missing path names it.
+func TestInspectError(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	injected := errors.New("injected")
+
+	err := fsys.InjectFault("x", "lstat", injected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Inspect(fsys, "x")
+
+	var while *gers.ErrWhile
+
+	if !errors.As(err, &while) || !errors.Is(err, injected) {
+		t.Errorf("expected an *errors.ErrWhile wrapping the fault, got %v", err)
+	}
+
+	_, err = IsEmptyDir(fsys, "x")
+	if !errors.Is(err, injected) {
+		t.Errorf("expected the fault, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package internal
+
+import "errors"
+
+var (
+	// ErrWouldBlock occurs when a lock cannot be acquired without waiting.
+	//
+	// Format:
+	// 	"lock is held by someone else"
+	ErrWouldBlock error
+
+	// ErrEscapes occurs when a path resolves outside of its root.
+	//
+	// Format:
+	// 	"path escapes its root"
+	ErrEscapes error
+)
+
+func init() {
+	ErrWouldBlock = errors.New("lock is held by someone else")
+	ErrEscapes = errors.New("path escapes its root")
+}
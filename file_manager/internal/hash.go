@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// Parallel calls fn for every index in [0, n) with the given number of
+// workers.
+//
+// Parameters:
+//   - n: The number of calls.
+//   - workers: The number of concurrent calls. Must be positive.
+//   - fn: The function to call. Must not be nil.
+//
+// Returns:
+//   - []error: The error of each call, in order.
+func Parallel(n, workers int, fn func(i int) error) []error {
+	errs := make([]error, n)
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, n) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := range n {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// HashFile streams the named file through a new hash.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - name: The name of the file.
+//   - newHash: The constructor of the hash. Must not be nil.
+//
+// Returns:
+//   - string: The lowercase hex-encoded digest.
+//   - error: An error if the file could not be read.
+func HashFile(fsys fs.FS, name string, newHash func() hash.Hash) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := newHash()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	return digest, nil
+}
+
+// HashFiles hashes every named file with the given number of workers. Every
+// file is attempted and the error of each one is reported separately.
+//
+// Parameters:
+//   - fsys: The file system to read from. Must not be nil.
+//   - names: The names of the files.
+//   - newHash: The constructor of the hash. Must not be nil.
+//   - workers: The number of files hashed concurrently. Must be positive.
+//
+// Returns:
+//   - []string: The hex-encoded digests, in the same order as names.
+//   - []error: The error of each file, in the same order as names.
+func HashFiles(fsys fs.FS, names []string, newHash func() hash.Hash, workers int) ([]string, []error) {
+	digests := make([]string, len(names))
+
+	errs := Parallel(len(names), workers, func(i int) error {
+		digest, err := HashFile(fsys, names[i], newHash)
+		digests[i] = digest
+
+		return err
+	})
+
+	return digests, errs
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// TestHashFiles tests that every file is hashed and errors are reported per file.
+func TestHashFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a": {Data: []byte("hello")},
+		"b": {Data: []byte("")},
+	}
+
+	digests, errs := HashFiles(fsys, []string{"a", "missing", "b"}, sha256.New, 2)
+
+	want := []string{
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		"",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+
+	for i := range want {
+		if digests[i] != want[i] {
+			t.Errorf("file %d: expected %s, got %s", i, want[i], digests[i])
+		}
+	}
+
+	if errs[0] != nil || errs[2] != nil || !errors.Is(errs[1], fs.ErrNotExist) {
+		t.Errorf("expected only the missing file to fail, got %v", errs)
+	}
+}
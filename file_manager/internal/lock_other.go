@@ -0,0 +1,47 @@
+//go:build !unix
+
+package internal
+
+import (
+	"errors"
+	"os"
+)
+
+// Flock places an advisory lock on the given file. It is not supported on this
+// platform.
+//
+// Parameters:
+//   - f: The file to lock. Must not be nil.
+//   - exclusive: Whether the lock is exclusive or shared.
+//   - block: Whether to wait for the lock to be available.
+//
+// Returns:
+//   - error: Always an error wrapping errors.ErrUnsupported.
+func Flock(f *os.File, exclusive, block bool) error {
+	return &os.PathError{Op: "flock", Path: f.Name(), Err: errors.ErrUnsupported}
+}
+
+// Funlock releases the advisory lock placed on the given file. It is not
+// supported on this platform.
+//
+// Parameters:
+//   - f: The file to unlock. Must not be nil.
+//
+// Returns:
+//   - error: Always an error wrapping errors.ErrUnsupported.
+func Funlock(f *os.File) error {
+	return &os.PathError{Op: "flock", Path: f.Name(), Err: errors.ErrUnsupported}
+}
+
+// ProcessAlive checks whether a process with the given PID exists on this host.
+// Since this cannot be told on this platform, any positive PID is considered
+// alive.
+//
+// Parameters:
+//   - pid: The PID of the process.
+//
+// Returns:
+//   - bool: True if the PID is positive, false otherwise.
+func ProcessAlive(pid int) bool {
+	return pid > 0
+}
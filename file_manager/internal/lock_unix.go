@@ -0,0 +1,82 @@
+//go:build unix
+
+package internal
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Flock places an advisory lock on the given file with flock(2).
+//
+// Parameters:
+//   - f: The file to lock. Must not be nil.
+//   - exclusive: Whether the lock is exclusive or shared.
+//   - block: Whether to wait for the lock to be available.
+//
+// Returns:
+//   - error: An error if the lock could not be placed.
+//
+// Errors:
+//   - ErrWouldBlock: If block is false and the lock is held by someone else.
+//   - any other error: If the lock could not be placed.
+func Flock(f *os.File, exclusive, block bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return ErrWouldBlock
+		}
+
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+}
+
+// Funlock releases the advisory lock placed on the given file.
+//
+// Parameters:
+//   - f: The file to unlock. Must not be nil.
+//
+// Returns:
+//   - error: An error if the lock could not be released.
+func Funlock(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+
+	return nil
+}
+
+// ProcessAlive checks whether a process with the given PID exists on this host.
+//
+// Parameters:
+//   - pid: The PID of the process.
+//
+// Returns:
+//   - bool: False if the process is known not to exist, true otherwise.
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, 0)
+	return !errors.Is(err, syscall.ESRCH)
+}
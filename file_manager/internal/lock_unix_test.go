@@ -0,0 +1,94 @@
+//go:build unix
+
+package internal
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// lockHelperEnv is the environment variable that turns the test binary into a
+// helper process holding a lock.
+const lockHelperEnv string = "MYGO_LOCK_HELPER"
+
+// TestLockHelper is not a real test: when run as a helper process, it locks the
+// given file, reports it on stdout and holds the lock until stdin is closed.
+func TestLockHelper(t *testing.T) {
+	path := os.Getenv(lockHelperEnv)
+	if path == "" {
+		t.Skip("only run as a helper process")
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	err = Flock(f, true, true)
+	if err != nil {
+		os.Exit(3)
+	}
+
+	_, _ = os.Stdout.WriteString("locked\n")
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+
+	os.Exit(0)
+}
+
+// TestFlock tests that a lock held by another process is honored.
+func TestFlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelper$")
+	cmd.Env = append(os.Environ(), lockHelperEnv+"="+path)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		t.Fatalf("helper did not lock: %q, %v", line, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	err = Flock(f, false, false)
+	if err != ErrWouldBlock {
+		t.Errorf("expected ErrWouldBlock, got %v", err)
+	}
+
+	_ = stdin.Close()
+
+	err = cmd.Wait()
+	if err != nil {
+		t.Fatalf("helper failed: %v", err)
+	}
+
+	err = Flock(f, true, false)
+	if err != nil {
+		t.Errorf("expected the lock to be released, got %v", err)
+	}
+
+	if ProcessAlive(cmd.Process.Pid) {
+		t.Errorf("expected helper process to be dead")
+	}
+}
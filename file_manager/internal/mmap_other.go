@@ -0,0 +1,32 @@
+//go:build !unix
+
+package internal
+
+import (
+	"errors"
+	"os"
+)
+
+// Mmap maps the given file in memory. It is not supported on this platform.
+//
+// Parameters:
+//   - f: The file to map.
+//   - size: The size of the file.
+//
+// Returns:
+//   - []byte: Always nil.
+//   - error: Always an error wrapping errors.ErrUnsupported.
+func Mmap(f *os.File, size int) ([]byte, error) {
+	return nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: errors.ErrUnsupported}
+}
+
+// Munmap releases a mapping made by Mmap. It is not supported on this platform.
+//
+// Parameters:
+//   - data: The mapping.
+//
+// Returns:
+//   - error: Always errors.ErrUnsupported.
+func Munmap(data []byte) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package internal
+
+import (
+	"os"
+	"syscall"
+)
+
+// Mmap maps the given file in memory, read-only.
+//
+// Parameters:
+//   - f: The file to map. Must be open for reading.
+//   - size: The size of the file. Must be positive.
+//
+// Returns:
+//   - []byte: The mapping. Writing to it crashes the program.
+//   - error: An error if the file could not be mapped.
+func Mmap(f *os.File, size int) ([]byte, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: err}
+	}
+
+	return data, nil
+}
+
+// Munmap releases a mapping made by Mmap.
+//
+// Parameters:
+//   - data: The mapping.
+//
+// Returns:
+//   - error: An error if the mapping could not be released.
+func Munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
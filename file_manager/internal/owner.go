@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatOwner formats the content of a lock file owned by the given process.
+//
+// Parameters:
+//   - pid: The PID of the owner.
+//   - hostname: The host the owner runs on.
+//
+// Returns:
+//   - []byte: The content of the lock file.
+//
+// Format:
+//
+//	"<pid>\n<hostname>\n"
+func FormatOwner(pid int, hostname string) []byte {
+	data := strconv.Itoa(pid) + "\n" + hostname + "\n"
+	return []byte(data)
+}
+
+// ParseOwner parses the content of a lock file written with FormatOwner.
+//
+// Parameters:
+//   - data: The content of the lock file.
+//
+// Returns:
+//   - int: The PID of the owner.
+//   - string: The host the owner runs on.
+//   - bool: True if the content is well-formed, false otherwise.
+func ParseOwner(data []byte) (int, string, bool) {
+	lines := strings.Split(string(data), "\n")
+	if len(lines) != 3 || lines[2] != "" {
+		return 0, "", false
+	}
+
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+
+	return pid, lines[1], true
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package internal
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE request of ioctl(2).
+const ficlone = 0x40049409
+
+// Reflink makes dst share the extents of src, on file systems that support it
+// (such as Btrfs and XFS).
+//
+// Parameters:
+//   - dst: The file to clone into. Must be open for writing.
+//   - src: The file to clone. Must be open for reading.
+//
+// Returns:
+//   - error: An error if the file system does not support cloning.
+func Reflink(dst, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return &os.LinkError{Op: "reflink", Old: src.Name(), New: dst.Name(), Err: errno}
+	}
+
+	return nil
+}
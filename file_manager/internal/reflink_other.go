@@ -0,0 +1,21 @@
+//go:build !linux
+
+package internal
+
+import (
+	"errors"
+	"os"
+)
+
+// Reflink makes dst share the extents of src. It is not supported on this
+// platform.
+//
+// Parameters:
+//   - dst: The file to clone into.
+//   - src: The file to clone.
+//
+// Returns:
+//   - error: Always an error wrapping errors.ErrUnsupported.
+func Reflink(dst, src *os.File) error {
+	return &os.LinkError{Op: "reflink", Old: src.Name(), New: dst.Name(), Err: errors.ErrUnsupported}
+}
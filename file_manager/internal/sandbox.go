@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	// MaxSymlinks is the maximum number of symbolic links followed while
+	// resolving a single path.
+	MaxSymlinks int = 40
+)
+
+// splitNative splits a native relative path into its elements, dropping empty
+// and "." elements.
+//
+// Parameters:
+//   - name: The path to split.
+//
+// Returns:
+//   - []string: The elements of the path.
+func splitNative(name string) []string {
+	fields := strings.Split(filepath.ToSlash(name), "/")
+
+	parts := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		if field != "" && field != "." {
+			parts = append(parts, field)
+		}
+	}
+
+	return parts
+}
+
+// ResolveIn resolves name inside root one element at a time, following
+// symbolic links, and refuses any path that would leave root.
+//
+// Parameters:
+//   - root: The absolute, symlink-free path of the root directory.
+//   - name: The relative path to resolve.
+//   - follow: Whether to follow a trailing symbolic link.
+//
+// Returns:
+//   - string: The native path of the resolved entry, always inside root.
+//   - error: An error if the path cannot be resolved.
+//
+// Errors:
+//   - ErrEscapes: If the path, or a symbolic link it goes through, leaves root.
+//   - syscall.ELOOP: If too many symbolic links were followed.
+//   - any other error: If an element could not be inspected.
+//
+// Elements that do not exist are resolved lexically, so that the returned path
+// can be used to create the entry.
+func ResolveIn(root, name string, follow bool) (string, error) {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", ErrEscapes
+	}
+
+	parts := splitNative(name)
+
+	var resolved []string
+
+	hops := 0
+
+	// missing is the depth of the first element of resolved that does not
+	// exist, or -1. Below it, elements are resolved lexically; once ".." pops
+	// it, elements must be inspected again, since they may be symbolic links.
+	missing := -1
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		if part == ".." {
+			if len(resolved) == 0 {
+				return "", ErrEscapes
+			}
+
+			resolved = resolved[:len(resolved)-1]
+
+			if missing >= len(resolved) {
+				missing = -1
+			}
+
+			continue
+		}
+
+		if missing >= 0 || (len(parts) == 0 && !follow) {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		loc := filepath.Join(root, filepath.Join(resolved...), part)
+
+		info, err := os.Lstat(loc)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = len(resolved)
+			resolved = append(resolved, part)
+
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		hops++
+		if hops > MaxSymlinks {
+			return "", &fs.PathError{Op: "resolve", Path: name, Err: syscall.ELOOP}
+		}
+
+		target, err := os.Readlink(loc)
+		if err != nil {
+			return "", err
+		}
+
+		if filepath.IsAbs(target) {
+			rel, err := filepath.Rel(root, target)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return "", ErrEscapes
+			}
+
+			resolved = nil
+			target = rel
+		}
+
+		parts = append(splitNative(target), parts...)
+	}
+
+	loc := filepath.Join(root, filepath.Join(resolved...))
+	return loc, nil
+}
@@ -0,0 +1,83 @@
+//go:build unix
+
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestResolveIn tests ResolveIn against a farm of symbolic links.
+func TestResolveIn(t *testing.T) {
+	base := t.TempDir()
+
+	root := filepath.Join(base, "root")
+
+	for _, dir := range []string{filepath.Join(root, "sub", "deep"), filepath.Join(base, "outside")} {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"in":        "sub",
+		"out":       "../outside",
+		"abs_in":    filepath.Join(root, "sub"),
+		"abs_out":   filepath.Join(base, "outside"),
+		"loop":      "loop",
+		"sub/up":    "..",
+		"sub/upup":  "../..",
+		"sub/chain": "../in/deep",
+		"dangling":  "sub/new",
+	}
+
+	for name, target := range links {
+		err := os.Symlink(target, filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	valid := map[string]string{
+		"":                "",
+		"sub/../sub/deep": "sub/deep",
+		"in/deep":         "sub/deep",
+		"abs_in/deep":     "sub/deep",
+		"sub/up/in":       "sub",
+		"sub/chain/..":    "sub",
+		"dangling":        "sub/new",
+		"missing/../sub":  "sub",
+		"missing/../in":   "sub",
+		"a/b/../../in/x":  "sub/x",
+	}
+
+	for name, expected := range valid {
+		loc, err := ResolveIn(root, name, true)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", name, err)
+		} else if want := filepath.Join(root, expected); loc != want {
+			t.Errorf("%q: expected %q, got %q", name, want, loc)
+		}
+	}
+
+	for _, name := range []string{"..", "sub/../..", "out", "out/x", "abs_out", "sub/upup", "sub/upup/root", "/etc", "missing/../out/x", "missing/../abs_out/x", "a/b/../../out", "missing/x/../../sub/upup"} {
+		_, err := ResolveIn(root, name, true)
+		if err != ErrEscapes {
+			t.Errorf("%q: expected ErrEscapes, got %v", name, err)
+		}
+	}
+
+	loc, err := ResolveIn(root, "out", false)
+	if err != nil || loc != filepath.Join(root, "out") {
+		t.Errorf("expected trailing link not to be followed, got %q, %v", loc, err)
+	}
+
+	_, err = ResolveIn(root, "loop", true)
+	if !errors.Is(err, syscall.ELOOP) {
+		t.Errorf("expected syscall.ELOOP, got %v", err)
+	}
+}
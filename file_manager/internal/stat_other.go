@@ -0,0 +1,46 @@
+//go:build !unix
+
+package internal
+
+import (
+	"io/fs"
+)
+
+// FileOwner returns the owner of a file. It is not known on this platform.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - int: Always -1.
+//   - int: Always -1.
+//   - bool: Always false.
+func FileOwner(info fs.FileInfo) (int, int, bool) {
+	return -1, -1, false
+}
+
+// FileID returns the identity of a file. It is not known on this platform.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - uint64: Always 0.
+//   - uint64: Always 0.
+//   - bool: Always false.
+func FileID(info fs.FileInfo) (uint64, uint64, bool) {
+	return 0, 0, false
+}
+
+// FileBlocks returns the number of bytes allocated to a file on disk. It is not
+// known on this platform.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - int64: Always 0.
+//   - bool: Always false.
+func FileBlocks(info fs.FileInfo) (int64, bool) {
+	return 0, false
+}
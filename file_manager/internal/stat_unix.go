@@ -0,0 +1,61 @@
+//go:build unix
+
+package internal
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// FileOwner returns the owner of a file.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - int: The user ID of the owner.
+//   - int: The group ID of the owner.
+//   - bool: False if the owner is not known.
+func FileOwner(info fs.FileInfo) (int, int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return -1, -1, false
+	}
+
+	return int(st.Uid), int(st.Gid), true
+}
+
+// FileID returns the identity of a file, which is shared by all its hard links.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - uint64: The device the file is on.
+//   - uint64: The inode of the file.
+//   - bool: False if the identity is not known.
+func FileID(info fs.FileInfo) (uint64, uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return 0, 0, false
+	}
+
+	return uint64(st.Dev), uint64(st.Ino), true
+}
+
+// FileBlocks returns the number of bytes allocated to a file on disk.
+//
+// Parameters:
+//   - info: The information about the file.
+//
+// Returns:
+//   - int64: The allocated size, in bytes.
+//   - bool: False if the allocated size is not known.
+func FileBlocks(info fs.FileInfo) (int64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return 0, false
+	}
+
+	return int64(st.Blocks) * 512, true
+}
@@ -0,0 +1,24 @@
+package internal
+
+// RelTo returns the name of an entry found by walking root, relative to
+// root. Root must be clean, as returned by path.Clean; otherwise, the same
+// tree would give different names depending on how its root is spelled.
+//
+// Parameters:
+//   - root: The clean root of the walk.
+//   - name: The name of the entry, as given by fs.WalkDir.
+//
+// Returns:
+//   - string: The slash-separated name of the entry relative to root. "." for root itself.
+func RelTo(root, name string) string {
+	switch {
+	case name == root:
+		return "."
+	case root == ".":
+		return name
+	case root == "/":
+		return name[1:]
+	default:
+		return name[len(root)+1:]
+	}
+}
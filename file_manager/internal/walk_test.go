@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"path"
+	"testing"
+)
+
+// TestRelTo tests RelTo with differently spelled roots.
+func TestRelTo(t *testing.T) {
+	for _, root := range []string{"gen", "./gen/", "gen//"} {
+		clean := path.Clean(root)
+
+		if got := RelTo(clean, "gen/sub/x"); got != "sub/x" {
+			t.Errorf("root %q: expected %q, got %q", root, "sub/x", got)
+		}
+	}
+
+	if got := RelTo(".", "sub/x"); got != "sub/x" {
+		t.Errorf("expected %q, got %q", "sub/x", got)
+	}
+
+	if got := RelTo("/", "/etc"); got != "etc" {
+		t.Errorf("expected %q, got %q", "etc", got)
+	}
+}
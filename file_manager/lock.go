@@ -0,0 +1,503 @@
+package file_manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+)
+
+const (
+	// minLockPoll is the first delay between two attempts of a context-bound lock.
+	minLockPoll time.Duration = 10 * time.Millisecond
+
+	// maxLockPoll is the longest delay between two attempts of a context-bound lock.
+	maxLockPoll time.Duration = 250 * time.Millisecond
+)
+
+// LockMode is the mode of an advisory lock.
+type LockMode int
+
+const (
+	// Shared is a lock that can be held by several owners at once, as long as
+	// nobody holds an Exclusive one.
+	Shared LockMode = iota
+
+	// Exclusive is a lock that can only be held by a single owner.
+	Exclusive
+)
+
+// String implements fmt.Stringer.
+func (m LockMode) String() string {
+	switch m {
+	case Shared:
+		return "shared"
+	case Exclusive:
+		return "exclusive"
+	default:
+		return "LockMode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
+
+// poll calls try until it succeeds, fails with an error other than ErrLocked
+// or the context ends. The delay between two attempts grows up to maxLockPoll.
+//
+// Parameters:
+//   - ctx: The context that bounds the wait. Must not be nil.
+//   - try: The function to call. Must not be nil.
+//
+// Returns:
+//   - error: The last error of try, or the error of the context.
+func poll(ctx context.Context, try func() error) error {
+	delay := minLockPoll
+
+	for {
+		err := try()
+		if err == nil || !errors.Is(err, ErrLocked) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		delay = min(2*delay, maxLockPoll)
+	}
+}
+
+// FileLock is an advisory lock placed with flock(2) on a file. It is released
+// when the FileLock is closed or the process exits.
+//
+// Advisory locks only coordinate processes that all use them; they do not
+// prevent anyone from reading or writing the file.
+type FileLock struct {
+	// file is the locked file.
+	file *os.File
+}
+
+// lockFile opens (and creates if needed) the file at the given path and locks it.
+//
+// Parameters:
+//   - path: The path of the file to lock.
+//   - mode: The mode of the lock.
+//   - block: Whether to wait for the lock to be available.
+//
+// Returns:
+//   - *FileLock: The lock.
+//   - error: An error if the lock could not be acquired.
+func lockFile(path string, mode LockMode, block bool) (*FileLock, error) {
+	if mode != Shared && mode != Exclusive {
+		return nil, gers.NewErrBadParam("mode", "must be either Shared or Exclusive")
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = internal.Flock(f, mode == Exclusive, block)
+	if err == internal.ErrWouldBlock {
+		_ = f.Close()
+		return nil, ErrLocked
+	} else if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	l := &FileLock{
+		file: f,
+	}
+
+	return l, nil
+}
+
+// Lock acquires an advisory lock on the file at the given path, creating the
+// file if needed and waiting for as long as the lock is held by someone else.
+//
+// Use it to serialize processes that regenerate the same output, for example
+// around a call to CreateDirectory with force set to true.
+//
+// Parameters:
+//   - path: The path of the file to lock.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. Close it to release the lock.
+//   - error: An error if the lock could not be acquired.
+//
+// Errors:
+//   - *errors.ErrBadParam: If the mode is not valid.
+//   - errors.ErrUnsupported: If advisory locks are not supported on this platform.
+//   - any other error: If the file could not be opened or locked.
+func Lock(path string, mode LockMode) (*FileLock, error) {
+	l, err := lockFile(path, mode, true)
+	return l, err
+}
+
+// TryLock is like Lock but fails immediately if the lock is held by someone
+// else.
+//
+// Parameters:
+//   - path: The path of the file to lock.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. Close it to release the lock.
+//   - error: An error if the lock could not be acquired.
+//
+// Errors:
+//   - ErrLocked: If the lock is held by someone else.
+//   - *errors.ErrBadParam: If the mode is not valid.
+//   - errors.ErrUnsupported: If advisory locks are not supported on this platform.
+//   - any other error: If the file could not be opened or locked.
+func TryLock(path string, mode LockMode) (*FileLock, error) {
+	l, err := lockFile(path, mode, false)
+	return l, err
+}
+
+// LockContext is like Lock but gives up once the context ends. The lock is
+// polled, so it may be acquired slightly after it was released.
+//
+// Parameters:
+//   - ctx: The context that bounds the wait. If nil, context.Background() is used.
+//   - path: The path of the file to lock.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. Close it to release the lock.
+//   - error: An error if the lock could not be acquired.
+//
+// Errors:
+//   - context.Canceled, context.DeadlineExceeded: If the context ended before the lock was acquired.
+//   - *errors.ErrBadParam: If the mode is not valid.
+//   - errors.ErrUnsupported: If advisory locks are not supported on this platform.
+//   - any other error: If the file could not be opened or locked.
+func LockContext(ctx context.Context, path string, mode LockMode) (*FileLock, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var l *FileLock
+
+	err := poll(ctx, func() error {
+		var err error
+
+		l, err = lockFile(path, mode, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Path returns the path of the locked file.
+//
+// Returns:
+//   - string: The path of the locked file.
+func (l FileLock) Path() string {
+	if l.file == nil {
+		return ""
+	}
+
+	return l.file.Name()
+}
+
+// Close releases the lock. The locked file is kept.
+//
+// Returns:
+//   - error: An error if the lock could not be released.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - fs.ErrClosed: If the lock was already released.
+//   - any other error: If the lock could not be released.
+func (l *FileLock) Close() error {
+	if l == nil {
+		return gers.ErrNilReceiver
+	} else if l.file == nil {
+		return fs.ErrClosed
+	}
+
+	err := internal.Funlock(l.file)
+
+	err2 := l.file.Close()
+	if err == nil {
+		err = err2
+	}
+
+	l.file = nil
+
+	return err
+}
+
+// LockFile is a lock materialized by the existence of a file that records the
+// PID and the host of its owner. Unlike FileLock, it also works on file systems
+// without flock(2) support, and a lock left behind by a dead process of the
+// same host is detected as stale and taken over.
+type LockFile struct {
+	// path is the path of the lock file.
+	path string
+
+	// content is what was written in the lock file.
+	content []byte
+}
+
+// ReadLockOwner reads the owner recorded in the given lock file.
+//
+// Parameters:
+//   - path: The path of the lock file.
+//
+// Returns:
+//   - int: The PID of the owner.
+//   - string: The host the owner runs on.
+//   - error: An error if the owner could not be read.
+//
+// Errors:
+//   - *errors.ErrUnexpected: If the content of the file is malformed.
+//   - any other error: If the file could not be read.
+func ReadLockOwner(path string) (int, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	pid, hostname, ok := internal.ParseOwner(data)
+	if !ok {
+		return 0, "", gers.NewErrUnexpected("lock file content", "<pid>\\n<hostname>\\n", strconv.Quote(string(data)))
+	}
+
+	return pid, hostname, nil
+}
+
+// breakStale removes the lock file at the given path if it was left behind by
+// a dead process of this host.
+//
+// Breakers are serialized by an advisory lock on the stale file itself, and the
+// file is only removed while the path still names it. Hence, a lock freshly
+// taken by another process in the meantime is never removed. Where advisory
+// locks are not available, the stale lock is reported as held.
+//
+// Parameters:
+//   - path: The path of the lock file.
+//   - hostname: The name of this host.
+//
+// Returns:
+//   - error: nil if the stale lock was removed, an *ErrLockHeld if the lock is alive.
+func breakStale(path, hostname string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	pid, owner_host, ok := internal.ParseOwner(data)
+	if !ok {
+		// The owner may still be writing the file.
+		return NewErrLockHeld(path, 0, "")
+	}
+
+	if owner_host != hostname || internal.ProcessAlive(pid) {
+		return NewErrLockHeld(path, pid, owner_host)
+	}
+
+	err = internal.Flock(f, true, true)
+	if err != nil {
+		return NewErrLockHeld(path, pid, owner_host)
+	}
+
+	opened, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	current, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !os.SameFile(opened, current) {
+		// Another process broke the stale lock first.
+		return nil
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// tryLockFile makes a single attempt at creating the lock file.
+//
+// Parameters:
+//   - path: The path of the lock file.
+//
+// Returns:
+//   - *LockFile: The lock.
+//   - error: An error if the lock could not be acquired.
+func tryLockFile(path string) (*LockFile, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	content := internal.FormatOwner(os.Getpid(), hostname)
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			err := breakStale(path, hostname)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, err = f.Write(content)
+
+		err2 := f.Close()
+		if err == nil {
+			err = err2
+		}
+
+		if err != nil {
+			_ = os.Remove(path)
+			return nil, err
+		}
+
+		l := &LockFile{
+			path:    path,
+			content: content,
+		}
+
+		return l, nil
+	}
+}
+
+// AcquireLockFile creates the lock file at the given path, recording the PID
+// and the host of the current process. If the file exists but was left by a
+// dead process of this host, it is taken over.
+//
+// Parameters:
+//   - path: The path of the lock file.
+//
+// Returns:
+//   - *LockFile: The lock. Close it to release the lock.
+//   - error: An error if the lock could not be acquired.
+//
+// Errors:
+//   - *ErrLockHeld: If the lock is held by another process. It matches ErrLocked.
+//   - any other error: If the lock file could not be created.
+func AcquireLockFile(path string) (*LockFile, error) {
+	l, err := tryLockFile(path)
+	return l, err
+}
+
+// AcquireLockFileContext is like AcquireLockFile but waits for the lock to be
+// released until the context ends.
+//
+// Parameters:
+//   - ctx: The context that bounds the wait. If nil, context.Background() is used.
+//   - path: The path of the lock file.
+//
+// Returns:
+//   - *LockFile: The lock. Close it to release the lock.
+//   - error: An error if the lock could not be acquired.
+//
+// Errors:
+//   - context.Canceled, context.DeadlineExceeded: If the context ended before the lock was acquired.
+//   - any other error: If the lock file could not be created.
+func AcquireLockFileContext(ctx context.Context, path string) (*LockFile, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var l *LockFile
+
+	err := poll(ctx, func() error {
+		var err error
+
+		l, err = tryLockFile(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Path returns the path of the lock file.
+//
+// Returns:
+//   - string: The path of the lock file.
+func (l LockFile) Path() string {
+	return l.path
+}
+
+// Close releases the lock by removing the lock file, unless it no longer
+// belongs to this lock.
+//
+// Returns:
+//   - error: An error if the lock could not be released.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - fs.ErrClosed: If the lock was already released.
+//   - any other error: If the lock file could not be removed.
+func (l *LockFile) Close() error {
+	if l == nil {
+		return gers.ErrNilReceiver
+	} else if l.content == nil {
+		return fs.ErrClosed
+	}
+
+	content := l.content
+	l.content = nil
+
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(data, content) {
+		// The lock was taken over by someone else.
+		return nil
+	}
+
+	err = os.Remove(l.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
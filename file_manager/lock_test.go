@@ -0,0 +1,313 @@
+//go:build unix
+
+package file_manager
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+)
+
+// deadPID returns the PID of a process that has exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command("true")
+
+	err := cmd.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cmd.Process.Pid
+}
+
+// writeOwner writes a lock file owned by the given process.
+func writeOwner(t *testing.T, path string, pid int, hostname string) {
+	t.Helper()
+
+	err := os.WriteFile(path, internal.FormatOwner(pid, hostname), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLockFileStale tests that a lock left by a dead process of this host is
+// taken over.
+func TestLockFileStale(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "lock")
+
+	writeOwner(t, path, deadPID(t), hostname)
+
+	l, err := AcquireLockFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pid, owner_host, err := ReadLockOwner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pid != os.Getpid() || owner_host != hostname {
+		t.Errorf("expected the lock to be owned by %d@%s, got %d@%s", os.Getpid(), hostname, pid, owner_host)
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = os.Stat(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+}
+
+// TestLockFileHeld tests that a lock owned by a live process, or by another
+// host, is not taken over.
+func TestLockFileHeld(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		pid      int
+		hostname string
+	}{
+		"live process": {os.Getppid(), hostname},
+		"other host":   {deadPID(t), hostname + ".elsewhere"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lock")
+
+			writeOwner(t, path, tt.pid, tt.hostname)
+
+			_, err := AcquireLockFile(path)
+
+			var held *ErrLockHeld
+
+			if !errors.As(err, &held) || !errors.Is(err, ErrLocked) {
+				t.Fatalf("expected an *ErrLockHeld, got %v", err)
+			}
+
+			if held.PID != tt.pid || held.Hostname != tt.hostname {
+				t.Errorf("expected the owner %d@%s, got %d@%s", tt.pid, tt.hostname, held.PID, held.Hostname)
+			}
+		})
+	}
+}
+
+// TestLockFileStaleRace tests that concurrent takeovers of the same stale lock
+// grant it to a single owner.
+func TestLockFileStaleRace(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "lock")
+
+	writeOwner(t, path, deadPID(t), hostname)
+
+	const n = 8
+
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+
+	for i := range n {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, errs[i] = AcquireLockFile(path)
+		}()
+	}
+
+	wg.Wait()
+
+	var owners int
+
+	for _, err := range errs {
+		if err == nil {
+			owners++
+		} else if !errors.Is(err, ErrLocked) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if owners != 1 {
+		t.Errorf("expected a single owner, got %d", owners)
+	}
+}
+
+// tryLock calls TryLock and reports whether the lock was acquired. Acquired
+// locks are released at the end of the test.
+func tryLock(t *testing.T, path string, mode LockMode) bool {
+	t.Helper()
+
+	l, err := TryLock(path, mode)
+	if errors.Is(err, ErrLocked) {
+		return false
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = l.Close() })
+
+	return true
+}
+
+// TestLockModes tests that shared locks coexist while an exclusive lock
+// excludes every other one, and that TryLock reports ErrLocked.
+func TestLockModes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	shared, err := Lock(path, Shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tryLock(t, path, Shared) {
+		t.Error("a second shared lock was refused")
+	}
+
+	if tryLock(t, path, Exclusive) {
+		t.Error("an exclusive lock was granted over shared ones")
+	}
+
+	err = shared.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := filepath.Join(t.TempDir(), "other")
+
+	exclusive, err := Lock(other, Exclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []LockMode{Shared, Exclusive} {
+		_, err := TryLock(other, mode)
+		if !errors.Is(err, ErrLocked) {
+			t.Errorf("TryLock(%v) over an exclusive lock = %v; want %v", mode, err, ErrLocked)
+		}
+	}
+
+	err = exclusive.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tryLock(t, other, Exclusive) {
+		t.Error("the exclusive lock was not released by Close")
+	}
+
+	_, err = TryLock(path, LockMode(42))
+	if err == nil {
+		t.Error("TryLock with an invalid mode succeeded")
+	}
+}
+
+// TestLockContext tests that LockContext gives up when its context ends, and
+// acquires the lock once it is released.
+func TestLockContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	held, err := Lock(path, Exclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err = LockContext(ctx, path, Shared)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockContext() error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("LockContext() gave up after %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = LockContext(cancelled, path, Exclusive)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("LockContext() with a cancelled context = %v; want %v", err, context.Canceled)
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { _ = held.Close() })
+
+	l, err := LockContext(context.Background(), path, Exclusive)
+	if err != nil {
+		t.Fatalf("LockContext() after the release: %v", err)
+	}
+
+	_ = l.Close()
+}
+
+// TestFileLockClose tests that closing a lock twice reports fs.ErrClosed and
+// does not release a lock acquired by someone else in the meantime.
+func TestFileLockClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	first, err := Lock(path, Exclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = first.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := TryLock(path, Exclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	err = first.Close()
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("second Close() = %v; want %v", err, fs.ErrClosed)
+	}
+
+	if first.Path() != "" {
+		t.Errorf("Path() of a closed lock = %q; want \"\"", first.Path())
+	}
+
+	if tryLock(t, path, Shared) {
+		t.Error("the second Close released someone else's lock")
+	}
+
+	var nil_lock *FileLock
+
+	err = nil_lock.Close()
+	if err == nil {
+		t.Error("Close() of a nil lock succeeded")
+	}
+}
@@ -0,0 +1,394 @@
+package file_manager
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/runes"
+)
+
+// Mapping is a read-only view of the content of a file. Where possible, the
+// file is mapped in memory, so that its content is paged in on demand instead
+// of being read at once; otherwise, it is read through a buffer on demand.
+//
+// A Mapping is safe for concurrent use, except that Close must not be called
+// while a slice returned by Bytes is still in use: the memory behind it is
+// released, and touching it crashes the program. Closing during an iteration
+// is safe: the mapping is released once the last running iteration ends.
+type Mapping struct {
+	// name is the name of the file.
+	name string
+
+	// mu protects the fields below.
+	mu sync.RWMutex
+
+	// data is the mapping, or nil if the file is read through f.
+	data []byte
+
+	// mapped is whether data comes from mmap and must be released.
+	mapped bool
+
+	// stream is whether f must be read sequentially, because its size is
+	// not known in advance.
+	stream bool
+
+	// f is the file, or nil once released.
+	f *os.File
+
+	// size is the size of the file when it was opened.
+	size int64
+
+	// active is the number of running iterations.
+	active int
+
+	// closing is whether Close was called while iterations were running.
+	closing bool
+}
+
+// MapFile maps the named file in memory, read-only. If the file cannot be
+// mapped (for example because the platform has no mmap), it is read through a
+// buffer instead, which Mapped reports.
+//
+// Files whose size is not known in advance, such as pipes, devices or the
+// files of /proc (which report a size of zero), are read sequentially through
+// a buffer. Each iteration over such a file starts over from the beginning if
+// the file can seek, and continues where the previous one stopped otherwise;
+// either way, such iterations must not run concurrently.
+//
+// The file must not be truncated while mapped.
+//
+// Parameters:
+//   - name: The native path of the file.
+//
+// Returns:
+//   - *Mapping: The view of the file. Must be closed.
+//   - error: An error if the file could not be opened.
+//
+// Errors:
+//   - *fs.PathError: If the file could not be opened.
+func MapFile(name string) (*Mapping, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	m := &Mapping{
+		name: name,
+		f:    f,
+		size: info.Size(),
+	}
+
+	if !info.Mode().IsRegular() || m.size == 0 {
+		m.stream = true
+		return m, nil
+	} else if m.size > math.MaxInt {
+		return m, nil
+	}
+
+	data, err := internal.Mmap(f, int(m.size))
+	if err != nil {
+		return m, nil
+	}
+
+	m.data = data
+	m.mapped = true
+
+	return m, nil
+}
+
+// Name returns the name of the file.
+//
+// Returns:
+//   - string: The name of the file.
+func (m *Mapping) Name() string {
+	return m.name
+}
+
+// Len returns the size of the file when it was opened.
+//
+// Returns:
+//   - int64: The size of the file, in bytes. Zero if it is not known in advance.
+func (m *Mapping) Len() int64 {
+	return m.size
+}
+
+// Mapped checks whether the content is available in memory, as opposed to
+// being read through a buffer.
+//
+// Returns:
+//   - bool: True if Bytes returns the content, false otherwise.
+func (m *Mapping) Mapped() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.data != nil
+}
+
+// Bytes returns the whole content of the file, without copying it.
+//
+// The slice must not be modified, nor used after Close.
+//
+// Returns:
+//   - []byte: The content of the file.
+//   - bool: False if the file is not mapped or the mapping is closed.
+func (m *Mapping) Bytes() ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.f == nil || m.closing || m.data == nil {
+		return nil, false
+	}
+
+	return m.data, true
+}
+
+// ReadAt implements io.ReaderAt.
+//
+// Errors:
+//   - os.ErrClosed: If the mapping is closed.
+//   - io.EOF: If off is at or past the end of the file.
+func (m *Mapping) ReadAt(p []byte, off int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.f == nil || m.closing {
+		return 0, &fs.PathError{Op: "read", Path: m.name, Err: os.ErrClosed}
+	} else if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: m.name, Err: fs.ErrInvalid}
+	}
+
+	if m.data == nil {
+		n, err := m.f.ReadAt(p, off)
+		return n, err
+	}
+
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// acquire registers a running iteration, which keeps the mapping alive
+// until release is called.
+//
+// Returns:
+//   - bool: False if the mapping is closed, in which case release must not
+//     be called.
+func (m *Mapping) acquire() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.f == nil || m.closing {
+		return false
+	}
+
+	m.active++
+
+	return true
+}
+
+// release unregisters an iteration registered by acquire, and releases the
+// mapping if it was closed in the meantime.
+func (m *Mapping) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.active--
+
+	if m.active == 0 && m.closing {
+		// There is no one left to report the error to.
+		_ = m.free()
+	}
+}
+
+// reader returns a buffered reader over the whole file. The mapping must be
+// acquired.
+//
+// Returns:
+//   - *bufio.Reader: The reader.
+func (m *Mapping) reader() *bufio.Reader {
+	if !m.stream {
+		return bufio.NewReader(io.NewSectionReader(m.f, 0, m.size))
+	}
+
+	// Pipes and the like cannot seek: they continue where they stopped.
+	_, _ = m.f.Seek(0, io.SeekStart)
+
+	return bufio.NewReader(m.f)
+}
+
+// Runes returns an iterator over the runes of the file, as runes.Runes does.
+// Invalid utf-8 data is yielded as utf8.RuneError, one byte at a time.
+//
+// Returns:
+//   - iter.Seq2[int64, rune]: The byte offset and the value of every rune. Never returns nil.
+func (m *Mapping) Runes() iter.Seq2[int64, rune] {
+	return func(yield func(int64, rune) bool) {
+		if !m.acquire() {
+			return
+		}
+
+		defer m.release()
+
+		if m.data != nil {
+			for offset, r := range runes.Runes(m.data) {
+				ok := yield(int64(offset), r)
+				if !ok {
+					return
+				}
+			}
+
+			return
+		}
+
+		br := m.reader()
+
+		var offset int64
+
+		for {
+			// bufio reports invalid bytes as RuneError of size 1, as runes.Runes does.
+			r, size, err := br.ReadRune()
+			if err != nil {
+				return
+			}
+
+			ok := yield(offset, r)
+			if !ok {
+				return
+			}
+
+			offset += int64(size)
+		}
+	}
+}
+
+// Lines returns an iterator over the lines of the file, as runes.Lines does.
+// When the file is mapped, every line is a read-only view of the mapping;
+// otherwise, it is a buffer reused by the next line. Either way, a line must
+// be copied to be kept beyond its iteration step.
+//
+// Returns:
+//   - iter.Seq2[int64, []byte]: The byte offset and the content of every line,
+//     without its terminator. Never returns nil.
+func (m *Mapping) Lines() iter.Seq2[int64, []byte] {
+	return func(yield func(int64, []byte) bool) {
+		if !m.acquire() {
+			return
+		}
+
+		defer m.release()
+
+		if m.data != nil {
+			for offset, line := range runes.Lines(m.data) {
+				ok := yield(int64(offset), line)
+				if !ok {
+					return
+				}
+			}
+
+			return
+		}
+
+		br := m.reader()
+
+		var offset int64
+		var line []byte
+
+		for {
+			line = line[:0]
+
+			var err error
+
+			for {
+				var chunk []byte
+
+				chunk, err = br.ReadSlice('\n')
+				line = append(line, chunk...)
+
+				if err != bufio.ErrBufferFull {
+					break
+				}
+			}
+
+			if len(line) == 0 || (err != nil && err != io.EOF) {
+				return
+			}
+
+			next := offset + int64(len(line))
+
+			line = bytes.TrimSuffix(line, []byte{'\n'})
+			line = bytes.TrimSuffix(line, []byte{'\r'})
+
+			ok := yield(offset, line)
+			if !ok {
+				return
+			}
+
+			offset = next
+		}
+	}
+}
+
+// Close releases the mapping and closes the file. If iterations are running,
+// they are released once the last one ends, and any error doing so is
+// discarded. Calling Close more than once has no effect.
+//
+// Returns:
+//   - error: An error if the mapping could not be released or the file closed.
+func (m *Mapping) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.f == nil || m.closing {
+		return nil
+	}
+
+	if m.active > 0 {
+		m.closing = true
+		return nil
+	}
+
+	err := m.free()
+	return err
+}
+
+// free releases the mapping and closes the file. The lock must be held for
+// writing and the mapping must be open.
+//
+// Returns:
+//   - error: An error if the mapping could not be released or the file closed.
+func (m *Mapping) free() error {
+	var err error
+
+	if m.mapped {
+		err = internal.Munmap(m.data)
+	}
+
+	m.data = nil
+	m.mapped = false
+
+	err = errors.Join(err, m.f.Close())
+	m.f = nil
+
+	return err
+}
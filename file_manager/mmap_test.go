@@ -0,0 +1,284 @@
+//go:build unix
+
+package file_manager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/runes"
+)
+
+// mmapContent mixes "\n" and "\r\n" terminators, invalid utf-8 and a last
+// line without terminator.
+var mmapContent = []byte("ab\r\ncd\n\xffé\n\r\nlast\r")
+
+// line is a line yielded by an iteration, along with its offset.
+type line struct {
+	offset int64
+	text   string
+}
+
+// wantLines returns the lines of data as runes.Lines splits them.
+func wantLines(data []byte) []line {
+	var lines []line
+
+	for offset, l := range runes.Lines(data) {
+		lines = append(lines, line{int64(offset), string(l)})
+	}
+
+	return lines
+}
+
+// gotLines collects the lines of a mapping.
+func gotLines(m *Mapping) []line {
+	var lines []line
+
+	for offset, l := range m.Lines() {
+		lines = append(lines, line{offset, string(l)})
+	}
+
+	return lines
+}
+
+// wantRunes returns the offsets and runes of data as runes.Runes decodes them.
+func wantRunes(data []byte) ([]int64, []rune) {
+	var offsets []int64
+	var rs []rune
+
+	for offset, r := range runes.Runes(data) {
+		offsets = append(offsets, int64(offset))
+		rs = append(rs, r)
+	}
+
+	return offsets, rs
+}
+
+// gotRunes collects the offsets and runes of a mapping.
+func gotRunes(m *Mapping) ([]int64, []rune) {
+	var offsets []int64
+	var rs []rune
+
+	for offset, r := range m.Runes() {
+		offsets = append(offsets, offset)
+		rs = append(rs, r)
+	}
+
+	return offsets, rs
+}
+
+// mapFile maps the named file and closes it at the end of the test.
+func mapFile(t *testing.T, name string) *Mapping {
+	t.Helper()
+
+	m, err := MapFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		err := m.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	return m
+}
+
+// TestMapFile tests that a regular file is mapped and iterated as the runes
+// package iterates the same bytes.
+func TestMapFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+
+	err := os.WriteFile(name, mmapContent, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := mapFile(t, name)
+
+	if !m.Mapped() {
+		t.Fatal("regular file not mapped")
+	}
+
+	if m.Len() != int64(len(mmapContent)) {
+		t.Errorf("Len() = %d, want %d", m.Len(), len(mmapContent))
+	}
+
+	data, ok := m.Bytes()
+	if !ok || !bytes.Equal(data, mmapContent) {
+		t.Errorf("Bytes() = %q, %t, want %q, true", data, ok, mmapContent)
+	}
+
+	got := gotLines(m)
+	want := wantLines(mmapContent)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+
+	got_offsets, got_runes := gotRunes(m)
+	want_offsets, want_runes := wantRunes(mmapContent)
+
+	if !slices.Equal(got_offsets, want_offsets) || !slices.Equal(got_runes, want_runes) {
+		t.Errorf("Runes() = %v %q, want %v %q", got_offsets, got_runes, want_offsets, want_runes)
+	}
+
+	p := make([]byte, 4)
+
+	n, err := m.ReadAt(p, 2)
+	if err != nil || string(p[:n]) != "\r\ncd" {
+		t.Errorf("ReadAt() = %q, %v, want %q, nil", p[:n], err, "\r\ncd")
+	}
+}
+
+// TestMapFileEmpty tests that an empty file yields nothing.
+func TestMapFileEmpty(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "empty")
+
+	err := os.WriteFile(name, nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := mapFile(t, name)
+
+	if m.Mapped() {
+		t.Error("empty file reported as mapped")
+	}
+
+	if lines := gotLines(m); len(lines) != 0 {
+		t.Errorf("Lines() = %q, want nothing", lines)
+	}
+
+	if _, rs := gotRunes(m); len(rs) != 0 {
+		t.Errorf("Runes() = %q, want nothing", rs)
+	}
+}
+
+// TestMapFilePipe tests that a named pipe, whose size is not known in
+// advance, is read sequentially.
+func TestMapFilePipe(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "fifo")
+
+	err := syscall.Mkfifo(name, 0o644)
+	if err != nil {
+		t.Skip("named pipes not supported:", err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		// Opening a pipe for writing blocks until it is opened for reading.
+		err := os.WriteFile(name, mmapContent, 0o644)
+		done <- err
+	}()
+
+	m := mapFile(t, name)
+
+	if m.Mapped() {
+		t.Error("pipe reported as mapped")
+	}
+
+	got := gotLines(m)
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := wantLines(mmapContent)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
+
+// TestMapFileProc tests that a file of /proc, which reports a size of zero,
+// is read in full, every time it is iterated.
+func TestMapFileProc(t *testing.T) {
+	const name = "/proc/self/status"
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Skip("no /proc:", err)
+	}
+
+	m := mapFile(t, name)
+
+	if m.Mapped() {
+		t.Error("/proc file reported as mapped")
+	}
+
+	first := gotLines(m)
+	if len(first) == 0 || !bytes.HasPrefix(data, []byte(first[0].text)) {
+		t.Fatalf("Lines() = %q, want the lines of %q", first, data)
+	}
+
+	second := gotLines(m)
+	if len(second) != len(first) {
+		t.Errorf("second Lines() yielded %d lines, want %d", len(second), len(first))
+	}
+
+	_, rs := gotRunes(m)
+	if len(rs) == 0 {
+		t.Error("Runes() yielded nothing")
+	}
+}
+
+// TestMappingClose tests that closing a mapping during an iteration neither
+// deadlocks nor stops it, and that a closed mapping reports so.
+func TestMappingClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+
+	err := os.WriteFile(name, mmapContent, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MapFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+
+	for range m.Lines() {
+		if count == 0 {
+			err := m.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := m.Bytes(); ok {
+				t.Error("Bytes() available after Close")
+			}
+		}
+
+		count++
+	}
+
+	if want := len(wantLines(mmapContent)); count != want {
+		t.Errorf("iteration yielded %d lines, want %d", count, want)
+	}
+
+	_, err = m.ReadAt(make([]byte, 1), 0)
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("ReadAt() error = %v, want %v", err, os.ErrClosed)
+	}
+
+	if lines := gotLines(m); len(lines) != 0 {
+		t.Errorf("Lines() after Close = %q, want nothing", lines)
+	}
+
+	err = m.Close()
+	if err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ErrStage occurs when a stage of a pipeline fails.
+type ErrStage struct {
+	// Index is the position of the failing stage in the pipeline.
+	Index uint
+
+	// Name is the name of the failing command.
+	Name string
+
+	// Stderr is what the stage wrote to its standard error, if it was captured.
+	Stderr string
+
+	// Inner is the original error.
+	Inner error
+}
+
+// Error implements error.
+func (e ErrStage) Error() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("stage ")
+	_, _ = builder.WriteString(strconv.FormatUint(uint64(e.Index), 10))
+
+	if e.Name != "" {
+		_, _ = builder.WriteString(" (")
+		_, _ = builder.WriteString(e.Name)
+		_, _ = builder.WriteRune(')')
+	}
+
+	if e.Inner == nil {
+		_, _ = builder.WriteString(" failed")
+	} else {
+		_, _ = builder.WriteString(": ")
+		_, _ = builder.WriteString(e.Inner.Error())
+	}
+
+	if e.Stderr != "" {
+		_, _ = builder.WriteString(": ")
+		_, _ = builder.WriteString(e.Stderr)
+	}
+
+	return builder.String()
+}
+
+// NewErrStage creates a new ErrStage error.
+//
+// Parameters:
+//   - idx: The position of the failing stage in the pipeline.
+//   - name: The name of the failing command.
+//   - stderr: The captured standard error of the stage, if any.
+//   - inner: The original error.
+//
+// Returns:
+//   - error: An instance of ErrStage. Never returns nil.
+//
+// Format:
+//
+//	"stage <idx> (<name>): <inner>: <stderr>"
+//
+// Where:
+//   - <idx> is the position of the failing stage.
+//   - <name> is the name of the command. If empty, the parenthesis are omitted.
+//   - <inner> is the original error message. If nil, the ": <inner>" part is replaced by " failed".
+//   - <stderr> is the captured standard error. If empty, the ": <stderr>" part is omitted.
+func NewErrStage(idx uint, name, stderr string, inner error) error {
+	e := &ErrStage{
+		Index:  idx,
+		Name:   name,
+		Stderr: stderr,
+		Inner:  inner,
+	}
+
+	return e
+}
+
+// Unwrap returns the inner error.
+//
+// Returns:
+//   - error: The inner error instance.
+func (e ErrStage) Unwrap() error {
+	return e.Inner
+}
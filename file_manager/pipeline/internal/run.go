@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// WaitDelay is how long a stage may keep its standard streams open after it
+// exits, which happens when it leaves behind a process that inherited them.
+// Past that delay, the streams are closed so that the stage can be waited for.
+const WaitDelay time.Duration = 5 * time.Second
+
+// Stage is a single command of a pipeline.
+type Stage struct {
+	// Cmd is the command to run.
+	Cmd *exec.Cmd
+
+	// Tee, if not nil, receives a copy of everything the command writes to its
+	// standard output.
+	Tee io.Writer
+
+	// Stderr, if not nil, is the standard error of a command that has none,
+	// for the duration of Run.
+	Stderr io.Writer
+}
+
+// Locked is a writer whose writes are serialized by a mutex, so that several
+// commands can share it.
+type Locked struct {
+	// mu serializes the writes. It may be shared with other Locked writers.
+	mu *sync.Mutex
+
+	// w is the writer to write to.
+	w io.Writer
+}
+
+// NewLocked wraps a writer so that its writes are serialized by mu. Files are
+// not wrapped: exec hands them to the command as is, and the kernel already
+// serializes their writes.
+//
+// Parameters:
+//   - mu: The mutex that serializes the writes. Must not be nil.
+//   - w: The writer to wrap.
+//
+// Returns:
+//   - io.Writer: The wrapped writer, or nil if w is nil.
+func NewLocked(mu *sync.Mutex, w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+
+	_, ok := w.(*os.File)
+	if ok {
+		return w
+	}
+
+	l := &Locked{
+		mu: mu,
+		w:  w,
+	}
+
+	return l
+}
+
+// Write implements io.Writer.
+func (l *Locked) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n, err := l.w.Write(p)
+	return n, err
+}
+
+// closeAll closes all the non-nil files, ignoring any error.
+//
+// Parameters:
+//   - files: The files to close.
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		if f != nil {
+			_ = f.Close()
+		}
+	}
+}
+
+// Run wires the stages together with pipes, starts them and waits for all of
+// them to finish. If the context is cancelled before that, every stage is
+// killed.
+//
+// Parameters:
+//   - ctx: The context that cancels the whole pipeline. Must not be nil.
+//   - stages: The stages to run, in order. Must not be empty.
+//   - stdin: The standard input of the first stage. If nil, the command's own is kept.
+//   - stdout: The standard output of the last stage. If nil, the command's own is kept.
+//
+// Returns:
+//   - []error: The error of each stage, in order. A nil element means the stage succeeded.
+//
+// If a stage cannot be started, the stages started so far are killed and only
+// the error of the failing stage is reported.
+func Run(ctx context.Context, stages []Stage, stdin io.Reader, stdout io.Writer) []error {
+	errs := make([]error, len(stages))
+
+	for _, stage := range stages {
+		if stage.Stderr == nil || stage.Cmd.Stderr != nil {
+			continue
+		}
+
+		stage.Cmd.Stderr = stage.Stderr
+
+		// The command belongs to the caller: do not leave it pointing at a
+		// writer of the pipeline.
+		defer func() {
+			stage.Cmd.Stderr = nil
+		}()
+	}
+
+	var close_after_start []*os.File
+	close_after_wait := make([]*os.File, len(stages))
+
+	last := len(stages) - 1
+
+	for i, stage := range stages {
+		if stage.Cmd.WaitDelay == 0 {
+			stage.Cmd.WaitDelay = WaitDelay
+		}
+
+		if i == 0 && stdin != nil {
+			stage.Cmd.Stdin = stdin
+		}
+
+		if i == last {
+			out := stdout
+
+			if stage.Tee != nil {
+				if out == nil {
+					out = stage.Tee
+				} else {
+					out = io.MultiWriter(out, stage.Tee)
+				}
+			}
+
+			if out != nil {
+				stage.Cmd.Stdout = out
+			}
+
+			continue
+		}
+
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll(close_after_start)
+			closeAll(close_after_wait)
+
+			errs[i] = err
+			return errs
+		}
+
+		stages[i+1].Cmd.Stdin = r
+		close_after_start = append(close_after_start, r)
+
+		if stage.Tee == nil {
+			// The child writes straight into the pipe.
+			stage.Cmd.Stdout = w
+			close_after_start = append(close_after_start, w)
+		} else {
+			// exec copies the output in a goroutine, so the write end must stay
+			// open until the command has been waited for.
+			stage.Cmd.Stdout = io.MultiWriter(w, stage.Tee)
+			close_after_wait[i] = w
+		}
+	}
+
+	for i, stage := range stages {
+		err := stage.Cmd.Start()
+		if err == nil {
+			continue
+		}
+
+		for _, started := range stages[:i] {
+			_ = started.Cmd.Process.Kill()
+			_ = started.Cmd.Wait()
+		}
+
+		closeAll(close_after_start)
+		closeAll(close_after_wait)
+
+		errs[i] = err
+		return errs
+	}
+
+	closeAll(close_after_start)
+
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, stage := range stages {
+				_ = stage.Cmd.Process.Kill()
+			}
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for i, stage := range stages {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = stage.Cmd.Wait()
+
+			w := close_after_wait[i]
+			if w != nil {
+				_ = w.Close()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+
+	return errs
+}
+
+// Failed returns the rightmost failing stage, as the pipefail option of the
+// shell does.
+//
+// Parameters:
+//   - errs: The errors of the stages, as returned by Run.
+//
+// Returns:
+//   - int: The position of the rightmost non-nil error.
+//   - bool: True if a stage failed, false otherwise.
+func Failed(errs []error) (int, bool) {
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i] != nil {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRun tests that the stages are wired together and tees receive the
+// intermediate streams.
+func TestRun(t *testing.T) {
+	var tee, out bytes.Buffer
+
+	stages := []Stage{
+		{Cmd: exec.Command("cat")},
+		{Cmd: exec.Command("tr", "a-z", "A-Z"), Tee: &tee},
+		{Cmd: exec.Command("sort")},
+	}
+
+	errs := Run(context.Background(), stages, strings.NewReader("b\nc\na\n"), &out)
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("stage %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if got := out.String(); got != "A\nB\nC\n" {
+		t.Errorf("expected %q, got %q", "A\nB\nC\n", got)
+	}
+
+	if got := tee.String(); got != "B\nC\nA\n" {
+		t.Errorf("expected tee %q, got %q", "B\nC\nA\n", got)
+	}
+}
+
+// TestRunFailure tests that the failing stage is reported. The first stage
+// writes nothing, so that it cannot be killed by SIGPIPE.
+func TestRunFailure(t *testing.T) {
+	stages := []Stage{
+		{Cmd: exec.Command("true")},
+		{Cmd: exec.Command("false")},
+		{Cmd: exec.Command("cat")},
+	}
+
+	errs := Run(context.Background(), stages, strings.NewReader("a\n"), nil)
+
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("expected only stage 1 to fail, got %v", errs)
+	}
+
+	if errs[1] == nil {
+		t.Errorf("expected stage 1 to fail")
+	}
+}
+
+// TestRunCancel tests that cancelling the context kills every stage.
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	stages := []Stage{
+		{Cmd: exec.Command("sleep", "10")},
+		{Cmd: exec.Command("cat")},
+	}
+
+	start := time.Now()
+
+	errs := Run(ctx, stages, nil, nil)
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("pipeline was not cancelled")
+	}
+
+	if errs[0] == nil {
+		t.Errorf("expected stage 0 to be killed")
+	}
+}
+
+// TestRunPipefail tests that an upstream stage killed by SIGPIPE does not
+// mask the failure of a later stage.
+func TestRunPipefail(t *testing.T) {
+	stages := []Stage{
+		{Cmd: exec.Command("yes")},
+		{Cmd: exec.Command("head", "-n", "1")},
+		{Cmd: exec.Command("sh", "-c", "cat >/dev/null; exit 3")},
+	}
+
+	errs := Run(context.Background(), stages, nil, nil)
+
+	i, ok := Failed(errs)
+	if !ok || i != 2 {
+		t.Fatalf("expected stage 2 to be reported, got %d, %t (errors: %v)", i, ok, errs)
+	}
+}
+
+// TestRunWaitDelay tests that a stage leaving behind a process that holds its
+// output does not block the pipeline forever.
+func TestRunWaitDelay(t *testing.T) {
+	var out bytes.Buffer
+
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo hi")
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	start := time.Now()
+
+	errs := Run(context.Background(), []Stage{{Cmd: cmd}}, nil, &out)
+
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("pipeline waited for the leftover process")
+	}
+
+	if !errors.Is(errs[0], exec.ErrWaitDelay) {
+		t.Errorf("expected exec.ErrWaitDelay, got %v", errs[0])
+	}
+
+	if got := out.String(); got != "hi\n" {
+		t.Errorf("expected %q, got %q", "hi\n", got)
+	}
+}
@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/pipeline/internal"
+	mio "github.com/PlayerR9/mygo-lib/writer"
+)
+
+// Pipeline is a chain of commands where the standard output of each command
+// is connected to the standard input of the next one, like "a | b | c".
+//
+// A Pipeline can only be run once, just like the commands it is made of.
+type Pipeline struct {
+	// Stdin is the standard input of the first command. If nil, the command's
+	// own Stdin is kept.
+	Stdin io.Reader
+
+	// Stdout is the standard output of the last command. If nil, the command's
+	// own Stdout is kept.
+	Stdout io.Writer
+
+	// Stderr is the standard error of every command that does not already have
+	// one, for the duration of Run. If nil, the standard error of such commands
+	// is captured and reported in ErrStage. Unless it is a file, its writes are
+	// serialized, so it need not be safe for concurrent use.
+	Stderr io.Writer
+
+	// stages are the stages of the pipeline.
+	stages []internal.Stage
+}
+
+// New creates a new pipeline from the given commands, usually created with
+// file_manager.NewCommand.
+//
+// Parameters:
+//   - cmds: The commands of the pipeline, in order.
+//
+// Returns:
+//   - *Pipeline: The new pipeline.
+//   - error: An error if the commands are not valid.
+//
+// Errors:
+//   - *errors.ErrBadParam: If no command is given, one of them is nil, or a
+//     command already has a standard input or output that the pipeline would
+//     replace; that is, a Stdin on any but the first command, or a Stdout on
+//     any but the last one.
+func New(cmds ...*exec.Cmd) (*Pipeline, error) {
+	if len(cmds) == 0 {
+		return nil, gers.NewErrBadParam("cmds", "must not be empty")
+	}
+
+	stages := make([]internal.Stage, 0, len(cmds))
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			return nil, gers.NewErrNilParam("cmds")
+		}
+
+		if i > 0 && cmd.Stdin != nil {
+			return nil, gers.NewErrBadParam("cmds", "command "+strconv.Itoa(i)+" must not have a Stdin")
+		} else if i < len(cmds)-1 && cmd.Stdout != nil {
+			return nil, gers.NewErrBadParam("cmds", "command "+strconv.Itoa(i)+" must not have a Stdout")
+		}
+
+		stages = append(stages, internal.Stage{
+			Cmd: cmd,
+		})
+	}
+
+	p := &Pipeline{
+		stages: stages,
+	}
+
+	return p, nil
+}
+
+// Tee copies everything the given stage writes to its standard output into w,
+// which is useful to inspect the intermediate streams of a pipeline. Unless
+// it is a file, the writes to w are serialized with those to the other tees
+// and to Stderr, so the same writer can be given to several stages.
+//
+// Parameters:
+//   - stage: The position of the stage, starting from 0.
+//   - w: The writer that receives the copy. If nil, any previous tee is removed.
+//
+// Returns:
+//   - error: An error if the tee cannot be set.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - *errors.ErrBadParam: If the stage does not exist.
+func (p *Pipeline) Tee(stage uint, w mio.Writer) error {
+	if p == nil {
+		return gers.ErrNilReceiver
+	}
+
+	if stage >= uint(len(p.stages)) {
+		return gers.NewErrBadParam("stage", "is out of range")
+	}
+
+	p.stages[stage].Tee = w
+
+	return nil
+}
+
+// Len returns the number of stages of the pipeline.
+//
+// Returns:
+//   - uint: The number of stages.
+func (p *Pipeline) Len() uint {
+	if p == nil {
+		return 0
+	}
+
+	return uint(len(p.stages))
+}
+
+// Run runs every stage of the pipeline and waits for all of them to finish.
+//
+// Like the pipefail option of the shell, the pipeline fails if any of its
+// stages fails, and the rightmost failing stage is reported: an upstream
+// stage often fails only because a later one stopped reading (for example,
+// with SIGPIPE), which must not mask the actual failure.
+//
+// A stage whose output is still held open by a process it left behind is
+// given five seconds to release it after exiting, unless the command sets
+// its own WaitDelay; past that, the stage fails with exec.ErrWaitDelay.
+//
+// Parameters:
+//   - ctx: The context that cancels the pipeline. If nil, context.Background() is used.
+//
+// Returns:
+//   - error: An error if a stage failed or the context was cancelled.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - context.Canceled, context.DeadlineExceeded: If the context ended before the pipeline.
+//   - *ErrStage: If a stage could not be started or exited with an error.
+func (p *Pipeline) Run(ctx context.Context) error {
+	if p == nil {
+		return gers.ErrNilReceiver
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	// os/exec copies the output of a command into a writer that is not a file
+	// from a goroutine of its own, so the writers shared by several stages
+	// must serialize their writes.
+	var mu sync.Mutex
+
+	stderr := internal.NewLocked(&mu, p.Stderr)
+
+	stages := make([]internal.Stage, 0, len(p.stages))
+	captured := make([]*bytes.Buffer, len(p.stages))
+
+	for i, stage := range p.stages {
+		stage.Tee = internal.NewLocked(&mu, stage.Tee)
+
+		if stage.Cmd.Stderr == nil {
+			if stderr != nil {
+				stage.Stderr = stderr
+			} else {
+				captured[i] = new(bytes.Buffer)
+				stage.Stderr = captured[i]
+			}
+		}
+
+		stages = append(stages, stage)
+	}
+
+	errs := internal.Run(ctx, stages, p.Stdin, p.Stdout)
+
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	i, ok := internal.Failed(errs)
+	if ok {
+		var stderr string
+
+		if captured[i] != nil {
+			stderr = strings.TrimSpace(captured[i].String())
+		}
+
+		name := filepath.Base(p.stages[i].Cmd.Path)
+
+		return NewErrStage(uint(i), name, stderr, errs[i])
+	}
+
+	return nil
+}
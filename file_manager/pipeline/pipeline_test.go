@@ -0,0 +1,187 @@
+//go:build unix
+
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunErrStage tests that the rightmost failing stage is reported along
+// with its captured standard error.
+func TestRunErrStage(t *testing.T) {
+	failing := exec.Command("sh", "-c", "echo first >&2; exit 1")
+	last := exec.Command("sh", "-c", "cat >/dev/null; echo '  boom  ' >&2; exit 3")
+
+	p, err := New(failing, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Run(context.Background())
+
+	var stage *ErrStage
+
+	if !errors.As(err, &stage) {
+		t.Fatalf("Run() error = %v; want *ErrStage", err)
+	}
+
+	if stage.Index != 1 || stage.Name != "sh" || stage.Stderr != "boom" {
+		t.Errorf("Run() error = %+v; want stage 1 (sh) with stderr %q", stage, "boom")
+	}
+
+	var exit *exec.ExitError
+
+	if !errors.As(err, &exit) || exit.ExitCode() != 3 {
+		t.Errorf("Run() error = %v; want exit status 3", err)
+	}
+
+	if failing.Stderr != nil || last.Stderr != nil {
+		t.Error("Run() left its writers in the commands")
+	}
+}
+
+// TestRunSharedWriters tests that Stderr and tees shared by several stages
+// are written to one at a time. Run it with -race.
+func TestRunSharedWriters(t *testing.T) {
+	const script = "for i in 1 2 3 4 5 6 7 8 9 10; do echo err$i >&2; echo out$i; done"
+
+	var stderr, tee, out bytes.Buffer
+
+	p, err := New(
+		exec.Command("sh", "-c", script),
+		exec.Command("sh", "-c", "cat; "+script),
+		exec.Command("cat"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Stderr = &stderr
+	p.Stdout = &out
+
+	for stage := range uint(2) {
+		err = p.Tee(stage, &tee)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = p.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Count(stderr.String(), "err"); got != 20 {
+		t.Errorf("Stderr got %d lines; want 20:\n%s", got, stderr.String())
+	}
+
+	// The second stage echoes the output of the first, then adds its own.
+	if got := strings.Count(tee.String(), "out"); got != 30 {
+		t.Errorf("tee got %d lines; want 30:\n%s", got, tee.String())
+	}
+
+	if got := strings.Count(out.String(), "out"); got != 20 {
+		t.Errorf("Stdout got %d lines; want 20:\n%s", got, out.String())
+	}
+}
+
+// TestTee tests that a tee receives the intermediate stream of its stage.
+func TestTee(t *testing.T) {
+	var tee, out bytes.Buffer
+
+	p, err := New(exec.Command("tr", "a-z", "A-Z"), exec.Command("sort"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Stdin = strings.NewReader("b\nc\na\n")
+	p.Stdout = &out
+
+	err = p.Tee(0, &tee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Tee(2, &tee)
+	if err == nil {
+		t.Error("Tee() of a missing stage succeeded")
+	}
+
+	err = p.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := tee.String(); got != "B\nC\nA\n" {
+		t.Errorf("tee = %q; want %q", got, "B\nC\nA\n")
+	}
+
+	if got := out.String(); got != "A\nB\nC\n" {
+		t.Errorf("Stdout = %q; want %q", got, "A\nB\nC\n")
+	}
+}
+
+// TestRunCancel tests that cancelling the context kills the pipeline and
+// reports the context error.
+func TestRunCancel(t *testing.T) {
+	p, err := New(exec.Command("sleep", "10"), exec.Command("cat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	err = p.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Error("pipeline was not cancelled")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	p, err = New(exec.Command("true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() with a cancelled context = %v; want %v", err, context.Canceled)
+	}
+}
+
+// TestNew tests that New rejects commands whose streams it would replace.
+func TestNew(t *testing.T) {
+	with_stdout := exec.Command("true")
+	with_stdout.Stdout = new(bytes.Buffer)
+
+	with_stdin := exec.Command("true")
+	with_stdin.Stdin = strings.NewReader("")
+
+	tests := [][]*exec.Cmd{
+		nil,
+		{nil},
+		{with_stdout, exec.Command("true")},
+		{exec.Command("true"), with_stdin},
+	}
+
+	for _, cmds := range tests {
+		_, err := New(cmds...)
+		if err == nil {
+			t.Errorf("New(%v) succeeded", cmds)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package file_manager
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// Root is a directory that paths cannot escape from. Every path handed to it
+// is resolved one element at a time, following symbolic links, and rejected
+// with an *ErrEscape as soon as it would leave the directory, be it through
+// ".." elements, absolute paths or symbolic links.
+//
+// Root implements vfs.FS, so it can be given to any function of this package.
+// Unlike other implementations, it accepts any relative native path as a name,
+// including ones with ".." elements that stay inside the root.
+//
+// The resolution is done before the operation, so a concurrent process able to
+// modify the tree may still race it.
+type Root struct {
+	// dir is the absolute, symlink-free path of the root.
+	dir string
+}
+
+// OpenRoot creates a Root for the given directory.
+//
+// Parameters:
+//   - dir: The directory to confine paths to.
+//
+// Returns:
+//   - *Root: The new root.
+//   - error: An error if the directory is not valid.
+//
+// Errors:
+//   - *fs.PathError: If the directory does not exist or is not a directory.
+//   - any other error: If the directory could not be resolved.
+func OpenRoot(dir string) (*Root, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err = filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "openroot", Path: dir, Err: gers.NewErrBadParam("dir", "must be a directory")}
+	}
+
+	r := &Root{
+		dir: abs,
+	}
+
+	return r, nil
+}
+
+// Name returns the absolute path of the root directory.
+//
+// Returns:
+//   - string: The path of the root.
+func (r Root) Name() string {
+	return r.dir
+}
+
+// Resolve resolves the given path inside the root.
+//
+// Parameters:
+//   - name: The relative path to resolve.
+//
+// Returns:
+//   - string: The native path of the entry, guaranteed to be inside the root.
+//   - error: An error if the path cannot be resolved.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the root.
+//   - any other error: If an element of the path could not be inspected.
+func (r Root) Resolve(name string) (string, error) {
+	loc, err := r.resolve("resolve", name, true)
+	return loc, err
+}
+
+// resolve resolves the given path inside the root.
+//
+// Parameters:
+//   - op: The operation being performed, used in the errors.
+//   - name: The relative path to resolve.
+//   - follow: Whether to follow a trailing symbolic link.
+//
+// Returns:
+//   - string: The native path of the entry.
+//   - error: An error if the path cannot be resolved.
+func (r Root) resolve(op, name string, follow bool) (string, error) {
+	if r.dir == "" {
+		return "", &fs.PathError{Op: op, Path: name, Err: gers.ErrNilReceiver}
+	}
+
+	loc, err := internal.ResolveIn(r.dir, name, follow)
+	if err == internal.ErrEscapes {
+		return "", NewErrEscape(r.dir, name)
+	} else if err != nil {
+		return "", err
+	}
+
+	return loc, nil
+}
+
+// Exists checks if the given path exists inside the root.
+//
+// Parameters:
+//   - name: The relative path to check.
+//
+// Returns:
+//   - bool: True if the path exists, false otherwise.
+//   - error: An error if something went wrong.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the root.
+//   - any other error: If the path could not be checked.
+func (r Root) Exists(name string) (bool, error) {
+	ok, err := Exists(r, name)
+	return ok, err
+}
+
+// Create creates or truncates the named file inside the root.
+//
+// Parameters:
+//   - name: The relative path of the file.
+//
+// Returns:
+//   - vfs.File: The file, open for reading and writing.
+//   - error: An error if the file could not be created.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the root.
+//   - any other error: If the file could not be created.
+func (r Root) Create(name string) (vfs.File, error) {
+	f, err := r.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+	return f, err
+}
+
+// Open implements fs.FS.
+func (r Root) Open(name string) (fs.File, error) {
+	f, err := r.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Stat implements fs.StatFS.
+func (r Root) Stat(name string) (fs.FileInfo, error) {
+	loc, err := r.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(loc)
+	return info, err
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (r Root) ReadDir(name string) ([]fs.DirEntry, error) {
+	loc, err := r.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(loc)
+	return entries, err
+}
+
+// Lstat implements vfs.FS.
+func (r Root) Lstat(name string) (fs.FileInfo, error) {
+	loc, err := r.resolve("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(loc)
+	return info, err
+}
+
+// ReadLink implements vfs.FS.
+func (r Root) ReadLink(name string) (string, error) {
+	loc, err := r.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := os.Readlink(loc)
+	return target, err
+}
+
+// OpenFile implements vfs.FS.
+func (r Root) OpenFile(name string, flag int, perm fs.FileMode) (vfs.File, error) {
+	loc, err := r.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(loc, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Mkdir implements vfs.FS.
+func (r Root) Mkdir(name string, perm fs.FileMode) error {
+	loc, err := r.resolve("mkdir", name, false)
+	if err != nil {
+		return err
+	}
+
+	err = os.Mkdir(loc, perm)
+	return err
+}
+
+// MkdirAll implements vfs.FS.
+func (r Root) MkdirAll(name string, perm fs.FileMode) error {
+	loc, err := r.resolve("mkdir", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(loc, perm)
+	return err
+}
+
+// Remove implements vfs.FS. A trailing symbolic link is removed, not its target.
+func (r Root) Remove(name string) error {
+	loc, err := r.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+
+	if loc == r.dir {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	err = os.Remove(loc)
+	return err
+}
+
+// RemoveAll implements vfs.FS. Symbolic links inside the removed tree are
+// removed, never followed.
+func (r Root) RemoveAll(name string) error {
+	loc, err := r.resolve("removeall", name, false)
+	if err != nil {
+		return err
+	}
+
+	if loc == r.dir {
+		return &fs.PathError{Op: "removeall", Path: name, Err: fs.ErrInvalid}
+	}
+
+	err = os.RemoveAll(loc)
+	return err
+}
+
+// Rename implements vfs.FS.
+func (r Root) Rename(oldname, newname string) error {
+	old_loc, err := r.resolve("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+
+	new_loc, err := r.resolve("rename", newname, false)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(old_loc, new_loc)
+	return err
+}
+
+// Symlink implements vfs.FS. The target is stored as is: links pointing
+// outside of the root can be created, but never followed through the Root.
+func (r Root) Symlink(oldname, newname string) error {
+	loc, err := r.resolve("symlink", newname, false)
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(oldname, loc)
+	return err
+}
+
+// Chmod implements vfs.FS.
+func (r Root) Chmod(name string, mode fs.FileMode) error {
+	loc, err := r.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(loc, mode)
+	return err
+}
+
+// Chtimes implements vfs.FS.
+func (r Root) Chtimes(name string, atime, mtime time.Time) error {
+	loc, err := r.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chtimes(loc, atime, mtime)
+	return err
+}
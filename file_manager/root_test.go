@@ -0,0 +1,265 @@
+//go:build unix
+
+package file_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sandbox creates a root directory next to an outside one holding a secret
+// file, along with symbolic links from the root to the outside:
+//
+//	outside/secret
+//	root/inside
+//	root/sub/
+//	root/out     -> ../outside
+//	root/abs     -> <absolute path of outside>
+//	root/leak    -> ../outside/secret
+//	root/sub/up  -> ../..
+func sandbox(t *testing.T) (*Root, string) {
+	t.Helper()
+
+	tmp := t.TempDir()
+
+	outside := filepath.Join(tmp, "outside")
+	dir := filepath.Join(tmp, "root")
+
+	for _, d := range []string{outside, filepath.Join(dir, "sub")} {
+		err := os.MkdirAll(d, 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, content := range map[string]string{
+		filepath.Join(outside, "secret"): "secret",
+		filepath.Join(dir, "inside"):     "inside",
+	} {
+		err := os.WriteFile(name, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for link, target := range map[string]string{
+		"out":    "../outside",
+		"abs":    outside,
+		"leak":   "../outside/secret",
+		"sub/up": "../..",
+	} {
+		err := os.Symlink(target, filepath.Join(dir, link))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r, outside
+}
+
+// checkOutside fails the test if the outside directory was modified.
+func checkOutside(t *testing.T, outside string, mtime time.Time) {
+	t.Helper()
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	if !slices.Equal(names, []string{"secret"}) {
+		t.Errorf("outside directory now holds %q", names)
+	}
+
+	secret := filepath.Join(outside, "secret")
+
+	data, err := os.ReadFile(secret)
+	if err != nil || string(data) != "secret" {
+		t.Errorf("secret now reads %q, %v", data, err)
+	}
+
+	info, err := os.Stat(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o600 || !info.ModTime().Equal(mtime) {
+		t.Errorf("secret now has mode %v and mtime %v", info.Mode(), info.ModTime())
+	}
+}
+
+// escapes lists names that leave the root, either through ".." elements,
+// symbolic links or both. Each one is also tried as the parent of a new entry.
+var escapes = []string{
+	"..",
+	"../outside/secret",
+	"sub/../../outside/secret",
+	"out/secret",
+	"abs/secret",
+	"sub/up/outside/secret",
+	"leak",
+}
+
+// TestRootEscapes tests that every mutating method of Root rejects the paths
+// that leave it, and leaves the outside untouched.
+func TestRootEscapes(t *testing.T) {
+	r, outside := sandbox(t)
+
+	info, err := os.Stat(filepath.Join(outside, "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := info.ModTime()
+
+	ops := map[string]func(name string) error{
+		"Create": func(name string) error {
+			f, err := r.Create(name)
+			if err == nil {
+				_ = f.Close()
+			}
+
+			return err
+		},
+		"OpenFile": func(name string) error {
+			f, err := r.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if err == nil {
+				_, _ = f.Write([]byte("pwned"))
+				_ = f.Close()
+			}
+
+			return err
+		},
+		"Rename from": func(name string) error {
+			return r.Rename(name, "stolen")
+		},
+		"Rename to": func(name string) error {
+			return r.Rename("inside", name)
+		},
+		"Symlink": func(name string) error {
+			return r.Symlink("inside", name)
+		},
+		"RemoveAll": func(name string) error {
+			return r.RemoveAll(name)
+		},
+		"Chmod": func(name string) error {
+			return r.Chmod(name, 0o777)
+		},
+		"Chtimes": func(name string) error {
+			return r.Chtimes(name, time.Unix(0, 0), time.Unix(0, 0))
+		},
+	}
+
+	for op, fn := range ops {
+		for _, name := range escapes {
+			for _, candidate := range []string{name, filepath.Join(name, "new")} {
+				// A trailing link is not followed by these: "leak" itself
+				// is inside the root, and removing or replacing it is fine.
+				if candidate == "leak" && (op == "Rename from" || op == "Rename to" || op == "Symlink" || op == "RemoveAll") {
+					continue
+				}
+
+				err := fn(candidate)
+
+				var escape *ErrEscape
+
+				if !errors.As(err, &escape) {
+					t.Errorf("%s(%q) error = %v; want *ErrEscape", op, candidate, err)
+				}
+			}
+		}
+	}
+
+	checkOutside(t, outside, mtime)
+
+	// Removing the links themselves stays inside the root.
+	for _, link := range []string{"out", "abs", "leak", "sub/up"} {
+		err := r.RemoveAll(link)
+		if err != nil {
+			t.Errorf("RemoveAll(%q): %v", link, err)
+		}
+	}
+
+	checkOutside(t, outside, mtime)
+}
+
+// TestRootSymlinkOut tests that a link pointing outside can be created but
+// not followed.
+func TestRootSymlinkOut(t *testing.T) {
+	r, outside := sandbox(t)
+
+	err := r.Symlink(filepath.Join(outside, "secret"), "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Create("new")
+
+	var escape *ErrEscape
+
+	if !errors.As(err, &escape) {
+		t.Errorf("Create() through a new link error = %v; want *ErrEscape", err)
+	}
+
+	err = r.Chmod("new", 0o777)
+	if !errors.As(err, &escape) {
+		t.Errorf("Chmod() through a new link error = %v; want *ErrEscape", err)
+	}
+}
+
+// TestRootInside tests that paths staying inside the root, even through ".."
+// elements, are allowed.
+func TestRootInside(t *testing.T) {
+	r, _ := sandbox(t)
+
+	f, err := r.Create("sub/../created")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = f.Close()
+
+	err = r.Rename("created", "sub/renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Chmod("sub/renamed", 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Chtimes("./sub/renamed", time.Unix(0, 0), time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.RemoveAll("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := r.Exists("sub")
+	if err != nil || ok {
+		t.Errorf("Exists(sub) after RemoveAll = %t, %v; want false, nil", ok, err)
+	}
+
+	err = r.RemoveAll(".")
+	if err == nil {
+		t.Error("RemoveAll(\".\") of the root itself succeeded")
+	}
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+)
+
+// BackupName returns the name of the backup with the given index.
+//
+// Parameters:
+//   - path: The path of the live file.
+//   - idx: The 1-based index of the backup. The higher, the older.
+//   - compressed: Whether the backup is compressed.
+//
+// Returns:
+//   - string: The name of the backup.
+//
+// Format:
+//
+//	"<path>.<idx>[.gz]"
+func BackupName(path string, idx uint, compressed bool) string {
+	name := path + "." + strconv.FormatUint(uint64(idx), 10)
+
+	if compressed {
+		name += ".gz"
+	}
+
+	return name
+}
+
+// findBackup returns the name of the existing backup with the given index,
+// compressed or not.
+//
+// Parameters:
+//   - path: The path of the live file.
+//   - idx: The 1-based index of the backup.
+//
+// Returns:
+//   - string: The name of the backup, or an empty string if it does not exist.
+//   - bool: Whether the backup is compressed.
+func findBackup(path string, idx uint) (string, bool) {
+	for _, compressed := range [2]bool{false, true} {
+		name := BackupName(path, idx, compressed)
+
+		_, err := os.Lstat(name)
+		if err == nil {
+			return name, compressed
+		}
+	}
+
+	return "", false
+}
+
+// Shift makes room for a new first backup by renaming every backup to the
+// next index, from the oldest to the newest so that no backup is ever
+// overwritten. The backups that would go beyond the limit are removed.
+//
+// Parameters:
+//   - path: The path of the live file.
+//   - max: The number of backups to keep. If 0, every backup is kept.
+//
+// Returns:
+//   - error: An error if a backup could not be renamed or removed.
+func Shift(path string, max uint) error {
+	var last uint
+
+	for {
+		name, _ := findBackup(path, last+1)
+		if name == "" {
+			break
+		}
+
+		last++
+	}
+
+	for idx := last; idx > 0; idx-- {
+		name, compressed := findBackup(path, idx)
+		if name == "" {
+			continue
+		}
+
+		var err error
+
+		if max > 0 && idx >= max {
+			err = os.Remove(name)
+		} else {
+			err = os.Rename(name, BackupName(path, idx+1, compressed))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compress compresses the given file with gzip into a file with the ".gz"
+// suffix, then removes the original. The compressed file is written under a
+// temporary name first, so that a partial file never takes the final name.
+//
+// Parameters:
+//   - name: The name of the file to compress.
+//   - perm: The permission bits of the compressed file.
+//
+// Returns:
+//   - error: An error if the file could not be compressed.
+func Compress(name string, perm fs.FileMode) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := name + ".gz.tmp"
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+
+	_, err = io.Copy(gz, in)
+	err = errors.Join(err, gz.Close(), out.Close())
+
+	if err == nil {
+		err = os.Rename(tmp, name+".gz")
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	err = os.Remove(name)
+	return err
+}
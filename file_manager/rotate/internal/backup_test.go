@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestShift tests the Shift function.
+func TestShift(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	for _, name := range []string{"app.log.1", "app.log.2.gz", "app.log.3"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Shift(path, 3)
+	if err != nil {
+		t.Fatalf("Shift() = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	want := []string{"app.log.2", "app.log.3.gz"}
+
+	if !slices.Equal(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log.3.gz"))
+	if err != nil || string(data) != "app.log.2.gz" {
+		t.Errorf("app.log.3.gz = %q, %v", data, err)
+	}
+}
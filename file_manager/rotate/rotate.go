@@ -0,0 +1,419 @@
+package rotate
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/rotate/internal"
+	mio "github.com/PlayerR9/mygo-lib/writer"
+)
+
+// Options are the options of a rotating file.
+type Options struct {
+	// MaxSize is the size, in bytes, the live file may reach before it is
+	// rotated. A single write larger than that still goes to a single file.
+	// If zero, the file is never rotated because of its size.
+	MaxSize int64
+
+	// Interval is how long the same live file is written to before it is
+	// rotated. The interval starts when the file is opened. If zero, the file
+	// is never rotated because of its age.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files to keep. If zero, every
+	// rotated file is kept.
+	MaxBackups uint
+
+	// Compress compresses the rotated files with gzip, in the background. A
+	// rotation waits for the previous compression to finish, so the write
+	// that triggers it, and every write behind it, may stall meanwhile.
+	Compress bool
+
+	// Perm are the permission bits of the files created. If zero, 0o644 is used.
+	Perm fs.FileMode
+
+	// ReopenOnSignal reopens the live file when the process receives SIGHUP,
+	// for use with an external tool that moves the file away. It has no effect
+	// on platforms without SIGHUP.
+	ReopenOnSignal bool
+}
+
+// Writer is a file that is rotated by size or by age. The rotated files are
+// named after the live file with an increasing index, "<path>.1" being the
+// most recent one, optionally followed by ".gz".
+//
+// Writer is safe for concurrent use.
+type Writer struct {
+	// path is the path of the live file.
+	path string
+
+	// opts are the options of the writer.
+	opts Options
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// file is the live file, or nil once closed.
+	file *os.File
+
+	// size is the size of the live file.
+	size int64
+
+	// opened is when the live file was opened.
+	opened time.Time
+
+	// pending tracks the compressions in progress.
+	pending sync.WaitGroup
+
+	// compress_mu protects compress_err. It is distinct from mu, which is held
+	// while waiting for the compressions.
+	compress_mu sync.Mutex
+
+	// compress_err is the first error of a background compression.
+	compress_err error
+
+	// sig receives SIGHUP, if requested.
+	sig chan os.Signal
+}
+
+var _ mio.Writer = (*Writer)(nil)
+
+// Open opens, or creates, the live file at the given path for appending. Its
+// parent directory must exist.
+//
+// Parameters:
+//   - path: The path of the live file.
+//   - opts: The options of the writer. If nil, the file is never rotated.
+//
+// Returns:
+//   - *Writer: The writer.
+//   - error: An error if the file could not be opened.
+//
+// Errors:
+//   - *errors.ErrBadParam: If path is empty or an option is negative.
+//   - any other error: If the file could not be opened.
+func Open(path string, opts *Options) (*Writer, error) {
+	if path == "" {
+		return nil, gers.NewErrBadParam("path", "must not be empty")
+	}
+
+	var o Options
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MaxSize < 0 {
+		return nil, gers.NewErrBadParam("opts.MaxSize", "must not be negative")
+	} else if o.Interval < 0 {
+		return nil, gers.NewErrBadParam("opts.Interval", "must not be negative")
+	}
+
+	if o.Perm == 0 {
+		o.Perm = 0o644
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		path: abs,
+		opts: o,
+	}
+
+	err = w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.ReopenOnSignal {
+		w.sig = make(chan os.Signal, 1)
+		signal.Notify(w.sig, syscall.SIGHUP)
+
+		go w.watch(w.sig)
+	}
+
+	return w, nil
+}
+
+// watch reopens the live file every time a signal is received, until the
+// channel is closed.
+//
+// Parameters:
+//   - ch: The channel the signals are received on.
+func (w *Writer) watch(ch chan os.Signal) {
+	for range ch {
+		_ = w.Reopen()
+	}
+}
+
+// open opens the live file. The lock must be held.
+//
+// Returns:
+//   - error: An error if the file could not be opened.
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, w.opts.Perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.opened = time.Now()
+
+	return nil
+}
+
+// Path returns the absolute path of the live file.
+//
+// Returns:
+//   - string: The path of the live file.
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// due checks whether the live file must be rotated before writing n bytes.
+// The lock must be held.
+//
+// Parameters:
+//   - n: The number of bytes about to be written.
+//
+// Returns:
+//   - bool: True if the live file must be rotated, false otherwise.
+func (w *Writer) due(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+
+	if w.opts.MaxSize > 0 && w.size+int64(n) > w.opts.MaxSize {
+		return true
+	}
+
+	return w.opts.Interval > 0 && time.Since(w.opened) >= w.opts.Interval
+}
+
+// Write implements writer.Writer. The live file is rotated beforehand if the
+// write would make it exceed MaxSize or if it is older than Interval.
+//
+// Errors:
+//   - os.ErrClosed: If the writer is closed.
+//   - any other error: If the file could not be rotated or written.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, &fs.PathError{Op: "write", Path: w.path, Err: os.ErrClosed}
+	}
+
+	ok := w.due(len(p))
+	if ok {
+		err := w.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the live file immediately, even if it is empty.
+//
+// Returns:
+//   - error: An error if the file could not be rotated.
+//
+// Errors:
+//   - os.ErrClosed: If the writer is closed.
+//   - any other error: If the file could not be rotated, or if a previous
+//     background compression failed.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return &fs.PathError{Op: "rotate", Path: w.path, Err: os.ErrClosed}
+	}
+
+	err := w.rotate()
+	return err
+}
+
+// rotate moves the live file to the first backup and opens a new one. The
+// lock must be held and the writer must be open.
+//
+// The sequence is: wait for pending compressions, close the live file, shift
+// the backups from the oldest to the newest, rename the live file to the first
+// backup and open a new live file. At no point is an existing file
+// overwritten, and whatever step fails, a live file is opened again so that
+// the writer keeps working.
+//
+// Waiting for the compressions is needed because the backup being compressed
+// would otherwise be shifted from under it; as the lock is held meanwhile, a
+// rotation stalls every write until the previous compression is done.
+//
+// Returns:
+//   - error: An error if the file could not be rotated.
+func (w *Writer) rotate() error {
+	w.pending.Wait()
+
+	err := w.takeCompressErr()
+	if err != nil {
+		return err
+	}
+
+	err = w.file.Close()
+	w.file = nil
+
+	if err != nil {
+		// The live file is left in place: keep writing to it.
+		return errors.Join(err, w.open())
+	}
+
+	err = internal.Shift(w.path, w.opts.MaxBackups)
+	if err != nil {
+		return errors.Join(err, w.open())
+	}
+
+	backup := internal.BackupName(w.path, 1, false)
+
+	err = os.Rename(w.path, backup)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// Keep writing to the same file rather than losing data.
+		return errors.Join(err, w.open())
+	}
+
+	if err == nil && w.opts.Compress {
+		w.pending.Add(1)
+
+		go w.compress(backup)
+	}
+
+	err = w.open()
+	return err
+}
+
+// compress compresses a backup in the background.
+//
+// Parameters:
+//   - backup: The name of the backup.
+func (w *Writer) compress(backup string) {
+	defer w.pending.Done()
+
+	err := internal.Compress(backup, w.opts.Perm)
+	if err == nil {
+		return
+	}
+
+	w.compress_mu.Lock()
+	defer w.compress_mu.Unlock()
+
+	if w.compress_err == nil {
+		w.compress_err = err
+	}
+}
+
+// takeCompressErr returns and clears the first error of a background
+// compression.
+//
+// Returns:
+//   - error: The error, or nil if every compression succeeded.
+func (w *Writer) takeCompressErr() error {
+	w.compress_mu.Lock()
+	defer w.compress_mu.Unlock()
+
+	err := w.compress_err
+	w.compress_err = nil
+
+	return err
+}
+
+// Reopen closes the live file and opens the file at its path again, which is
+// needed after an external tool moved it away.
+//
+// Returns:
+//   - error: An error if the file could not be reopened.
+//
+// Errors:
+//   - os.ErrClosed: If the writer is closed.
+//   - any other error: If the file could not be reopened.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return &fs.PathError{Op: "reopen", Path: w.path, Err: os.ErrClosed}
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	err = errors.Join(err, w.open())
+	return err
+}
+
+// Sync commits the content of the live file to stable storage.
+//
+// Returns:
+//   - error: An error if the file could not be synced.
+//
+// Errors:
+//   - os.ErrClosed: If the writer is closed.
+//   - any other error: If the file could not be synced.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return &fs.PathError{Op: "sync", Path: w.path, Err: os.ErrClosed}
+	}
+
+	err := w.file.Sync()
+	return err
+}
+
+// Close closes the live file and waits for the background compressions.
+// Calling Close more than once has no effect.
+//
+// Returns:
+//   - error: An error if the file could not be closed or a compression failed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	if w.sig != nil {
+		signal.Stop(w.sig)
+		close(w.sig)
+
+		w.sig = nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	w.pending.Wait()
+
+	err = errors.Join(err, w.takeCompressErr())
+	return err
+}
@@ -0,0 +1,359 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readFile reads a file, decompressing it if its name ends with ".gz".
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r = zr
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// writeAll writes every string to the writer.
+func writeAll(t *testing.T, w *Writer, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		_, err := w.Write([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// exists checks whether the given file exists.
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// TestRotateSize tests that the live file is rotated before it exceeds
+// MaxSize, the most recent backup being the first one.
+func TestRotateSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, &Options{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAll(t, w, "first\n", "second\n", "third\n")
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		path:        "third\n",
+		path + ".1": "second\n",
+		path + ".2": "first\n",
+	}
+
+	for name, content := range want {
+		if got := readFile(t, name); got != content {
+			t.Errorf("%s: expected %q, got %q", filepath.Base(name), content, got)
+		}
+	}
+
+	_, err = w.Write([]byte("closed"))
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+}
+
+// TestRotateOversized tests that a write larger than MaxSize goes to a single
+// file.
+func TestRotateOversized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, &Options{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, "oversized\n")
+
+	if got := readFile(t, path); got != "oversized\n" {
+		t.Errorf("expected %q, got %q", "oversized\n", got)
+	}
+
+	if exists(path + ".1") {
+		t.Errorf("expected no backup")
+	}
+}
+
+// TestRotateInterval tests that the live file is rotated once it is older
+// than Interval.
+func TestRotateInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, &Options{Interval: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, "old\n", "still old\n")
+
+	time.Sleep(100 * time.Millisecond)
+
+	writeAll(t, w, "new\n")
+
+	if got := readFile(t, path+".1"); got != "old\nstill old\n" {
+		t.Errorf("expected the old lines in the backup, got %q", got)
+	}
+
+	if got := readFile(t, path); got != "new\n" {
+		t.Errorf("expected the new line in the live file, got %q", got)
+	}
+}
+
+// TestRotateMaxBackups tests that the oldest backups are pruned.
+func TestRotateMaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, &Options{MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		writeAll(t, w, line)
+
+		err := w.Rotate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readFile(t, path+".1"); got != "4\n" {
+		t.Errorf("expected %q, got %q", "4\n", got)
+	}
+
+	if got := readFile(t, path+".2"); got != "3\n" {
+		t.Errorf("expected %q, got %q", "3\n", got)
+	}
+
+	if exists(path + ".3") {
+		t.Errorf("expected the third backup to be pruned")
+	}
+}
+
+// TestRotateCompress tests that the backups are compressed in the background
+// and that Close waits for them.
+func TestRotateCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, &Options{Compress: true, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"1\n", "2\n", "3\n"} {
+		writeAll(t, w, line)
+
+		err := w.Rotate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{".1.gz": "3\n", ".2.gz": "2\n"} {
+		if got := readFile(t, path+name); got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+
+	for _, name := range []string{".1", ".2", ".3", ".3.gz"} {
+		if exists(path + name) {
+			t.Errorf("expected %s not to exist", name)
+		}
+	}
+}
+
+// TestRotateReopen tests that the live file is recreated once moved away.
+func TestRotateReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, "before\n")
+
+	err = os.Rename(path, path+".moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Reopen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAll(t, w, "after\n")
+
+	if got := readFile(t, path+".moved"); got != "before\n" {
+		t.Errorf("expected %q in the moved file, got %q", "before\n", got)
+	}
+
+	if got := readFile(t, path); got != "after\n" {
+		t.Errorf("expected %q in the live file, got %q", "after\n", got)
+	}
+}
+
+// TestRotateCloseError tests that a live file that cannot be closed is
+// reopened, so that the writer keeps working, and that the backups are left
+// untouched.
+func TestRotateCloseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, "1\n")
+
+	err = w.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAll(t, w, "2\n")
+
+	// Closing the file from under the writer makes its own Close fail.
+	w.mu.Lock()
+	_ = w.file.Close()
+	w.mu.Unlock()
+
+	err = w.Rotate()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected os.ErrClosed, got %v", err)
+	}
+
+	writeAll(t, w, "3\n")
+
+	if got := readFile(t, path); got != "2\n3\n" {
+		t.Errorf("expected %q in the live file, got %q", "2\n3\n", got)
+	}
+
+	if got := readFile(t, path+".1"); got != "1\n" {
+		t.Errorf("expected %q in the first backup, got %q", "1\n", got)
+	}
+
+	if exists(path + ".2") {
+		t.Error("expected the backups not to be shifted")
+	}
+}
+
+// TestRotateConcurrent tests that concurrent writes are neither lost nor
+// interleaved across rotations.
+func TestRotateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w, err := Open(path, &Options{MaxSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		writers = 8
+		lines   = 50
+		line    = "0123456789\n"
+	)
+
+	var wg sync.WaitGroup
+
+	for range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range lines {
+				_, err := w.Write([]byte(line))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+
+	for _, entry := range entries {
+		content := readFile(t, filepath.Join(dir, entry.Name()))
+
+		if len(content) > 64 {
+			t.Errorf("%s: expected at most 64 bytes, got %d", entry.Name(), len(content))
+		}
+
+		if strings.ReplaceAll(content, line, "") != "" {
+			t.Errorf("%s: unexpected content %q", entry.Name(), content)
+		}
+
+		total += strings.Count(content, line)
+	}
+
+	if total != writers*lines {
+		t.Errorf("expected %d lines, got %d", writers*lines, total)
+	}
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Render executes the given text/template with the given variables. Missing
+// variables are reported as errors instead of being rendered as "<no value>".
+//
+// Parameters:
+//   - name: The name of the template, used in the errors.
+//   - text: The text of the template.
+//   - vars: The variables of the template.
+//
+// Returns:
+//   - string: The rendered text.
+//   - error: An error if the template is malformed or could not be executed.
+func Render(name, text string, vars any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	err = tmpl.Execute(&builder, vars)
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// CleanRel cleans a slash-separated relative path.
+//
+// Parameters:
+//   - p: The path to clean.
+//
+// Returns:
+//   - string: The cleaned path.
+//   - bool: False if the path is empty, absolute or leaves its base directory.
+func CleanRel(p string) (string, bool) {
+	if p == "" || strings.HasPrefix(p, "/") {
+		return "", false
+	}
+
+	cleaned := path.Clean(p)
+
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+
+	return cleaned, true
+}
@@ -0,0 +1,45 @@
+package internal
+
+import "testing"
+
+// TestRender tests the Render function.
+func TestRender(t *testing.T) {
+	vars := map[string]any{
+		"Name": "svc",
+	}
+
+	got, err := Render("t", "cmd/{{ .Name }}/main.go", vars)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if got != "cmd/svc/main.go" {
+		t.Errorf("expected %q, got %q", "cmd/svc/main.go", got)
+	}
+
+	_, err = Render("t", "{{ .Missing }}", vars)
+	if err == nil {
+		t.Errorf("expected an error for a missing variable")
+	}
+}
+
+// TestCleanRel tests the CleanRel function.
+func TestCleanRel(t *testing.T) {
+	valid := map[string]string{
+		"a/b/../c": "a/c",
+		"./a":      "a",
+		"a//b/":    "a/b",
+	}
+
+	for p, expected := range valid {
+		got, ok := CleanRel(p)
+		if !ok || got != expected {
+			t.Errorf("%q: expected %q, got %q (%t)", p, expected, got, ok)
+		}
+	}
+
+	for _, p := range []string{"", ".", "/a", "..", "a/../../b"} {
+		_, ok := CleanRel(p)
+		if ok {
+			t.Errorf("%q: expected to be rejected", p)
+		}
+	}
+}
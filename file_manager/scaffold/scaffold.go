@@ -0,0 +1,500 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/scaffold/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+const (
+	// DefaultDirMode is the mode of the directories whose Mode is zero.
+	DefaultDirMode fs.FileMode = 0o755
+
+	// DefaultFileMode is the mode of the files whose Mode is zero.
+	DefaultFileMode fs.FileMode = 0o644
+)
+
+// Entry is a directory or a file of a scaffold. Both its Path and its Content
+// are text/template templates executed with the variables of the scaffold.
+type Entry struct {
+	// Path is the slash-separated path of the entry, relative to its parent.
+	Path string `json:"path"`
+
+	// Dir is true if the entry is a directory. Entries with children are
+	// always directories.
+	Dir bool `json:"dir,omitempty"`
+
+	// Content is the content of a file.
+	Content string `json:"content,omitempty"`
+
+	// Mode is the permissions of the entry. If zero, DefaultDirMode or
+	// DefaultFileMode is used.
+	Mode fs.FileMode `json:"mode,omitempty"`
+
+	// Children are the entries of a directory.
+	Children []Entry `json:"children,omitempty"`
+}
+
+// Spec is the description of a tree to scaffold.
+//
+// YAML is not supported since this module only depends on the standard
+// library; specs are either written in Go or decoded from JSON with ParseSpec.
+type Spec struct {
+	// Entries are the top-level entries of the tree.
+	Entries []Entry `json:"entries"`
+}
+
+// ParseSpec decodes a spec from JSON.
+//
+// Parameters:
+//   - r: The reader to read from. Must not be nil.
+//
+// Returns:
+//   - *Spec: The decoded spec.
+//   - error: An error if the spec could not be decoded.
+//
+// Errors:
+//   - *errors.ErrBadParam: If r is nil.
+//   - any other error: If the JSON is malformed or has unknown fields.
+func ParseSpec(r io.Reader) (*Spec, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParam("r")
+	}
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var spec Spec
+
+	err := dec.Decode(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// Op is the operation planned for an entry.
+type Op int
+
+const (
+	// Create means the entry does not exist and will be created.
+	Create Op = iota
+
+	// Skip means the entry already exists and will be left untouched.
+	Skip
+
+	// Overwrite means the entry already exists and will be replaced.
+	Overwrite
+)
+
+// String implements fmt.Stringer.
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Skip:
+		return "skip"
+	case Overwrite:
+		return "overwrite"
+	default:
+		return "Op(" + strconv.Itoa(int(op)) + ")"
+	}
+}
+
+// Action is what will be done to a single entry.
+type Action struct {
+	// Path is the rendered path of the entry, relative to the root.
+	Path string
+
+	// Dir is true if the entry is a directory.
+	Dir bool
+
+	// Op is the operation to perform.
+	Op Op
+
+	// Mode is the permissions of the entry.
+	Mode fs.FileMode
+
+	// Content is the rendered content of a file.
+	Content []byte
+}
+
+// Report lists the paths, relative to the root, affected by each operation.
+type Report struct {
+	// Created are the entries that did not exist.
+	Created []string
+
+	// Skipped are the entries that already existed and were left untouched.
+	Skipped []string
+
+	// Overwritten are the entries that already existed and were replaced.
+	Overwritten []string
+}
+
+// Plan is the list of actions needed to scaffold a spec. It is computed
+// without modifying anything, so it doubles as a dry run.
+type Plan struct {
+	// fsys is the file system to scaffold into.
+	fsys vfs.FS
+
+	// root is the directory to scaffold into.
+	root string
+
+	// Actions are the actions to perform, parents before their children.
+	Actions []Action
+}
+
+// NewPlan renders the spec with the given variables and decides, for every
+// entry, whether it has to be created, skipped or overwritten.
+//
+// Existing directories are always kept. Existing files are skipped unless
+// force is true, in which case they are overwritten. An existing entry of the
+// wrong kind is replaced if force is true, and is an error otherwise; this
+// follows the semantics of file_manager.CreateDirectory.
+//
+// Parameters:
+//   - fsys: The file system to scaffold into. If nil, the native file system is used.
+//   - root: The directory to scaffold into. It is created if needed.
+//   - spec: The spec to scaffold. Must not be nil.
+//   - vars: The variables of the templates.
+//   - force: Whether existing files are overwritten.
+//
+// Returns:
+//   - *Plan: The plan.
+//   - error: An error if the plan could not be made.
+//
+// Errors:
+//   - *errors.ErrBadParam: If spec is nil or a rendered path is not valid.
+//   - fs.ErrExist: If an entry exists with the wrong kind and force is false.
+//   - any other error: If a template fails or the tree could not be inspected.
+func NewPlan(fsys vfs.FS, root string, spec *Spec, vars any, force bool) (*Plan, error) {
+	if spec == nil {
+		return nil, gers.NewErrNilParam("spec")
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	p := &Plan{
+		fsys: fsys,
+		root: root,
+	}
+
+	planned := make(map[string]Action)
+
+	err := p.plan("", false, spec.Entries, vars, force, planned)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// plan appends the actions of the given entries to the plan.
+//
+// Parameters:
+//   - parent: The rendered path of the parent directory, relative to the root.
+//   - fresh: Whether the parent directory is going to be created from scratch.
+//   - entries: The entries to plan.
+//   - vars: The variables of the templates.
+//   - force: Whether existing files are overwritten.
+//   - planned: The actions already planned, by path.
+//
+// Returns:
+//   - error: An error if an entry could not be planned.
+func (p *Plan) plan(parent string, fresh bool, entries []Entry, vars any, force bool, planned map[string]Action) error {
+	for _, e := range entries {
+		name, err := internal.Render(e.Path, e.Path, vars)
+		if err != nil {
+			return err
+		}
+
+		rel, ok := internal.CleanRel(path.Join(parent, name))
+		if !ok || (parent != "" && !isBelow(rel, parent)) {
+			return gers.NewErrBadParam("spec", "has an invalid path "+strconv.Quote(e.Path))
+		}
+
+		is_dir := e.Dir || len(e.Children) > 0
+
+		action := Action{
+			Path: rel,
+			Dir:  is_dir,
+			Mode: e.Mode,
+		}
+
+		if is_dir {
+			if action.Mode == 0 {
+				action.Mode = DefaultDirMode
+			}
+		} else {
+			if action.Mode == 0 {
+				action.Mode = DefaultFileMode
+			}
+
+			content, err := internal.Render(e.Path, e.Content, vars)
+			if err != nil {
+				return err
+			}
+
+			action.Content = []byte(content)
+		}
+
+		fresh_children, err := p.add(parent, fresh, action, force, planned)
+		if err != nil {
+			return err
+		}
+
+		if is_dir {
+			err := p.plan(rel, fresh_children, e.Children, vars, force, planned)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// add plans the given action, along with the directories between its parent
+// and itself when its path has several elements.
+//
+// Parameters:
+//   - parent: The rendered path of the parent directory, relative to the root.
+//   - fresh: Whether the parent directory is going to be created from scratch.
+//   - action: The action to plan. Its Op is decided here.
+//   - force: Whether existing files are overwritten.
+//   - planned: The actions already planned, by path.
+//
+// Returns:
+//   - bool: Whether the children of the action are going to be created from scratch.
+//   - error: An error if the action could not be planned.
+func (p *Plan) add(parent string, fresh bool, action Action, force bool, planned map[string]Action) (bool, error) {
+	parts := strings.Split(strings.TrimPrefix(action.Path[len(parent):], "/"), "/")
+
+	dir := parent
+
+	for _, part := range parts[:len(parts)-1] {
+		dir = path.Join(dir, part)
+
+		between := Action{
+			Path: dir,
+			Dir:  true,
+			Mode: DefaultDirMode,
+		}
+
+		var err error
+
+		fresh, err = p.addOne(between, fresh, force, planned)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	fresh, err := p.addOne(action, fresh, force, planned)
+	return fresh, err
+}
+
+// addOne plans a single action, unless a directory with the same path was
+// already planned.
+//
+// Parameters:
+//   - action: The action to plan. Its Op is decided here.
+//   - fresh: Whether the parent directory is going to be created from scratch.
+//   - force: Whether existing files are overwritten.
+//   - planned: The actions already planned, by path.
+//
+// Returns:
+//   - bool: Whether the children of the action are going to be created from scratch.
+//   - error: An error if the action could not be planned.
+func (p *Plan) addOne(action Action, fresh, force bool, planned map[string]Action) (bool, error) {
+	prev, seen := planned[action.Path]
+	if seen {
+		if !prev.Dir || !action.Dir {
+			return false, gers.NewErrBadParam("spec", "declares "+strconv.Quote(action.Path)+" more than once")
+		}
+
+		return prev.Op != Skip, nil
+	}
+
+	if fresh {
+		action.Op = Create
+	} else {
+		op, err := p.decide(action.Path, action.Dir, force)
+		if err != nil {
+			return false, err
+		}
+
+		action.Op = op
+	}
+
+	planned[action.Path] = action
+	p.Actions = append(p.Actions, action)
+
+	return action.Op != Skip, nil
+}
+
+// isBelow checks whether rel is strictly inside dir.
+//
+// Parameters:
+//   - rel: The path to check.
+//   - dir: The directory.
+//
+// Returns:
+//   - bool: True if rel is inside dir, false otherwise.
+func isBelow(rel, dir string) bool {
+	return len(rel) > len(dir) && rel[:len(dir)] == dir && rel[len(dir)] == '/'
+}
+
+// decide decides what to do with the given entry.
+//
+// Parameters:
+//   - rel: The path of the entry, relative to the root.
+//   - is_dir: Whether the entry is a directory.
+//   - force: Whether existing files are overwritten.
+//
+// Returns:
+//   - Op: The operation to perform.
+//   - error: An error if the entry cannot be scaffolded.
+func (p Plan) decide(rel string, is_dir, force bool) (Op, error) {
+	info, err := p.fsys.Stat(path.Join(p.root, rel))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Create, nil
+	} else if err != nil {
+		return Skip, err
+	}
+
+	switch {
+	case info.IsDir() && is_dir:
+		return Skip, nil
+	case force:
+		return Overwrite, nil
+	case info.IsDir() != is_dir:
+		return Skip, &fs.PathError{Op: "scaffold", Path: rel, Err: fs.ErrExist}
+	default:
+		return Skip, nil
+	}
+}
+
+// Report returns what applying the plan would do.
+//
+// Returns:
+//   - Report: The paths affected by each operation.
+func (p Plan) Report() Report {
+	var r Report
+
+	for _, a := range p.Actions {
+		switch a.Op {
+		case Create:
+			r.Created = append(r.Created, a.Path)
+		case Skip:
+			r.Skipped = append(r.Skipped, a.Path)
+		case Overwrite:
+			r.Overwritten = append(r.Overwritten, a.Path)
+		}
+	}
+
+	return r
+}
+
+// Apply performs the actions of the plan. The root is created first if it
+// does not exist.
+//
+// Returns:
+//   - Report: The paths affected by each operation, up to the first failure.
+//   - error: An error if an action failed.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - any other error: If an entry could not be created.
+func (p *Plan) Apply() (Report, error) {
+	if p == nil {
+		return Report{}, gers.ErrNilReceiver
+	}
+
+	var done Report
+
+	err := p.fsys.MkdirAll(p.root, DefaultDirMode)
+	if err != nil {
+		return done, err
+	}
+
+	for _, a := range p.Actions {
+		err := p.apply(a)
+		if err != nil {
+			return done, err
+		}
+
+		switch a.Op {
+		case Create:
+			done.Created = append(done.Created, a.Path)
+		case Skip:
+			done.Skipped = append(done.Skipped, a.Path)
+		case Overwrite:
+			done.Overwritten = append(done.Overwritten, a.Path)
+		}
+	}
+
+	return done, nil
+}
+
+// apply performs a single action.
+//
+// Parameters:
+//   - a: The action to perform.
+//
+// Returns:
+//   - error: An error if the action failed.
+func (p Plan) apply(a Action) error {
+	if a.Op == Skip {
+		return nil
+	}
+
+	loc := path.Join(p.root, a.Path)
+
+	if a.Dir {
+		err := fm.CreateDirectory(p.fsys, loc, a.Mode, a.Op == Overwrite)
+		return err
+	}
+
+	if a.Op == Overwrite {
+		info, err := p.fsys.Lstat(loc)
+		if err == nil && info.IsDir() {
+			err := p.fsys.RemoveAll(loc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := p.fsys.OpenFile(loc, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, a.Mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(a.Content)
+
+	err2 := f.Close()
+	if err == nil {
+		err = err2
+	}
+
+	if err != nil || a.Op != Overwrite {
+		return err
+	}
+
+	// Overwritten files keep their mode otherwise.
+	err = p.fsys.Chmod(loc, a.Mode)
+	return err
+}
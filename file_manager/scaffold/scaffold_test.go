@@ -0,0 +1,194 @@
+package scaffold
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// testSpec is the spec scaffolded by the tests.
+var testSpec = &Spec{
+	Entries: []Entry{
+		{Path: "{{.Name}}", Children: []Entry{
+			{Path: "README.md", Content: "# {{.Name}}\n"},
+			{Path: "cmd/main.go", Content: "package main\n", Mode: 0o600},
+		}},
+	},
+}
+
+// testVars are the variables of testSpec.
+var testVars = map[string]string{"Name": "app"}
+
+// readFile reads a file of fsys.
+func readFile(t *testing.T, fsys fs.FS, name string) string {
+	t.Helper()
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// TestNewPlan tests that the plan renders the spec in order, parents first,
+// without modifying anything.
+func TestNewPlan(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	p, err := NewPlan(fsys, "out", testSpec, testVars, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Action{
+		{Path: "app", Dir: true, Op: Create, Mode: DefaultDirMode},
+		{Path: "app/README.md", Op: Create, Mode: DefaultFileMode, Content: []byte("# app\n")},
+		{Path: "app/cmd", Dir: true, Op: Create, Mode: DefaultDirMode},
+		{Path: "app/cmd/main.go", Op: Create, Mode: 0o600, Content: []byte("package main\n")},
+	}
+
+	if !reflect.DeepEqual(p.Actions, want) {
+		t.Errorf("expected %+v, got %+v", want, p.Actions)
+	}
+
+	_, err = fsys.Stat("out")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the plan to be a dry run, got %v", err)
+	}
+}
+
+// TestNewPlanInvalid tests that paths escaping their parent and duplicated
+// files are rejected.
+func TestNewPlanInvalid(t *testing.T) {
+	specs := map[string]*Spec{
+		"escape":    {Entries: []Entry{{Path: "../x"}}},
+		"child":     {Entries: []Entry{{Path: "d", Children: []Entry{{Path: "../x"}}}}},
+		"duplicate": {Entries: []Entry{{Path: "f"}, {Path: "f"}}},
+	}
+
+	for name, spec := range specs {
+		_, err := NewPlan(vfs.NewMemory(), "out", spec, nil, false)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestApply tests the creation of a tree, then the operations planned once it
+// exists, with and without force.
+func TestApply(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	p, err := NewPlan(fsys, "out", testSpec, testVars, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := p.Apply()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (Report{Created: []string{"app", "app/README.md", "app/cmd", "app/cmd/main.go"}}); !reflect.DeepEqual(r, want) {
+		t.Errorf("expected %+v, got %+v", want, r)
+	}
+
+	if got := readFile(t, fsys, "out/app/README.md"); got != "# app\n" {
+		t.Errorf("expected %q, got %q", "# app\n", got)
+	}
+
+	info, err := fsys.Stat("out/app/cmd/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	err = vfs.WriteFile(fsys, "out/app/README.md", []byte("edited"), 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip existing.
+	p, err = NewPlan(fsys, "out", testSpec, testVars, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Report{Skipped: []string{"app", "app/README.md", "app/cmd", "app/cmd/main.go"}}); !reflect.DeepEqual(p.Report(), want) {
+		t.Errorf("expected %+v, got %+v", want, p.Report())
+	}
+
+	_, err = p.Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, fsys, "out/app/README.md"); got != "edited" {
+		t.Errorf("expected the file to be skipped, got %q", got)
+	}
+
+	// Overwrite.
+	p, err = NewPlan(fsys, "out", testSpec, testVars, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = p.Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Report{Skipped: []string{"app", "app/cmd"}, Overwritten: []string{"app/README.md", "app/cmd/main.go"}}); !reflect.DeepEqual(r, want) {
+		t.Errorf("expected %+v, got %+v", want, r)
+	}
+
+	if got := readFile(t, fsys, "out/app/README.md"); got != "# app\n" {
+		t.Errorf("expected %q, got %q", "# app\n", got)
+	}
+
+	info, err = fsys.Stat("out/app/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != DefaultFileMode {
+		t.Errorf("expected mode %v, got %v", DefaultFileMode, info.Mode().Perm())
+	}
+}
+
+// TestApplyWrongKind tests that an entry of the wrong kind is an error unless
+// force is true.
+func TestApplyWrongKind(t *testing.T) {
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("out/app/README.md", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewPlan(fsys, "out", testSpec, testVars, false)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected fs.ErrExist, got %v", err)
+	}
+
+	p, err := NewPlan(fsys, "out", testSpec, testVars, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Apply()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := readFile(t, fsys, "out/app/README.md"); got != "# app\n" {
+		t.Errorf("expected %q, got %q", "# app\n", got)
+	}
+}
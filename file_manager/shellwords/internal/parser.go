@@ -0,0 +1,366 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+)
+
+// Parser splits a command line into words following the POSIX shell quoting
+// rules.
+type Parser struct {
+	// input is the remaining input to parse.
+	input []rune
+
+	// pos is the position of the next rune to read.
+	pos int
+
+	// env is the map used for variable expansion. If nil, no expansion is done.
+	env map[string]string
+}
+
+// NewParser creates a new parser for the given input.
+//
+// Parameters:
+//   - input: The command line to parse.
+//   - env: The variables to expand. If nil, '$' is treated as a literal.
+//
+// Returns:
+//   - *Parser: The new parser. Never returns nil.
+func NewParser(input string, env map[string]string) *Parser {
+	p := &Parser{
+		input: []rune(input),
+		env:   env,
+	}
+
+	return p
+}
+
+// isBlank checks whether the given rune separates words.
+//
+// Parameters:
+//   - r: The rune to check.
+//
+// Returns:
+//   - bool: True if the rune is a word separator, false otherwise.
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// isNameStart checks whether the given rune can start a variable name.
+//
+// Parameters:
+//   - r: The rune to check.
+//
+// Returns:
+//   - bool: True if the rune can start a name, false otherwise.
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isNameRune checks whether the given rune can appear in a variable name.
+//
+// Parameters:
+//   - r: The rune to check.
+//
+// Returns:
+//   - bool: True if the rune can appear in a name, false otherwise.
+func isNameRune(r rune) bool {
+	return isNameStart(r) || (r >= '0' && r <= '9')
+}
+
+// eof returns the error for an input that ended while want was expected.
+//
+// Parameters:
+//   - want: The expected value.
+//
+// Returns:
+//   - error: The error. Never returns nil.
+func eof(want string) error {
+	err := gers.NewErrUnexpected("", want, "end of input")
+	return err
+}
+
+// Words parses the whole input and returns the words it contains.
+//
+// Returns:
+//   - []string: The parsed words.
+//   - error: An error if the input is malformed.
+//
+// Errors:
+//   - *errors.ErrUnexpected: If a quote, an escape or a variable is not terminated.
+func (p *Parser) Words() ([]string, error) {
+	var words []string
+
+	for {
+		p.skipBlanksAndComments()
+
+		if p.pos >= len(p.input) {
+			break
+		}
+
+		word, err := p.word()
+		if err != nil {
+			return words, err
+		}
+
+		words = append(words, word)
+	}
+
+	return words, nil
+}
+
+// skipBlanksAndComments advances the parser past any blanks, line
+// continuations and comments. A comment starts with a '#' at the beginning of
+// a word and ends at the next newline.
+func (p *Parser) skipBlanksAndComments() {
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+
+		if isBlank(r) {
+			p.pos++
+			continue
+		}
+
+		// A backslash-newline is removed before words are split, so it does
+		// not start a word by itself.
+		if r == '\\' && p.pos+1 < len(p.input) && p.input[p.pos+1] == '\n' {
+			p.pos += 2
+			continue
+		}
+
+		if r != '#' {
+			return
+		}
+
+		for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// word parses a single word. The parser must not be positioned on a blank.
+//
+// Returns:
+//   - string: The parsed word.
+//   - error: An error if the word is malformed.
+func (p *Parser) word() (string, error) {
+	var builder strings.Builder
+
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+
+		if isBlank(r) {
+			break
+		}
+
+		p.pos++
+
+		switch r {
+		case '\'':
+			err := p.singleQuoted(&builder)
+			if err != nil {
+				return "", err
+			}
+		case '"':
+			err := p.doubleQuoted(&builder)
+			if err != nil {
+				return "", err
+			}
+		case '\\':
+			if p.pos >= len(p.input) {
+				return "", eof("escaped character")
+			}
+
+			next := p.input[p.pos]
+			p.pos++
+
+			if next != '\n' {
+				_, _ = builder.WriteRune(next)
+			}
+		case '$':
+			err := p.expand(&builder)
+			if err != nil {
+				return "", err
+			}
+		default:
+			_, _ = builder.WriteRune(r)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// singleQuoted reads up to the closing single quote. Everything in between is
+// taken literally.
+//
+// Parameters:
+//   - builder: The builder to write the quoted text to.
+//
+// Returns:
+//   - error: An error if the closing quote is missing.
+func (p *Parser) singleQuoted(builder *strings.Builder) error {
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		p.pos++
+
+		if r == '\'' {
+			return nil
+		}
+
+		_, _ = builder.WriteRune(r)
+	}
+
+	return eof(strconv.Quote("'"))
+}
+
+// doubleQuoted reads up to the closing double quote. Inside double quotes, a
+// backslash only escapes '$', '`', '"', '\\' and newline, and variables are
+// expanded.
+//
+// Parameters:
+//   - builder: The builder to write the quoted text to.
+//
+// Returns:
+//   - error: An error if the closing quote is missing.
+func (p *Parser) doubleQuoted(builder *strings.Builder) error {
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		p.pos++
+
+		switch r {
+		case '"':
+			return nil
+		case '\\':
+			if p.pos >= len(p.input) {
+				return eof(strconv.Quote("\""))
+			}
+
+			next := p.input[p.pos]
+
+			switch next {
+			case '$', '`', '"', '\\':
+				p.pos++
+				_, _ = builder.WriteRune(next)
+			case '\n':
+				p.pos++
+			default:
+				_, _ = builder.WriteRune(r)
+			}
+		case '$':
+			err := p.expand(builder)
+			if err != nil {
+				return err
+			}
+		default:
+			_, _ = builder.WriteRune(r)
+		}
+	}
+
+	return eof(strconv.Quote("\""))
+}
+
+// expand expands the variable that follows a '$'. Both the $NAME and the
+// ${NAME} forms are supported; unset variables expand to the empty string.
+// When no environment was given, or the '$' is not followed by a name, the '$'
+// is kept as is.
+//
+// Parameters:
+//   - builder: The builder to write the expansion to.
+//
+// Returns:
+//   - error: An error if a "${" is not terminated.
+func (p *Parser) expand(builder *strings.Builder) error {
+	if p.env == nil || p.pos >= len(p.input) {
+		_, _ = builder.WriteRune('$')
+		return nil
+	}
+
+	r := p.input[p.pos]
+
+	if r == '{' {
+		start := p.pos + 1
+		end := start
+
+		for end < len(p.input) && p.input[end] != '}' {
+			end++
+		}
+
+		if end >= len(p.input) {
+			return eof(strconv.Quote("}"))
+		}
+
+		name := string(p.input[start:end])
+		if name == "" {
+			return gers.NewErrUnexpected("variable name", "non-empty", "")
+		}
+
+		p.pos = end + 1
+
+		_, _ = builder.WriteString(p.env[name])
+
+		return nil
+	}
+
+	if !isNameStart(r) {
+		_, _ = builder.WriteRune('$')
+		return nil
+	}
+
+	start := p.pos
+
+	for p.pos < len(p.input) && isNameRune(p.input[p.pos]) {
+		p.pos++
+	}
+
+	name := string(p.input[start:p.pos])
+
+	_, _ = builder.WriteString(p.env[name])
+
+	return nil
+}
+
+// IsSafe checks whether the given word can be passed to sh without quoting.
+//
+// Parameters:
+//   - word: The word to check.
+//
+// Returns:
+//   - bool: True if the word needs no quoting, false otherwise.
+func IsSafe(word string) bool {
+	if word == "" {
+		return false
+	}
+
+	for _, r := range word {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("@%+=:,./_-", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// QuoteWord quotes the given word with single quotes. Single quotes inside
+// the word close the quoting, are escaped with a backslash and reopen it.
+//
+// Parameters:
+//   - builder: The builder to write the quoted word to.
+//   - word: The word to quote.
+func QuoteWord(builder *strings.Builder, word string) {
+	_, _ = builder.WriteRune('\'')
+
+	for _, r := range word {
+		if r == '\'' {
+			_, _ = builder.WriteString(`'\''`)
+		} else {
+			_, _ = builder.WriteRune(r)
+		}
+	}
+
+	_, _ = builder.WriteRune('\'')
+}
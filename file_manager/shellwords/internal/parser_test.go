@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestWords tests the Words method.
+func TestWords(t *testing.T) {
+	env := map[string]string{
+		"HOME": "/home/me",
+		"ARGS": "a b",
+	}
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`echo hello   world`, []string{"echo", "hello", "world"}},
+		{`echo 'a b' "c d"`, []string{"echo", "a b", "c d"}},
+		{`echo a\ b ''`, []string{"echo", "a b", ""}},
+		{`echo "\$HOME" '$HOME' $HOME`, []string{"echo", "$HOME", "$HOME", "/home/me"}},
+		{`echo "${HOME}/x" $ARGS`, []string{"echo", "/home/me/x", "a b"}},
+		{"ls # comment\n-l a#b", []string{"ls", "-l", "a#b"}},
+		{"echo a\\\nb", []string{"echo", "ab"}},
+		{"a \\\n b", []string{"a", "b"}},
+		{"a \\\n\\\n", []string{"a"}},
+		{"\\\n# comment\nb", []string{"b"}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(test.input, env)
+
+		words, err := p.Words()
+		if err != nil {
+			t.Errorf("input %q: unexpected error: %v", test.input, err)
+		} else if !slices.Equal(words, test.expected) {
+			t.Errorf("input %q: expected %q, got %q", test.input, test.expected, words)
+		}
+	}
+
+	for _, input := range []string{`echo 'a`, `echo "a`, `echo a\`, `echo ${HOME`} {
+		p := NewParser(input, env)
+
+		_, err := p.Words()
+		if err == nil {
+			t.Errorf("input %q: expected an error", input)
+		}
+	}
+}
+
+// TestQuoteWord tests that quoted words are parsed back unchanged.
+func TestQuoteWord(t *testing.T) {
+	words := []string{"", "plain", "it's", "a b", "$HOME", `back\slash`, "new\nline"}
+
+	for _, word := range words {
+		var builder strings.Builder
+
+		QuoteWord(&builder, word)
+
+		p := NewParser(builder.String(), map[string]string{})
+
+		got, err := p.Words()
+		if err != nil {
+			t.Errorf("word %q: unexpected error: %v", word, err)
+		} else if len(got) != 1 || got[0] != word {
+			t.Errorf("word %q: got %q", word, got)
+		}
+	}
+}
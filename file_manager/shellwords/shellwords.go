@@ -0,0 +1,124 @@
+package shellwords
+
+import (
+	"strings"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/shellwords/internal"
+)
+
+// Split splits a command line into words following the POSIX shell quoting
+// rules. Single quotes, double quotes, backslash escapes and comments are
+// supported; '$' is always taken literally.
+//
+// Parameters:
+//   - line: The command line to split.
+//
+// Returns:
+//   - []string: The words of the command line.
+//   - error: An error if the command line is malformed.
+//
+// Errors:
+//   - *errors.ErrUnexpected: If a quote or an escape is not terminated.
+func Split(line string) ([]string, error) {
+	if line == "" {
+		return nil, nil
+	}
+
+	p := internal.NewParser(line, nil)
+
+	words, err := p.Words()
+	if err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// SplitEnv is like Split but also expands $NAME and ${NAME} outside of single
+// quotes using the given variables. Unset variables expand to the empty string
+// and expanded values are never split into several words.
+//
+// Parameters:
+//   - line: The command line to split.
+//   - env: The variables to expand. If nil, '$' is taken literally.
+//
+// Returns:
+//   - []string: The words of the command line.
+//   - error: An error if the command line is malformed.
+//
+// Errors:
+//   - *errors.ErrUnexpected: If a quote, an escape or a "${" is not terminated.
+func SplitEnv(line string, env map[string]string) ([]string, error) {
+	if line == "" {
+		return nil, nil
+	}
+
+	p := internal.NewParser(line, env)
+
+	words, err := p.Words()
+	if err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// QuoteWord quotes a single word so that sh reads it back unchanged. Words
+// that do not need quoting are returned as is.
+//
+// Parameters:
+//   - word: The word to quote.
+//
+// Returns:
+//   - string: The quoted word.
+//
+// Example:
+//
+//	QuoteWord("it's") // returns 'it'\''s'
+func QuoteWord(word string) string {
+	ok := internal.IsSafe(word)
+	if ok {
+		return word
+	}
+
+	var builder strings.Builder
+
+	internal.QuoteWord(&builder, word)
+
+	return builder.String()
+}
+
+// Quote quotes every argument with QuoteWord and joins them with spaces, so
+// that the result is safe to hand to sh.
+//
+// Parameters:
+//   - args: The arguments to quote.
+//
+// Returns:
+//   - string: The quoted command line.
+//
+// Example:
+//
+//	Quote([]string{"echo", "hello world"}) // returns echo 'hello world'
+func Quote(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	for i, arg := range args {
+		if i > 0 {
+			_, _ = builder.WriteRune(' ')
+		}
+
+		ok := internal.IsSafe(arg)
+		if ok {
+			_, _ = builder.WriteString(arg)
+		} else {
+			internal.QuoteWord(&builder, arg)
+		}
+	}
+
+	return builder.String()
+}
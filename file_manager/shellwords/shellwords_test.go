@@ -0,0 +1,116 @@
+package shellwords
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+)
+
+// TestSplit tests Split, including line continuations between words.
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"\\\n", nil},
+		{"a \\\n b", []string{"a", "b"}},
+		{"a\\\nb", []string{"ab"}},
+		{`cp "my file" 'it''s' a\ b`, []string{"cp", "my file", "its", "a b"}},
+		{`echo $HOME "${HOME}"`, []string{"echo", "$HOME", "${HOME}"}},
+		{"run # the rest is ignored", []string{"run"}},
+		{`"" ''`, []string{"", ""}},
+	}
+
+	for _, test := range tests {
+		got, err := Split(test.line)
+		if err != nil || !slices.Equal(got, test.want) {
+			t.Errorf("Split(%q) = %q, %v; want %q, nil", test.line, got, err, test.want)
+		}
+	}
+
+	for _, line := range []string{`'open`, `"open`, `trailing\`} {
+		_, err := Split(line)
+
+		var unexpected *gers.ErrUnexpected
+
+		if !errors.As(err, &unexpected) {
+			t.Errorf("Split(%q) error = %v; want *errors.ErrUnexpected", line, err)
+		}
+	}
+}
+
+// TestSplitEnv tests that SplitEnv expands variables outside of single quotes
+// without splitting their values.
+func TestSplitEnv(t *testing.T) {
+	env := map[string]string{
+		"DIR":  "/tmp/a b",
+		"NAME": "x",
+	}
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`ls $DIR`, []string{"ls", "/tmp/a b"}},
+		{`echo "${NAME}y" '$NAME' \$NAME`, []string{"echo", "xy", "$NAME", "$NAME"}},
+		{`echo $UNSET. $ 1$`, []string{"echo", ".", "$", "1$"}},
+	}
+
+	for _, test := range tests {
+		got, err := SplitEnv(test.line, env)
+		if err != nil || !slices.Equal(got, test.want) {
+			t.Errorf("SplitEnv(%q) = %q, %v; want %q, nil", test.line, got, err, test.want)
+		}
+	}
+
+	got, err := SplitEnv(`echo $NAME`, nil)
+	if err != nil || !slices.Equal(got, []string{"echo", "$NAME"}) {
+		t.Errorf("SplitEnv with a nil env = %q, %v; want the '$' taken literally", got, err)
+	}
+
+	_, err = SplitEnv(`echo ${NAME`, env)
+	if err == nil {
+		t.Error("SplitEnv of an unterminated \"${\" succeeded")
+	}
+}
+
+// TestQuote tests Quote and QuoteWord, and that Split reads their output back
+// unchanged.
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"echo", "hello world"}, "echo 'hello world'"},
+		{[]string{"it's"}, `'it'\''s'`},
+		{[]string{"", "a=b,c:d/e.f"}, "'' a=b,c:d/e.f"},
+	}
+
+	for _, test := range tests {
+		got := Quote(test.args)
+		if got != test.want {
+			t.Errorf("Quote(%q) = %q; want %q", test.args, got, test.want)
+		}
+	}
+
+	if got := QuoteWord("$HOME"); got != "'$HOME'" {
+		t.Errorf("QuoteWord(%q) = %q; want %q", "$HOME", got, "'$HOME'")
+	}
+
+	args := []string{"", "plain", "it's", "a b", "$HOME", `back\slash`, "new\nline", "#hash", `"dq"`, "\\\n", "日本"}
+
+	got, err := Split(Quote(args))
+	if err != nil || !slices.Equal(got, args) {
+		t.Errorf("Split(Quote(%q)) = %q, %v; want the arguments back", args, got, err)
+	}
+
+	got, err = SplitEnv(Quote(args), map[string]string{"HOME": "/home"})
+	if err != nil || !slices.Equal(got, args) {
+		t.Errorf("SplitEnv(Quote(%q)) = %q, %v; want the arguments back", args, got, err)
+	}
+}
@@ -0,0 +1,37 @@
+package internal
+
+// DiffKeys compares two sorted lists of keys.
+//
+// Parameters:
+//   - old: The old keys, sorted in increasing order.
+//   - new: The new keys, sorted in increasing order.
+//
+// Returns:
+//   - []string: The keys only present in new.
+//   - []string: The keys only present in old.
+//   - []string: The keys present in both.
+func DiffKeys(old, new []string) ([]string, []string, []string) {
+	var added, removed, common []string
+
+	var i, j int
+
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			common = append(common, old[i])
+			i++
+			j++
+		case old[i] < new[j]:
+			removed = append(removed, old[i])
+			i++
+		default:
+			added = append(added, new[j])
+			j++
+		}
+	}
+
+	removed = append(removed, old[i:]...)
+	added = append(added, new[j:]...)
+
+	return added, removed, common
+}
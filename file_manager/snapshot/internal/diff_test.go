@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestDiffKeys tests the DiffKeys function.
+func TestDiffKeys(t *testing.T) {
+	old := []string{"a", "b", "d", "f"}
+	new := []string{"b", "c", "d", "g", "h"}
+
+	added, removed, common := DiffKeys(old, new)
+
+	if !slices.Equal(added, []string{"c", "g", "h"}) {
+		t.Errorf("unexpected added keys: %v", added)
+	}
+
+	if !slices.Equal(removed, []string{"a", "f"}) {
+		t.Errorf("unexpected removed keys: %v", removed)
+	}
+
+	if !slices.Equal(common, []string{"b", "d"}) {
+		t.Errorf("unexpected common keys: %v", common)
+	}
+}
@@ -0,0 +1,352 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	fmi "github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/snapshot/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// Kind is the kind of an entry of a snapshot.
+type Kind string
+
+const (
+	// File is a regular file.
+	File Kind = "file"
+
+	// Dir is a directory.
+	Dir Kind = "dir"
+
+	// Symlink is a symbolic link.
+	Symlink Kind = "symlink"
+
+	// Other is any other kind of entry (device, socket, named pipe, ...).
+	Other Kind = "other"
+)
+
+// kindOf returns the kind of an entry with the given mode.
+//
+// Parameters:
+//   - mode: The mode of the entry.
+//
+// Returns:
+//   - Kind: The kind of the entry.
+func kindOf(mode fs.FileMode) Kind {
+	switch {
+	case mode.IsRegular():
+		return File
+	case mode.IsDir():
+		return Dir
+	case mode&fs.ModeSymlink != 0:
+		return Symlink
+	default:
+		return Other
+	}
+}
+
+// Entry describes a single entry of a directory tree.
+type Entry struct {
+	// Path is the slash-separated path of the entry, relative to the root.
+	Path string `json:"path"`
+
+	// Kind is the kind of the entry.
+	Kind Kind `json:"kind"`
+
+	// Size is the size of a file, or the length of the target of a symbolic link.
+	Size int64 `json:"size"`
+
+	// Mode holds the permission bits of the entry, along with the setuid,
+	// setgid and sticky bits.
+	Mode fs.FileMode `json:"mode"`
+
+	// SHA256 is the hex-encoded digest of the content of a file.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Target is the target of a symbolic link.
+	Target string `json:"target,omitempty"`
+}
+
+// Snapshot is the description of a directory tree at a given time.
+type Snapshot struct {
+	// Entries are the entries of the tree, sorted by path. The root itself is
+	// not included.
+	Entries []Entry `json:"entries"`
+}
+
+// Take walks the tree rooted at root and describes every entry in it. The
+// content of the files is hashed in parallel.
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - root: The root of the tree.
+//
+// Returns:
+//   - *Snapshot: The snapshot of the tree.
+//   - error: An error if the tree could not be read.
+//
+// Errors:
+//   - fs.ErrNotExist: If the root does not exist.
+//   - any other error: If an entry could not be read.
+func Take(fsys vfs.FS, root string) (*Snapshot, error) {
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	// fs.WalkDir cleans the names it reports, so the root must be clean too
+	// for the entries to be named the same however the root is spelled.
+	root = path.Clean(root)
+
+	ok, err := fm.Exists(fsys, root)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &fs.PathError{Op: "snapshot", Path: root, Err: fs.ErrNotExist}
+	}
+
+	var entries []Entry
+	var files []string
+	var file_idx []int
+
+	err = fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == root {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := Entry{
+			Path: fmi.RelTo(root, name),
+			Kind: kindOf(info.Mode()),
+			Mode: info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky),
+		}
+
+		switch entry.Kind {
+		case File:
+			entry.Size = info.Size()
+
+			files = append(files, name)
+			file_idx = append(file_idx, len(entries))
+		case Symlink:
+			target, err := fsys.ReadLink(name)
+			if err != nil {
+				return err
+			}
+
+			entry.Target = target
+			entry.Size = int64(len(target))
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digests, errs := fmi.HashFiles(fsys, files, sha256.New, runtime.NumCPU())
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, digest := range digests {
+		entries[file_idx[i]].SHA256 = digest
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	s := &Snapshot{
+		Entries: entries,
+	}
+
+	return s, nil
+}
+
+// Load reads a snapshot previously written with Save.
+//
+// Parameters:
+//   - r: The reader to read from. Must not be nil.
+//
+// Returns:
+//   - *Snapshot: The snapshot read.
+//   - error: An error if the snapshot could not be read.
+//
+// Errors:
+//   - *errors.ErrBadParam: If r is nil.
+//   - any other error: If the JSON is malformed or could not be read.
+func Load(r io.Reader) (*Snapshot, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParam("r")
+	}
+
+	var s Snapshot
+
+	err := json.NewDecoder(r).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(s.Entries, func(a, b Entry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return &s, nil
+}
+
+// Save writes the snapshot as indented JSON.
+//
+// Parameters:
+//   - w: The writer to write to. Must not be nil.
+//
+// Returns:
+//   - error: An error if the snapshot could not be written.
+//
+// Errors:
+//   - *errors.ErrBadParam: If w is nil.
+//   - any other error: If the snapshot could not be written.
+func (s Snapshot) Save(w io.Writer) error {
+	if w == nil {
+		return gers.NewErrNilParam("w")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+
+	err := enc.Encode(s)
+	return err
+}
+
+// index returns the paths of the entries and the entries by path.
+//
+// Returns:
+//   - []string: The sorted paths of the entries.
+//   - map[string]Entry: The entries by path.
+func (s Snapshot) index() ([]string, map[string]Entry) {
+	paths := make([]string, 0, len(s.Entries))
+	by_path := make(map[string]Entry, len(s.Entries))
+
+	for _, e := range s.Entries {
+		paths = append(paths, e.Path)
+		by_path[e.Path] = e
+	}
+
+	slices.Sort(paths)
+
+	return paths, by_path
+}
+
+// Diff is the difference between two snapshots. Every list is sorted.
+type Diff struct {
+	// Added are the paths only present in the new snapshot.
+	Added []string `json:"added,omitempty"`
+
+	// Removed are the paths only present in the old snapshot.
+	Removed []string `json:"removed,omitempty"`
+
+	// Modified are the paths whose kind, content or link target changed.
+	Modified []string `json:"modified,omitempty"`
+
+	// ModeChanged are the paths whose mode changed.
+	ModeChanged []string `json:"mode_changed,omitempty"`
+}
+
+// IsEmpty checks whether the two snapshots were identical.
+//
+// Returns:
+//   - bool: True if there is no difference, false otherwise.
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 && len(d.ModeChanged) == 0
+}
+
+// Compare computes the difference between two snapshots.
+//
+// Parameters:
+//   - before: The old snapshot. If nil, it is considered empty.
+//   - after: The new snapshot. If nil, it is considered empty.
+//
+// Returns:
+//   - Diff: The difference between the snapshots.
+func Compare(before, after *Snapshot) Diff {
+	if before == nil {
+		before = new(Snapshot)
+	}
+
+	if after == nil {
+		after = new(Snapshot)
+	}
+
+	old_paths, old_entries := before.index()
+	new_paths, new_entries := after.index()
+
+	added, removed, common := internal.DiffKeys(old_paths, new_paths)
+
+	d := Diff{
+		Added:   added,
+		Removed: removed,
+	}
+
+	for _, p := range common {
+		a := old_entries[p]
+		b := new_entries[p]
+
+		if a.Kind != b.Kind || a.Size != b.Size || a.SHA256 != b.SHA256 || a.Target != b.Target {
+			d.Modified = append(d.Modified, p)
+		}
+
+		if a.Mode != b.Mode {
+			d.ModeChanged = append(d.ModeChanged, p)
+		}
+	}
+
+	return d
+}
+
+// DiffLive compares the snapshot with the current state of the tree it was
+// taken from.
+//
+// Parameters:
+//   - fsys: The file system to read from. If nil, the native file system is used.
+//   - root: The root of the tree.
+//
+// Returns:
+//   - Diff: The changes made to the tree since the snapshot was taken.
+//   - error: An error if the tree could not be read.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - any error returned by Take.
+func (s *Snapshot) DiffLive(fsys vfs.FS, root string) (Diff, error) {
+	if s == nil {
+		return Diff{}, gers.ErrNilReceiver
+	}
+
+	live, err := Take(fsys, root)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	d := Compare(s, live)
+	return d, nil
+}
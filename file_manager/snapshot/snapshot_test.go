@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// newTree creates a small tree in memory, under gen.
+func newTree(t *testing.T) *vfs.Memory {
+	t.Helper()
+
+	fsys := vfs.NewMemory()
+
+	err := fsys.MkdirAll("gen/sub", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{"gen/a.txt": "a", "gen/sub/b.txt": "b"} {
+		err := vfs.WriteFile(fsys, name, []byte(data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = fsys.Symlink("sub/b.txt", "gen/link")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fsys
+}
+
+// paths returns the paths of the entries of a snapshot.
+func paths(s *Snapshot) []string {
+	result := make([]string, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		result = append(result, e.Path)
+	}
+
+	return result
+}
+
+// TestTake tests that entries are described relative to the root, however it is spelled.
+func TestTake(t *testing.T) {
+	fsys := newTree(t)
+
+	want := []string{"a.txt", "link", "sub", "sub/b.txt"}
+
+	var first *Snapshot
+
+	for _, root := range []string{"gen", "./gen/", "gen/sub/.."} {
+		s, err := Take(fsys, root)
+		if err != nil {
+			t.Fatalf("root %q: unexpected error: %v", root, err)
+		}
+
+		if got := paths(s); !slices.Equal(got, want) {
+			t.Errorf("root %q: expected %v, got %v", root, want, got)
+		}
+
+		if first == nil {
+			first = s
+		} else if d := Compare(first, s); !d.IsEmpty() {
+			t.Errorf("root %q: expected no difference, got %+v", root, d)
+		}
+	}
+
+	link := first.Entries[1]
+	if link.Kind != Symlink || link.Target != "sub/b.txt" {
+		t.Errorf("expected a symlink to sub/b.txt, got %+v", link)
+	}
+
+	file := first.Entries[0]
+	if file.Kind != File || file.Size != 1 || file.SHA256 != "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb" {
+		t.Errorf("unexpected file entry %+v", file)
+	}
+}
+
+// TestTakeNative tests a native root with a trailing separator.
+func TestTakeNative(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "f"), []byte("x"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Take(nil, dir+"/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := paths(s); !slices.Equal(got, []string{"f"}) {
+		t.Errorf("expected [f], got %v", got)
+	}
+}
+
+// TestCompare tests that every kind of change is reported.
+func TestCompare(t *testing.T) {
+	fsys := newTree(t)
+
+	before, err := Take(fsys, "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vfs.WriteFile(fsys, "gen/a.txt", []byte("changed"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fsys.Chmod("gen/sub/b.txt", 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fsys.Remove("gen/link")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vfs.WriteFile(fsys, "gen/new.txt", nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := before.DiffLive(fsys, "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Diff{
+		Added:       []string{"new.txt"},
+		Removed:     []string{"link"},
+		Modified:    []string{"a.txt"},
+		ModeChanged: []string{"sub/b.txt"},
+	}
+
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("expected %+v, got %+v", want, d)
+	}
+}
+
+// TestSaveLoad tests the JSON round-trip of a snapshot.
+func TestSaveLoad(t *testing.T) {
+	s, err := Take(newTree(t), "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	err = s.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(s, loaded) {
+		t.Errorf("expected %+v, got %+v", s, loaded)
+	}
+}
@@ -0,0 +1,89 @@
+package supervisor
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrNotReady occurs when a child is not ready before its readiness
+	// timeout. This error can be checked with errors.Is.
+	//
+	// Format:
+	// 	"not ready in time"
+	ErrNotReady error
+
+	// ErrGaveUp occurs when a child stopped for good before being ready, either
+	// because its policy does not restart it or because it restarted too many
+	// times. This error can be checked with errors.Is.
+	//
+	// Format:
+	// 	"exited before being ready"
+	ErrGaveUp error
+
+	// ErrStarted occurs when a supervisor is started more than once. This error
+	// can be checked with the == operator.
+	//
+	// Format:
+	// 	"supervisor was already started"
+	ErrStarted error
+)
+
+func init() {
+	ErrNotReady = errors.New("not ready in time")
+	ErrGaveUp = errors.New("exited before being ready")
+	ErrStarted = errors.New("supervisor was already started")
+}
+
+// ErrChild occurs when a child of a supervisor fails.
+type ErrChild struct {
+	// Name is the name of the child.
+	Name string
+
+	// Inner is the reason of the failure.
+	Inner error
+}
+
+// Error implements error.
+func (e ErrChild) Error() string {
+	msg := "child " + strconv.Quote(e.Name)
+
+	if e.Inner == nil {
+		return msg + " failed"
+	}
+
+	return msg + ": " + e.Inner.Error()
+}
+
+// NewErrChild creates a new ErrChild error.
+//
+// Parameters:
+//   - name: The name of the child.
+//   - inner: The reason of the failure.
+//
+// Returns:
+//   - error: An instance of ErrChild. Never returns nil.
+//
+// Format:
+//
+//	"child <name>: <inner>"
+//
+// Where:
+//   - <name> is the quoted name of the child.
+//   - <inner> is the reason of the failure. If nil, ": <inner>" is replaced by " failed".
+func NewErrChild(name string, inner error) error {
+	e := &ErrChild{
+		Name:  name,
+		Inner: inner,
+	}
+
+	return e
+}
+
+// Unwrap returns the reason of the failure.
+//
+// Returns:
+//   - error: The reason of the failure.
+func (e ErrChild) Unwrap() error {
+	return e.Inner
+}
@@ -0,0 +1,50 @@
+//go:build !unix
+
+package internal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Group makes the command start in a process group of its own. Process
+// groups are not supported on this platform, so only the process itself is
+// ever signaled.
+//
+// Parameters:
+//   - cmd: The command, not started yet. Must not be nil.
+func Group(cmd *exec.Cmd) {}
+
+// Terminate asks the process to terminate. As there is no gentle way to do so
+// on this platform, the process is killed.
+//
+// Parameters:
+//   - pid: The PID of the process.
+//
+// Returns:
+//   - error: An error if the process could not be killed.
+func Terminate(pid int) error {
+	return Kill(pid)
+}
+
+// Kill kills the process.
+//
+// Parameters:
+//   - pid: The PID of the process.
+//
+// Returns:
+//   - error: An error if the process could not be killed.
+func Kill(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	err = p.Kill()
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+
+	return err
+}
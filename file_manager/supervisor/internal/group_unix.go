@@ -0,0 +1,54 @@
+//go:build unix
+
+package internal
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// Group makes the command start in a process group of its own, so that it can
+// be signaled along with its descendants.
+//
+// Parameters:
+//   - cmd: The command, not started yet. Must not be nil.
+func Group(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// Terminate asks the process group started by Group to terminate.
+//
+// Parameters:
+//   - pid: The PID of the leader of the group.
+//
+// Returns:
+//   - error: An error if the group could not be signaled.
+func Terminate(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGTERM)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+
+	return err
+}
+
+// Kill kills the process group started by Group.
+//
+// Parameters:
+//   - pid: The PID of the leader of the group.
+//
+// Returns:
+//   - error: An error if the group could not be signaled.
+func Kill(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+
+	return err
+}
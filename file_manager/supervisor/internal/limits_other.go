@@ -0,0 +1,23 @@
+//go:build !unix
+
+package internal
+
+// Supported is whether WithLimits is supported on this platform.
+const Supported bool = false
+
+// WithLimits wraps a command so that it runs with the given resource limits.
+// It is not supported on this platform, so the command is returned as is.
+//
+// Parameters:
+//   - path: The program to run.
+//   - args: The arguments of the program.
+//   - cpu: The CPU time, in seconds.
+//   - memory: The size of the address space, in bytes.
+//   - files: The number of open files.
+//
+// Returns:
+//   - string: The program to run.
+//   - []string: Its arguments.
+func WithLimits(path string, args []string, cpu, memory, files uint64) (string, []string) {
+	return path, args
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Supported is whether WithLimits is supported on this platform.
+const Supported bool = true
+
+// WithLimits wraps a command so that it runs with the given resource limits,
+// both soft and hard. The limits are set by /bin/sh right before it replaces
+// itself with the program, so they apply from the very first instruction of
+// the program. A zero value leaves the corresponding limit unchanged.
+//
+// Parameters:
+//   - path: The program to run.
+//   - args: The arguments of the program.
+//   - cpu: The CPU time, in seconds.
+//   - memory: The size of the address space, in bytes.
+//   - files: The number of open files.
+//
+// Returns:
+//   - string: The program to run instead.
+//   - []string: Its arguments.
+func WithLimits(path string, args []string, cpu, memory, files uint64) (string, []string) {
+	var steps []string
+
+	if cpu > 0 {
+		steps = append(steps, "ulimit -t "+strconv.FormatUint(cpu, 10))
+	}
+
+	if memory > 0 {
+		kib := (memory + 1023) / 1024
+		steps = append(steps, "ulimit -v "+strconv.FormatUint(kib, 10))
+	}
+
+	if files > 0 {
+		steps = append(steps, "ulimit -n "+strconv.FormatUint(files, 10))
+	}
+
+	if len(steps) == 0 {
+		return path, args
+	}
+
+	script := strings.Join(steps, " && ") + ` && exec "$0" "$@"`
+
+	wrapped := append([]string{"-c", script, path}, args...)
+
+	return "/bin/sh", wrapped
+}
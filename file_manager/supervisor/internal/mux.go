@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Mux multiplexes whole lines of several sources into a single writer.
+type Mux struct {
+	// mu serializes the writes.
+	mu sync.Mutex
+
+	// w is the writer to write to.
+	w io.Writer
+}
+
+// NewMux creates a new Mux.
+//
+// Parameters:
+//   - w: The writer to write to. If nil, the lines are discarded.
+//
+// Returns:
+//   - *Mux: The new Mux. Never returns nil.
+func NewMux(w io.Writer) *Mux {
+	if w == nil {
+		w = io.Discard
+	}
+
+	m := &Mux{
+		w: w,
+	}
+
+	return m
+}
+
+// line writes a single line, prefixed. Errors of the writer are ignored, as
+// the output of a child must never block it.
+//
+// Parameters:
+//   - prefix: The prefix of the line.
+//   - line: The line, without the trailing newline.
+func (m *Mux) line(prefix string, line []byte) {
+	buf := make([]byte, 0, len(prefix)+len(line)+1)
+	buf = append(buf, prefix...)
+	buf = append(buf, line...)
+	buf = append(buf, '\n')
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _ = m.w.Write(buf)
+}
+
+// Source is a writer that splits what it is given into lines and hands each
+// of them, prefixed, to a Mux.
+type Source struct {
+	// mux is the Mux to write to.
+	mux *Mux
+
+	// prefix is the prefix of the lines.
+	prefix string
+
+	// on_line is called with every complete line, if not nil.
+	on_line func(line string)
+
+	// partial is the last, incomplete line.
+	partial []byte
+}
+
+// Source creates a new source of lines.
+//
+// Parameters:
+//   - prefix: The prefix of the lines.
+//   - on_line: The function called with every line, without prefix nor newline. May be nil.
+//
+// Returns:
+//   - *Source: The new source. Never returns nil.
+func (m *Mux) Source(prefix string, on_line func(line string)) *Source {
+	s := &Source{
+		mux:     m,
+		prefix:  prefix,
+		on_line: on_line,
+	}
+
+	return s
+}
+
+// Write implements io.Writer.
+func (s *Source) Write(p []byte) (int, error) {
+	data := append(s.partial, p...)
+
+	for {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+
+		s.emit(bytes.TrimSuffix(data[:idx], []byte{'\r'}))
+
+		data = data[idx+1:]
+	}
+
+	s.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// emit hands a complete line to the Mux and to the callback.
+//
+// Parameters:
+//   - line: The line, without the trailing newline.
+func (s *Source) emit(line []byte) {
+	s.mux.line(s.prefix, line)
+
+	if s.on_line != nil {
+		s.on_line(string(line))
+	}
+}
+
+// Flush emits the last line if it is not terminated by a newline.
+func (s *Source) Flush() {
+	if len(s.partial) == 0 {
+		return
+	}
+
+	s.emit(s.partial)
+	s.partial = nil
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// TestSource tests that a Source only emits whole lines.
+func TestSource(t *testing.T) {
+	var buf bytes.Buffer
+
+	var lines []string
+
+	src := NewMux(&buf).Source("[a] ", func(line string) {
+		lines = append(lines, line)
+	})
+
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\r\n", "\n", "tail"} {
+		_, _ = src.Write([]byte(chunk))
+	}
+
+	if want := "[a] hello\n[a] world\n[a] \n"; buf.String() != want {
+		t.Errorf("before Flush, output = %q, want %q", buf.String(), want)
+	}
+
+	src.Flush()
+
+	if want := "[a] hello\n[a] world\n[a] \n[a] tail\n"; buf.String() != want {
+		t.Errorf("after Flush, output = %q, want %q", buf.String(), want)
+	}
+
+	if want := []string{"hello", "world", "", "tail"}; !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
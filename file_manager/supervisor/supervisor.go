@@ -0,0 +1,756 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/supervisor/internal"
+	mio "github.com/PlayerR9/mygo-lib/writer"
+)
+
+const (
+	// DefaultReadyTimeout is how long a child may take to be ready when
+	// Readiness.Timeout is zero.
+	DefaultReadyTimeout time.Duration = 30 * time.Second
+
+	// DefaultInitialBackoff is the first restart delay when Backoff.Initial is zero.
+	DefaultInitialBackoff time.Duration = 100 * time.Millisecond
+
+	// DefaultMaxBackoff is the longest restart delay when Backoff.Max is zero.
+	DefaultMaxBackoff time.Duration = 10 * time.Second
+
+	// probeInterval is the delay between two attempts of a TCP probe.
+	probeInterval time.Duration = 50 * time.Millisecond
+
+	// waitDelay is how long the output of a child is still read once it
+	// exited, in case a descendant that left its process group keeps it open.
+	waitDelay time.Duration = 5 * time.Second
+)
+
+// Policy is when a child is restarted once it exits.
+type Policy int
+
+const (
+	// Never never restarts the child.
+	Never Policy = iota
+
+	// OnFailure restarts the child when it exits with an error.
+	OnFailure
+
+	// Always restarts the child whatever the way it exits.
+	Always
+)
+
+// String implements fmt.Stringer.
+func (p Policy) String() string {
+	switch p {
+	case Never:
+		return "never"
+	case OnFailure:
+		return "on-failure"
+	case Always:
+		return "always"
+	default:
+		return "Policy(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// Backoff is the delay between the restarts of a child. The delay doubles
+// with each consecutive restart, up to Max, and goes back to Initial once the
+// child ran for longer than Max.
+type Backoff struct {
+	// Initial is the first delay. If zero, DefaultInitialBackoff is used.
+	Initial time.Duration
+
+	// Max is the longest delay. If zero, DefaultMaxBackoff is used.
+	Max time.Duration
+}
+
+// delay returns the delay before the given restart.
+//
+// Parameters:
+//   - attempt: The number of consecutive restarts so far.
+//
+// Returns:
+//   - time.Duration: The delay.
+func (b Backoff) delay(attempt int) time.Duration {
+	d := b.Initial
+
+	for range attempt {
+		d *= 2
+
+		if d >= b.Max {
+			return b.Max
+		}
+	}
+
+	return min(d, b.Max)
+}
+
+// Limits are the resource limits of a child, set both as soft and hard limits.
+// They are only supported on Unix platforms, where the child is started
+// through /bin/sh to set them before the program runs. A zero value leaves the
+// corresponding limit unchanged.
+type Limits struct {
+	// CPU is the CPU time the child may use, rounded up to the second.
+	CPU time.Duration
+
+	// Memory is the size, in bytes, of the address space of the child.
+	Memory uint64
+
+	// OpenFiles is the number of files the child may have open.
+	OpenFiles uint64
+}
+
+// isZero checks whether no limit is set.
+//
+// Returns:
+//   - bool: True if no limit is set, false otherwise.
+func (l Limits) isZero() bool {
+	return l.CPU == 0 && l.Memory == 0 && l.OpenFiles == 0
+}
+
+// Readiness is how to tell that a child is ready. If both Pattern and Address
+// are nil, a child is ready as soon as it starts; if both are set, the first
+// one to succeed wins.
+type Readiness struct {
+	// Pattern is matched against every line the child writes, on either
+	// output.
+	Pattern *regexp.Regexp
+
+	// Address is a TCP address, such as "localhost:8080", the child listens on.
+	Address string
+
+	// Timeout is how long the child may take to be ready. If zero,
+	// DefaultReadyTimeout is used.
+	Timeout time.Duration
+}
+
+// Spec is the specification of a child.
+type Spec struct {
+	// Name is the name of the child, which prefixes its output. Must be unique.
+	Name string
+
+	// Path is the program to run, as given to file_manager.NewCommand.
+	Path string
+
+	// Args are the arguments of the program.
+	Args []string
+
+	// Dir is the working directory of the child. If empty, the one of the
+	// supervisor is used.
+	Dir string
+
+	// Env is the environment of the child. If nil, the one of the supervisor
+	// is used.
+	Env []string
+
+	// Policy is when the child is restarted.
+	Policy Policy
+
+	// MaxRestarts is the number of restarts after which the child is given up
+	// on. If zero, the child is restarted forever.
+	MaxRestarts int
+
+	// Backoff is the delay between the restarts.
+	Backoff Backoff
+
+	// Limits are the resource limits of the child.
+	Limits Limits
+
+	// Ready is how to tell that the child is ready.
+	Ready Readiness
+}
+
+// State is the state of a child.
+type State int
+
+const (
+	// Starting is the state of a child that runs but is not ready yet.
+	Starting State = iota
+
+	// Ready is the state of a child that runs and is ready.
+	Ready
+
+	// Restarting is the state of a child waiting to be restarted.
+	Restarting
+
+	// Exited is the state of a child that exited and will not be restarted.
+	Exited
+
+	// Failed is the state of a child that failed and will not be restarted.
+	Failed
+
+	// Stopped is the state of a child stopped by the supervisor.
+	Stopped
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case Starting:
+		return "starting"
+	case Ready:
+		return "ready"
+	case Restarting:
+		return "restarting"
+	case Exited:
+		return "exited"
+	case Failed:
+		return "failed"
+	case Stopped:
+		return "stopped"
+	default:
+		return "State(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Status is the status of a child at a given time.
+type Status struct {
+	// Name is the name of the child.
+	Name string
+
+	// State is the state of the child.
+	State State
+
+	// PID is the PID of the child, or 0 if it does not run.
+	PID int
+
+	// Restarts is the number of times the child was restarted.
+	Restarts int
+
+	// LastErr is the error the child last exited with, if any.
+	LastErr error
+}
+
+// child is a supervised process.
+type child struct {
+	// spec is the specification of the child.
+	spec Spec
+
+	// ready is closed the first time the child is ready.
+	ready chan struct{}
+
+	// ready_once guards the closing of ready.
+	ready_once sync.Once
+
+	// done is closed once the child is not supervised anymore.
+	done chan struct{}
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// status is the status of the child.
+	status Status
+
+	// stopping is whether the supervisor is stopping.
+	stopping bool
+}
+
+// markReady records that the current run of the child is ready.
+func (c *child) markReady() {
+	c.mu.Lock()
+
+	if c.status.State == Starting {
+		c.status.State = Ready
+	}
+
+	c.mu.Unlock()
+
+	c.ready_once.Do(func() {
+		close(c.ready)
+	})
+}
+
+// onLine checks a line of the output of the child against its readiness
+// pattern.
+//
+// Parameters:
+//   - line: The line.
+func (c *child) onLine(line string) {
+	ok := c.spec.Ready.Pattern.MatchString(line)
+	if ok {
+		c.markReady()
+	}
+}
+
+// setState sets the state of the child.
+//
+// Parameters:
+//   - state: The new state.
+func (c *child) setState(state State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.State = state
+}
+
+// start starts the command unless the supervisor is stopping.
+//
+// Parameters:
+//   - cmd: The command to start.
+//
+// Returns:
+//   - bool: False if the supervisor is stopping.
+//   - error: An error if the command could not be started.
+func (c *child) start(cmd *exec.Cmd) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopping {
+		return false, nil
+	}
+
+	c.status.State = Starting
+
+	err := cmd.Start()
+	if err != nil {
+		return true, err
+	}
+
+	c.status.PID = cmd.Process.Pid
+
+	return true, nil
+}
+
+// probe dials the address of the child until it answers, the child exits or
+// the supervisor stops.
+//
+// Parameters:
+//   - exited: Closed once the current run of the child exits.
+//   - stop: Closed once the supervisor stops.
+func (c *child) probe(exited, stop <-chan struct{}) {
+	for {
+		conn, err := net.DialTimeout("tcp", c.spec.Ready.Address, time.Second)
+		if err == nil {
+			_ = conn.Close()
+
+			c.markReady()
+
+			return
+		}
+
+		select {
+		case <-exited:
+			return
+		case <-stop:
+			return
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+// restart tells whether the child must be restarted after it exited.
+//
+// Parameters:
+//   - err: The error it exited with.
+//
+// Returns:
+//   - bool: True if the child must be restarted, false otherwise.
+func (c *child) restart(err error) bool {
+	switch c.spec.Policy {
+	case Always:
+	case OnFailure:
+		if err == nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.spec.MaxRestarts == 0 || c.status.Restarts < c.spec.MaxRestarts
+}
+
+// Supervisor starts a set of children and restarts them according to their
+// policies. Each child runs in a process group of its own, where supported, so
+// that stopping it also stops its descendants. The output of every child is
+// prefixed with its name and written, one whole line at a time, to a single
+// writer.
+type Supervisor struct {
+	// mux multiplexes the output of the children.
+	mux *internal.Mux
+
+	// children are the supervised children.
+	children []*child
+
+	// stop is closed once the supervisor stops.
+	stop chan struct{}
+
+	// stop_once guards the closing of stop.
+	stop_once sync.Once
+
+	// wg tracks the goroutines of the children.
+	wg sync.WaitGroup
+
+	// mu protects started.
+	mu sync.Mutex
+
+	// started is whether Start was called.
+	started bool
+}
+
+// New creates a supervisor of the given children. Nothing is started until
+// Start is called.
+//
+// Parameters:
+//   - out: The writer the output of the children is written to. If nil, it is discarded.
+//   - specs: The specifications of the children.
+//
+// Returns:
+//   - *Supervisor: The new supervisor.
+//   - error: An error if a specification is invalid.
+//
+// Errors:
+//   - *errors.ErrBadParam: If a name is empty or repeated, or a path is empty.
+//   - errors.ErrUnsupported: If the operating system is not supported by
+//     file_manager.NewCommand (i.e. other than Windows and Linux).
+//   - *ErrChild: If resource limits are requested on a platform other than
+//     Unix, wrapping errors.ErrUnsupported.
+func New(out mio.Writer, specs ...Spec) (*Supervisor, error) {
+	// The children are started by file_manager.NewCommand, which would panic
+	// in their goroutines.
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		return nil, errors.ErrUnsupported
+	}
+
+	names := make(map[string]struct{}, len(specs))
+
+	s := &Supervisor{
+		mux:  internal.NewMux(out),
+		stop: make(chan struct{}),
+	}
+
+	for i, spec := range specs {
+		param := "specs[" + strconv.Itoa(i) + "]"
+
+		if spec.Name == "" {
+			return nil, gers.NewErrBadParam(param+".Name", "must not be empty")
+		} else if spec.Path == "" {
+			return nil, gers.NewErrBadParam(param+".Path", "must not be empty")
+		}
+
+		_, ok := names[spec.Name]
+		if ok {
+			return nil, gers.NewErrBadParam(param+".Name", "must be unique")
+		}
+
+		names[spec.Name] = struct{}{}
+
+		if !internal.Supported && !spec.Limits.isZero() {
+			return nil, NewErrChild(spec.Name, errors.ErrUnsupported)
+		}
+
+		if spec.Backoff.Initial <= 0 {
+			spec.Backoff.Initial = DefaultInitialBackoff
+		}
+
+		if spec.Backoff.Max <= 0 {
+			spec.Backoff.Max = DefaultMaxBackoff
+		}
+
+		if spec.Ready.Timeout <= 0 {
+			spec.Ready.Timeout = DefaultReadyTimeout
+		}
+
+		c := &child{
+			spec:  spec,
+			ready: make(chan struct{}),
+			done:  make(chan struct{}),
+			status: Status{
+				Name: spec.Name,
+			},
+		}
+
+		s.children = append(s.children, c)
+	}
+
+	return s, nil
+}
+
+// run supervises a child until it is given up on or the supervisor stops.
+//
+// Parameters:
+//   - c: The child to supervise.
+func (s *Supervisor) run(c *child) {
+	defer s.wg.Done()
+	defer close(c.done)
+
+	var attempt int
+
+	prefix := "[" + c.spec.Name + "] "
+
+	for {
+		limits := c.spec.Limits
+
+		path, args := internal.WithLimits(c.spec.Path, c.spec.Args, uint64((limits.CPU+time.Second-1)/time.Second), limits.Memory, limits.OpenFiles)
+
+		cmd := fm.NewCommand(path, args...)
+		cmd.Dir = c.spec.Dir
+		cmd.Env = c.spec.Env
+		cmd.WaitDelay = waitDelay
+
+		internal.Group(cmd)
+
+		var on_line func(string)
+
+		if c.spec.Ready.Pattern != nil {
+			on_line = c.onLine
+		}
+
+		stdout := s.mux.Source(prefix, on_line)
+		stderr := s.mux.Source(prefix, on_line)
+
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		started_at := time.Now()
+
+		ok, err := c.start(cmd)
+		if !ok {
+			c.setState(Stopped)
+			return
+		}
+
+		if err == nil {
+			exited := make(chan struct{})
+
+			switch {
+			case c.spec.Ready.Pattern == nil && c.spec.Ready.Address == "":
+				c.markReady()
+			case c.spec.Ready.Address != "":
+				go c.probe(exited, s.stop)
+			}
+
+			err = cmd.Wait()
+
+			close(exited)
+
+			stdout.Flush()
+			stderr.Flush()
+		}
+
+		c.mu.Lock()
+
+		c.status.PID = 0
+		c.status.LastErr = err
+		stopping := c.stopping
+
+		c.mu.Unlock()
+
+		if stopping {
+			c.setState(Stopped)
+			return
+		}
+
+		ok = c.restart(err)
+		if !ok {
+			if err == nil {
+				c.setState(Exited)
+			} else {
+				c.setState(Failed)
+			}
+
+			return
+		}
+
+		if time.Since(started_at) > c.spec.Backoff.Max {
+			attempt = 0
+		}
+
+		delay := c.spec.Backoff.delay(attempt)
+		attempt++
+
+		c.mu.Lock()
+
+		c.status.State = Restarting
+		c.status.Restarts++
+
+		c.mu.Unlock()
+
+		select {
+		case <-s.stop:
+			c.setState(Stopped)
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Start starts every child, then waits for all of them to be ready. Children
+// that fail to be ready are not stopped: call Stop to do so.
+//
+// Parameters:
+//   - ctx: The context that bounds the wait. Must not be nil.
+//
+// Returns:
+//   - error: An error if a child is not ready.
+//
+// Errors:
+//   - ErrStarted: If the supervisor was already started.
+//   - *ErrChild: If a child is not ready, wrapping ErrNotReady or ErrGaveUp.
+//   - any other error: The error of the context.
+func (s *Supervisor) Start(ctx context.Context) error {
+	s.mu.Lock()
+
+	if s.started {
+		s.mu.Unlock()
+		return ErrStarted
+	}
+
+	s.started = true
+
+	s.mu.Unlock()
+
+	for _, c := range s.children {
+		s.wg.Add(1)
+
+		go s.run(c)
+	}
+
+	for _, c := range s.children {
+		timer := time.NewTimer(c.spec.Ready.Timeout)
+
+		select {
+		case <-c.ready:
+			timer.Stop()
+		case <-c.done:
+			timer.Stop()
+
+			// The child may have been ready right before it exited.
+			select {
+			case <-c.ready:
+				continue
+			default:
+			}
+
+			return NewErrChild(c.spec.Name, ErrGaveUp)
+		case <-timer.C:
+			return NewErrChild(c.spec.Name, ErrNotReady)
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Status returns the status of every child, in the order they were given.
+//
+// Returns:
+//   - []Status: The status of the children.
+func (s *Supervisor) Status() []Status {
+	statuses := make([]Status, 0, len(s.children))
+
+	for _, c := range s.children {
+		c.mu.Lock()
+		statuses = append(statuses, c.status)
+		c.mu.Unlock()
+	}
+
+	return statuses
+}
+
+// Wait waits for every child to be given up on or stopped.
+//
+// Returns:
+//   - error: The errors of the children that failed, joined.
+//
+// Errors:
+//   - *ErrChild: For each child that failed, wrapping the error it exited with.
+func (s *Supervisor) Wait() error {
+	s.wg.Wait()
+
+	var errs []error
+
+	for _, status := range s.Status() {
+		if status.State == Failed {
+			errs = append(errs, NewErrChild(status.Name, status.LastErr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// signal sends a signal to every running child.
+//
+// Parameters:
+//   - send: The function sending the signal.
+//
+// Returns:
+//   - error: The errors met, joined.
+func (s *Supervisor) signal(send func(pid int) error) error {
+	var errs []error
+
+	for _, c := range s.children {
+		c.mu.Lock()
+
+		c.stopping = true
+
+		if c.status.PID != 0 {
+			err := send(c.status.PID)
+			if err != nil {
+				errs = append(errs, NewErrChild(c.spec.Name, err))
+			}
+		}
+
+		c.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Stop stops every child: their process groups are asked to terminate, then
+// killed if they are still running after the grace period. No child is
+// restarted afterwards. Calling Stop more than once has no further effect
+// than waiting for the children.
+//
+// Parameters:
+//   - grace: How long the children may take to terminate.
+//
+// Returns:
+//   - error: An error if a child could not be signaled.
+//
+// Errors:
+//   - *ErrChild: For each child that could not be signaled.
+func (s *Supervisor) Stop(grace time.Duration) error {
+	s.stop_once.Do(func() {
+		close(s.stop)
+	})
+
+	err := s.signal(internal.Terminate)
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return err
+	case <-timer.C:
+	}
+
+	err = errors.Join(err, s.signal(internal.Kill))
+
+	<-done
+
+	return err
+}
@@ -0,0 +1,217 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements writer.Writer.
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// String returns what was written so far.
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// sh returns the spec of a child running the given shell script.
+func sh(name, script string) Spec {
+	return Spec{
+		Name: name,
+		Path: "/bin/sh",
+		Args: []string{"-c", script},
+	}
+}
+
+// TestRestartBackoff tests that a failing child is restarted with a growing
+// delay, then given up on.
+func TestRestartBackoff(t *testing.T) {
+	var out syncBuffer
+
+	spec := sh("flaky", "echo run; exit 3")
+	spec.Policy = OnFailure
+	spec.MaxRestarts = 2
+	spec.Backoff = Backoff{Initial: 50 * time.Millisecond, Max: time.Second}
+
+	s, err := New(&out, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	err = s.Start(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.Wait()
+
+	elapsed := time.Since(start)
+
+	var child_err *ErrChild
+	var exit_err *exec.ExitError
+
+	if !errors.As(err, &child_err) || !errors.As(err, &exit_err) || exit_err.ExitCode() != 3 {
+		t.Fatalf("expected the exit status 3 of the child, got %v", err)
+	}
+
+	status := s.Status()[0]
+
+	if status.State != Failed || status.Restarts != 2 {
+		t.Errorf("expected the child to fail after 2 restarts, got %+v", status)
+	}
+
+	// 50ms, then 100ms.
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("expected the restarts to be delayed, took %v", elapsed)
+	}
+
+	if got := strings.Count(out.String(), "[flaky] run\n"); got != 3 {
+		t.Errorf("expected 3 runs, got %d in %q", got, out.String())
+	}
+}
+
+// TestAlwaysRestart tests that a child that succeeds is restarted by Always
+// but not by OnFailure.
+func TestAlwaysRestart(t *testing.T) {
+	always := sh("always", "exit 0")
+	always.Policy = Always
+	always.MaxRestarts = 1
+	always.Backoff.Initial = time.Millisecond
+
+	on_failure := sh("on_failure", "exit 0")
+	on_failure.Policy = OnFailure
+
+	s, err := New(nil, always, on_failure)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Start(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, restarts := range []int{1, 0} {
+		status := s.Status()[i]
+
+		if status.State != Exited || status.Restarts != restarts {
+			t.Errorf("expected %s to exit after %d restarts, got %+v", status.Name, restarts, status)
+		}
+	}
+}
+
+// TestReadiness tests that Start waits for the readiness pattern, and that
+// Stop terminates a ready child.
+func TestReadiness(t *testing.T) {
+	spec := sh("server", "sleep 0.1; echo listening; exec sleep 30")
+	spec.Ready = Readiness{Pattern: regexp.MustCompile(`^listening$`), Timeout: 5 * time.Second}
+
+	s, err := New(nil, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Start(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status := s.Status()[0]
+	if status.State != Ready || status.PID == 0 {
+		t.Errorf("expected a ready child, got %+v", status)
+	}
+
+	err = s.Stop(time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.Wait()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if status := s.Status()[0]; status.State != Stopped {
+		t.Errorf("expected a stopped child, got %+v", status)
+	}
+}
+
+// TestNotReady tests the failures of the readiness check.
+func TestNotReady(t *testing.T) {
+	tests := map[string]struct {
+		script string
+		want   error
+	}{
+		"timeout": {"exec sleep 30", ErrNotReady},
+		"gave up": {"echo starting; exit 1", ErrGaveUp},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := sh(name, tt.script)
+			spec.Ready = Readiness{Pattern: regexp.MustCompile(`^ready$`), Timeout: 200 * time.Millisecond}
+
+			s, err := New(nil, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.Start(context.Background())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+
+			err = s.Stop(time.Second)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestStartTwice tests that a supervisor is only started once.
+func TestStartTwice(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Start(context.Background())
+	if err != ErrStarted {
+		t.Errorf("expected ErrStarted, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ErrCycle occurs when tasks depend on each other in a cycle.
+type ErrCycle struct {
+	// Path are the names of the tasks of the cycle, each one depending on the
+	// next one and the last one depending on the first one.
+	Path []string
+}
+
+// Error implements error.
+func (e ErrCycle) Error() string {
+	quoted := make([]string, 0, len(e.Path)+1)
+
+	for _, name := range e.Path {
+		quoted = append(quoted, strconv.Quote(name))
+	}
+
+	if len(e.Path) > 0 {
+		quoted = append(quoted, strconv.Quote(e.Path[0]))
+	}
+
+	return "dependency cycle: " + strings.Join(quoted, " -> ")
+}
+
+// NewErrCycle creates a new ErrCycle error.
+//
+// Parameters:
+//   - path: The names of the tasks of the cycle.
+//
+// Returns:
+//   - error: An instance of ErrCycle. Never returns nil.
+//
+// Format:
+//
+//	"dependency cycle: <path>"
+//
+// Where:
+//   - <path> are the quoted names of the tasks, separated by " -> ", ending with the first one again.
+func NewErrCycle(path []string) error {
+	e := &ErrCycle{
+		Path: path,
+	}
+
+	return e
+}
+
+// ErrUnknownTask occurs when a task that does not exist is referred to.
+type ErrUnknownTask struct {
+	// Name is the name of the task.
+	Name string
+}
+
+// Error implements error.
+func (e ErrUnknownTask) Error() string {
+	return "unknown task " + strconv.Quote(e.Name)
+}
+
+// NewErrUnknownTask creates a new ErrUnknownTask error.
+//
+// Parameters:
+//   - name: The name of the task.
+//
+// Returns:
+//   - error: An instance of ErrUnknownTask. Never returns nil.
+//
+// Format:
+//
+//	"unknown task <name>"
+//
+// Where:
+//   - <name> is the quoted name of the task.
+func NewErrUnknownTask(name string) error {
+	e := &ErrUnknownTask{
+		Name: name,
+	}
+
+	return e
+}
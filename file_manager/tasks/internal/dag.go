@@ -0,0 +1,99 @@
+package internal
+
+// Sort orders the nodes of a graph so that every node comes after its
+// dependencies. Among the nodes that are ready at the same time, the original
+// order is kept.
+//
+// Parameters:
+//   - deps: The dependencies of every node, by index.
+//
+// Returns:
+//   - []int: The nodes in dependency order.
+//   - []int: A cycle, as a path whose last node depends on the first one, if
+//     the graph is not acyclic. The order is nil then.
+func Sort(deps [][]int) ([]int, []int) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(deps))
+	order := make([]int, 0, len(deps))
+
+	var stack []int
+	var cycle []int
+
+	var visit func(n int) bool
+
+	visit = func(n int) bool {
+		switch state[n] {
+		case visited:
+			return true
+		case visiting:
+			for i, m := range stack {
+				if m == n {
+					cycle = append([]int(nil), stack[i:]...)
+					break
+				}
+			}
+
+			return false
+		}
+
+		state[n] = visiting
+		stack = append(stack, n)
+
+		for _, d := range deps[n] {
+			ok := visit(d)
+			if !ok {
+				return false
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[n] = visited
+		order = append(order, n)
+
+		return true
+	}
+
+	for n := range deps {
+		ok := visit(n)
+		if !ok {
+			return nil, cycle
+		}
+	}
+
+	return order, nil
+}
+
+// Closure returns the given nodes along with everything they depend on,
+// directly or not.
+//
+// Parameters:
+//   - deps: The dependencies of every node, by index.
+//   - roots: The nodes to start from.
+//
+// Returns:
+//   - []bool: Whether each node is in the closure.
+func Closure(deps [][]int, roots []int) []bool {
+	in := make([]bool, len(deps))
+
+	stack := append([]int(nil), roots...)
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if in[n] {
+			continue
+		}
+
+		in[n] = true
+
+		stack = append(stack, deps[n]...)
+	}
+
+	return in
+}
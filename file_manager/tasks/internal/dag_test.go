@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSort tests the Sort function.
+func TestSort(t *testing.T) {
+	deps := [][]int{
+		0: {1, 2},
+		1: {3},
+		2: {3},
+		3: nil,
+	}
+
+	order, cycle := Sort(deps)
+	if cycle != nil {
+		t.Fatalf("Sort() found cycle %v", cycle)
+	}
+
+	if want := []int{3, 1, 2, 0}; !slices.Equal(order, want) {
+		t.Errorf("Sort() = %v, want %v", order, want)
+	}
+
+	deps[3] = []int{0}
+
+	order, cycle = Sort(deps)
+	if order != nil {
+		t.Fatalf("Sort() = %v, want a cycle", order)
+	}
+
+	if want := []int{0, 1, 3}; !slices.Equal(cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycle, want)
+	}
+}
+
+// TestClosure tests the Closure function.
+func TestClosure(t *testing.T) {
+	deps := [][]int{
+		0: {1},
+		1: nil,
+		2: {1},
+	}
+
+	got := Closure(deps, []int{0})
+
+	if want := []bool{true, true, false}; !slices.Equal(got, want) {
+		t.Errorf("Closure() = %v, want %v", got, want)
+	}
+}
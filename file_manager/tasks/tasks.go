@@ -0,0 +1,868 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/tasks/internal"
+	mio "github.com/PlayerR9/mygo-lib/writer"
+)
+
+// Task is a unit of work of a graph.
+type Task struct {
+	// Name is the name of the task. Must be unique within a graph.
+	Name string
+
+	// Deps are the names of the tasks that must succeed before this one runs.
+	Deps []string
+
+	// Inputs are the files the task reads. Patterns of filepath.Match are
+	// expanded; plain paths must exist when the task is checked.
+	Inputs []string
+
+	// Outputs are the files the task writes. A task without outputs is always
+	// run. The outputs must exist once the task succeeds.
+	Outputs []string
+
+	// Command is the program to run, followed by its arguments, as given to
+	// file_manager.NewCommand. It is ignored if Func is set.
+	Command []string
+
+	// Dir is the working directory of Command. If empty, the one of the
+	// process is used. Inputs and outputs are not relative to it.
+	Dir string
+
+	// Func is run instead of Command, if set. Its output goes to out.
+	Func func(ctx context.Context, out io.Writer) error
+}
+
+// Graph is a set of tasks and of the dependencies between them.
+type Graph struct {
+	// tasks are the tasks, in dependency order.
+	tasks []Task
+
+	// deps are the dependencies of every task, by index.
+	deps [][]int
+
+	// index maps the name of a task to its index.
+	index map[string]int
+}
+
+// New creates a graph of the given tasks.
+//
+// Parameters:
+//   - tasks: The tasks of the graph.
+//
+// Returns:
+//   - *Graph: The new graph.
+//   - error: An error if the tasks are invalid.
+//
+// Errors:
+//   - *errors.ErrBadParam: If a name is empty or repeated, or a task has no way to run.
+//   - *ErrUnknownTask: If a task depends on a task that does not exist.
+//   - *ErrCycle: If tasks depend on each other in a cycle.
+func New(tasks ...Task) (*Graph, error) {
+	index := make(map[string]int, len(tasks))
+
+	for i, task := range tasks {
+		param := "tasks[" + strconv.Itoa(i) + "]"
+
+		if task.Name == "" {
+			return nil, gers.NewErrBadParam(param+".Name", "must not be empty")
+		} else if task.Func == nil && len(task.Command) == 0 {
+			return nil, gers.NewErrBadParam(param, "must have a Command or a Func")
+		}
+
+		_, ok := index[task.Name]
+		if ok {
+			return nil, gers.NewErrBadParam(param+".Name", "must be unique")
+		}
+
+		index[task.Name] = i
+	}
+
+	deps := make([][]int, len(tasks))
+
+	for i, task := range tasks {
+		for _, dep := range task.Deps {
+			j, ok := index[dep]
+			if !ok {
+				return nil, NewErrUnknownTask(dep)
+			}
+
+			deps[i] = append(deps[i], j)
+		}
+	}
+
+	order, cycle := internal.Sort(deps)
+	if cycle != nil {
+		names := make([]string, 0, len(cycle))
+
+		for _, n := range cycle {
+			names = append(names, tasks[n].Name)
+		}
+
+		return nil, NewErrCycle(names)
+	}
+
+	g := &Graph{
+		tasks: make([]Task, 0, len(tasks)),
+		deps:  make([][]int, len(tasks)),
+		index: make(map[string]int, len(tasks)),
+	}
+
+	position := make([]int, len(tasks))
+
+	for pos, n := range order {
+		position[n] = pos
+	}
+
+	for pos, n := range order {
+		g.tasks = append(g.tasks, tasks[n])
+		g.index[tasks[n].Name] = pos
+
+		for _, d := range deps[n] {
+			g.deps[pos] = append(g.deps[pos], position[d])
+		}
+	}
+
+	return g, nil
+}
+
+// Check is how a task is found to be up to date.
+type Check int
+
+const (
+	// ByTime finds a task up to date when its oldest output is not older than
+	// its newest input, as make does.
+	ByTime Check = iota
+
+	// ByHash finds a task up to date when its outputs exist and a stamp of its
+	// command and of the content of its inputs matches the one written by its
+	// last successful run.
+	ByHash
+)
+
+// String implements fmt.Stringer.
+func (c Check) String() string {
+	switch c {
+	case ByTime:
+		return "by time"
+	case ByHash:
+		return "by hash"
+	default:
+		return "Check(" + strconv.Itoa(int(c)) + ")"
+	}
+}
+
+// DefaultStampDir is the directory of the stamps when Options.StampDir is empty.
+const DefaultStampDir string = ".stamps"
+
+// Options are the options of a run.
+type Options struct {
+	// Parallel is the number of tasks run concurrently. If zero, the number of
+	// CPUs is used.
+	Parallel int
+
+	// Check is how tasks are found to be up to date.
+	Check Check
+
+	// StampDir is the directory the stamps of ByHash are kept in. If empty,
+	// DefaultStampDir is used.
+	StampDir string
+
+	// Force runs every task, even the up-to-date ones.
+	Force bool
+
+	// Output receives the output of the tasks, each task's output written
+	// at once when it ends. If nil, it is discarded.
+	Output mio.Writer
+}
+
+// Status is the outcome of a task.
+type Status int
+
+const (
+	// Succeeded is the status of a task that ran successfully.
+	Succeeded Status = iota
+
+	// UpToDate is the status of a task that did not need to run.
+	UpToDate
+
+	// Failed is the status of a task that ran and failed.
+	Failed
+
+	// Blocked is the status of a task that did not run because a dependency
+	// did not succeed.
+	Blocked
+
+	// Canceled is the status of a task that did not run, or was interrupted,
+	// because the context ended.
+	Canceled
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "ok"
+	case UpToDate:
+		return "up-to-date"
+	case Failed:
+		return "FAILED"
+	case Blocked:
+		return "blocked"
+	case Canceled:
+		return "canceled"
+	default:
+		return "Status(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// ok checks whether a dependent task may run after a task with this status.
+//
+// Returns:
+//   - bool: True if the status is Succeeded or UpToDate, false otherwise.
+func (s Status) ok() bool {
+	return s == Succeeded || s == UpToDate
+}
+
+// Result is the outcome of a task in a run.
+type Result struct {
+	// Name is the name of the task.
+	Name string
+
+	// Status is the outcome of the task.
+	Status Status
+
+	// Err is why the task failed, was blocked or was canceled.
+	Err error
+
+	// Duration is how long the task ran, zero if it did not.
+	Duration time.Duration
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	// Results are the outcomes of the tasks of the run, in dependency order.
+	Results []Result
+}
+
+// OK checks whether every task of the run succeeded or was up to date.
+//
+// Returns:
+//   - bool: True if no task failed, was blocked or was canceled.
+func (r Report) OK() bool {
+	for _, res := range r.Results {
+		if !res.Status.ok() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Summary writes one line per task, followed by the count of each status.
+//
+// Parameters:
+//   - w: The writer to write to. Must not be nil.
+//
+// Returns:
+//   - error: An error if the writer fails.
+//
+// Errors:
+//   - writer.ErrNoWriter: If w is nil.
+//   - any other error: If the writer fails.
+//
+// Format:
+//
+//	"<status>\t<name>\t<duration>[\t<error>]\n" for each task, then
+//	"<n> ok, <n> up-to-date, <n> failed, <n> blocked, <n> canceled\n"
+func (r Report) Summary(w mio.Writer) error {
+	if w == nil {
+		return mio.ErrNoWriter
+	}
+
+	var builder strings.Builder
+
+	var counts [Canceled + 1]int
+
+	for _, res := range r.Results {
+		counts[res.Status]++
+
+		_, _ = builder.WriteString(res.Status.String())
+		_ = builder.WriteByte('\t')
+		_, _ = builder.WriteString(res.Name)
+		_ = builder.WriteByte('\t')
+		_, _ = builder.WriteString(res.Duration.Round(time.Millisecond).String())
+
+		if res.Err != nil {
+			_ = builder.WriteByte('\t')
+			_, _ = builder.WriteString(res.Err.Error())
+		}
+
+		_ = builder.WriteByte('\n')
+	}
+
+	parts := make([]string, 0, len(counts))
+
+	for status, count := range counts {
+		parts = append(parts, strconv.Itoa(count)+" "+strings.ToLower(Status(status).String()))
+	}
+
+	_, _ = builder.WriteString(strings.Join(parts, ", "))
+	_ = builder.WriteByte('\n')
+
+	err := mio.WriteString(w, builder.String())
+	return err
+}
+
+// runner holds the state of a run.
+type runner struct {
+	// g is the graph being run.
+	g *Graph
+
+	// opts are the options of the run.
+	opts Options
+
+	// out_mu serializes the writes to the output.
+	out_mu sync.Mutex
+}
+
+// expand expands the inputs of a task.
+//
+// Parameters:
+//   - task: The task.
+//
+// Returns:
+//   - []string: The input files, sorted and without duplicates.
+//   - error: An error if a plain input does not exist or a pattern is malformed.
+func expand(task Task) ([]string, error) {
+	meta := "*?["
+	if runtime.GOOS != "windows" {
+		meta += `\`
+	}
+
+	var files []string
+
+	for _, pattern := range task.Inputs {
+		if !strings.ContainsAny(pattern, meta) {
+			ok, err := fm.Exists(nil, pattern)
+			if err != nil {
+				return nil, err
+			} else if !ok {
+				return nil, &fs.PathError{Op: "input", Path: pattern, Err: fs.ErrNotExist}
+			}
+
+			files = append(files, pattern)
+
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files), nil
+}
+
+// outputsExist checks whether every output of a task exists.
+//
+// Parameters:
+//   - task: The task.
+//
+// Returns:
+//   - bool: True if every output exists, false otherwise.
+//   - error: An error if an output could not be checked.
+func outputsExist(task Task) (bool, error) {
+	for _, output := range task.Outputs {
+		ok, err := fm.Exists(nil, output)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// newer checks whether the oldest output of a task is not older than its
+// newest input. The outputs must exist.
+//
+// Parameters:
+//   - task: The task.
+//   - inputs: The expanded inputs of the task.
+//
+// Returns:
+//   - bool: True if the outputs are up to date, false otherwise.
+//   - error: An error if a file could not be inspected.
+func newer(task Task, inputs []string) (bool, error) {
+	var newest time.Time
+
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return false, err
+		}
+
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+
+	for _, output := range task.Outputs {
+		info, err := os.Stat(output)
+		if err != nil {
+			return false, err
+		}
+
+		if info.ModTime().Before(newest) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// stamp computes the stamp of a task: a digest of its command and of the name
+// and content of each of its inputs.
+//
+// Parameters:
+//   - task: The task.
+//   - inputs: The expanded inputs of the task.
+//
+// Returns:
+//   - string: The hex-encoded stamp.
+//   - error: An error if an input could not be read.
+func stamp(task Task, inputs []string) (string, error) {
+	h := sha256.New()
+
+	for _, arg := range task.Command {
+		_, _ = io.WriteString(h, arg)
+		_, _ = h.Write([]byte{0})
+	}
+
+	_, _ = h.Write([]byte{1})
+
+	for _, input := range inputs {
+		f, err := os.Open(input)
+		if err != nil {
+			return "", err
+		}
+
+		content := sha256.New()
+
+		_, err = io.Copy(content, f)
+		_ = f.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		_, _ = io.WriteString(h, input)
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(content.Sum(nil))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stampPath returns the path of the stamp of a task.
+//
+// Parameters:
+//   - task: The task.
+//
+// Returns:
+//   - string: The path of the stamp.
+func (r *runner) stampPath(task Task) string {
+	return filepath.Join(r.opts.StampDir, url.PathEscape(task.Name)+".stamp")
+}
+
+// upToDate checks whether a task needs to run.
+//
+// Parameters:
+//   - task: The task.
+//
+// Returns:
+//   - bool: True if the task is up to date, false otherwise.
+//   - string: The stamp of the task, computed when checking ByHash.
+//   - error: An error if the inputs or outputs could not be checked.
+func (r *runner) upToDate(task Task) (bool, string, error) {
+	inputs, err := expand(task)
+	if err != nil {
+		return false, "", err
+	}
+
+	var sum string
+
+	if r.opts.Check == ByHash {
+		sum, err = stamp(task, inputs)
+		if err != nil {
+			return false, "", err
+		}
+	}
+
+	if r.opts.Force || len(task.Outputs) == 0 {
+		return false, sum, nil
+	}
+
+	ok, err := outputsExist(task)
+	if err != nil || !ok {
+		return false, sum, err
+	}
+
+	if r.opts.Check == ByTime {
+		ok, err := newer(task, inputs)
+		return ok, sum, err
+	}
+
+	prev, err := os.ReadFile(r.stampPath(task))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, sum, nil
+	} else if err != nil {
+		return false, sum, err
+	}
+
+	return strings.TrimSpace(string(prev)) == sum, sum, nil
+}
+
+// invoke runs the command or the function of a task until it ends or the
+// context ends.
+//
+// Parameters:
+//   - ctx: The context of the run.
+//   - task: The task.
+//   - out: The writer the output goes to.
+//
+// Returns:
+//   - error: An error if the task failed.
+func invoke(ctx context.Context, task Task, out io.Writer) error {
+	if task.Func != nil {
+		err := task.Func(ctx, out)
+		return err
+	}
+
+	cmd := fm.NewCommand(task.Command[0], task.Command[1:]...)
+	cmd.Dir = task.Dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	defer close(exited)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = cmd.Process.Kill()
+		case <-exited:
+		}
+	}()
+
+	err = cmd.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// exec checks a task and runs it if needed.
+//
+// Parameters:
+//   - ctx: The context of the run.
+//   - task: The task.
+//
+// Returns:
+//   - Result: The outcome of the task.
+func (r *runner) exec(ctx context.Context, task Task) Result {
+	res := Result{
+		Name: task.Name,
+	}
+
+	if ctx.Err() != nil {
+		res.Status = Canceled
+		res.Err = ctx.Err()
+
+		return res
+	}
+
+	ok, sum, err := r.upToDate(task)
+	if err != nil {
+		res.Status = Failed
+		res.Err = err
+
+		return res
+	} else if ok {
+		res.Status = UpToDate
+		return res
+	}
+
+	var buf bytes.Buffer
+
+	start := time.Now()
+
+	err = invoke(ctx, task, &buf)
+
+	res.Duration = time.Since(start)
+
+	if r.opts.Output != nil && buf.Len() > 0 {
+		r.out_mu.Lock()
+		_ = mio.WriteBytes(r.opts.Output, buf.Bytes())
+		r.out_mu.Unlock()
+	}
+
+	if err == nil {
+		err = r.finish(task, sum)
+	}
+
+	switch {
+	case err == nil:
+		res.Status = Succeeded
+	case ctx.Err() != nil:
+		res.Status = Canceled
+		res.Err = err
+	default:
+		res.Status = Failed
+		res.Err = err
+	}
+
+	return res
+}
+
+// finish checks the outputs of a task that ran successfully and records its
+// stamp.
+//
+// Parameters:
+//   - task: The task.
+//   - sum: The stamp of the task, if checking ByHash.
+//
+// Returns:
+//   - error: An error if an output is missing or the stamp could not be written.
+func (r *runner) finish(task Task, sum string) error {
+	for _, output := range task.Outputs {
+		ok, err := fm.Exists(nil, output)
+		if err != nil {
+			return err
+		} else if !ok {
+			return &fs.PathError{Op: "output", Path: output, Err: fs.ErrNotExist}
+		}
+	}
+
+	if r.opts.Check != ByHash || len(task.Outputs) == 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(r.opts.StampDir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(r.stampPath(task), []byte(sum+"\n"), 0o644)
+	return err
+}
+
+// Run runs the given targets, and everything they depend on, in dependency
+// order, with up to Options.Parallel tasks at once. Tasks that are up to date
+// are skipped. A task that fails blocks the tasks that depend on it, but not
+// the other branches of the graph.
+//
+// Parameters:
+//   - ctx: The context of the run. When it ends, the running tasks are killed
+//     and the remaining ones are canceled. Must not be nil.
+//   - targets: The names of the tasks to run. If empty, every task is run.
+//   - opts: The options of the run. If nil, the defaults are used.
+//
+// Returns:
+//   - *Report: The outcome of every task of the run.
+//   - error: An error if a target does not exist.
+//
+// Errors:
+//   - *ErrUnknownTask: If a target does not exist.
+//
+// Panics:
+//   - If the operating system is not supported. (i.e. Windows and Linux)
+func (g *Graph) Run(ctx context.Context, targets []string, opts *Options) (*Report, error) {
+	var roots []int
+
+	for _, name := range targets {
+		n, ok := g.index[name]
+		if !ok {
+			return nil, NewErrUnknownTask(name)
+		}
+
+		roots = append(roots, n)
+	}
+
+	var in []bool
+
+	if len(roots) == 0 {
+		in = make([]bool, len(g.tasks))
+
+		for i := range in {
+			in[i] = true
+		}
+	} else {
+		in = internal.Closure(g.deps, roots)
+	}
+
+	r := &runner{
+		g: g,
+	}
+
+	if opts != nil {
+		r.opts = *opts
+	}
+
+	if r.opts.Parallel <= 0 {
+		r.opts.Parallel = runtime.NumCPU()
+	}
+
+	if r.opts.StampDir == "" {
+		r.opts.StampDir = DefaultStampDir
+	}
+
+	results := r.schedule(ctx, in)
+
+	report := new(Report)
+
+	for n, res := range results {
+		if in[n] {
+			report.Results = append(report.Results, res)
+		}
+	}
+
+	return report, nil
+}
+
+// schedule runs the selected tasks.
+//
+// Parameters:
+//   - ctx: The context of the run.
+//   - in: Whether each task is part of the run.
+//
+// Returns:
+//   - []Result: The outcome of every task, by index.
+func (r *runner) schedule(ctx context.Context, in []bool) []Result {
+	n := len(r.g.tasks)
+
+	results := make([]Result, n)
+	pending := make([]int, n)
+	dependents := make([][]int, n)
+
+	var ready []int
+
+	for i := range n {
+		if !in[i] {
+			continue
+		}
+
+		pending[i] = len(r.g.deps[i])
+
+		for _, d := range r.g.deps[i] {
+			dependents[d] = append(dependents[d], i)
+		}
+
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type done struct {
+		idx int
+		res Result
+	}
+
+	finished := make(chan done)
+
+	var running int
+
+	// complete records the outcome of a task and releases its dependents,
+	// blocking those that cannot run.
+	var complete func(i int, res Result)
+
+	complete = func(i int, res Result) {
+		results[i] = res
+
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] > 0 {
+				continue
+			}
+
+			blocked := -1
+
+			for _, d := range r.g.deps[j] {
+				if !results[d].Status.ok() {
+					blocked = d
+					break
+				}
+			}
+
+			if blocked < 0 {
+				ready = append(ready, j)
+				continue
+			}
+
+			res := Result{
+				Name:   r.g.tasks[j].Name,
+				Status: Blocked,
+				Err:    errors.New("dependency " + strconv.Quote(results[blocked].Name) + " did not succeed"),
+			}
+
+			// Cancellation is not the fault of the dependency.
+			if results[blocked].Status == Canceled {
+				res.Status = Canceled
+				res.Err = results[blocked].Err
+			}
+
+			complete(j, res)
+		}
+	}
+
+	for len(ready) > 0 || running > 0 {
+		for len(ready) > 0 && running < r.opts.Parallel {
+			i := ready[0]
+			ready = ready[1:]
+
+			running++
+
+			go func() {
+				finished <- done{idx: i, res: r.exec(ctx, r.g.tasks[i])}
+			}()
+		}
+
+		d := <-finished
+		running--
+
+		complete(d.idx, d.res)
+	}
+
+	return results
+}
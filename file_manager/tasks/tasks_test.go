@@ -0,0 +1,305 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// succeed is the function of a task that succeeds.
+func succeed(ctx context.Context, out io.Writer) error {
+	return nil
+}
+
+// statuses returns the status of every task of a report, by name.
+func statuses(r *Report) map[string]Status {
+	m := make(map[string]Status, len(r.Results))
+
+	for _, res := range r.Results {
+		m[res.Name] = res.Status
+	}
+
+	return m
+}
+
+// TestRunFailure tests that a failure only blocks the tasks that depend on it.
+func TestRunFailure(t *testing.T) {
+	failure := errors.New("failure")
+
+	g, err := New(
+		Task{Name: "a", Func: func(ctx context.Context, out io.Writer) error { return failure }},
+		Task{Name: "b", Deps: []string{"a"}, Func: succeed},
+		Task{Name: "c", Deps: []string{"b", "d"}, Func: succeed},
+		Task{Name: "d", Func: succeed},
+		Task{Name: "e", Deps: []string{"d"}, Func: succeed},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := g.Run(context.Background(), nil, &Options{Parallel: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Status{"a": Failed, "b": Blocked, "c": Blocked, "d": Succeeded, "e": Succeeded}
+
+	got := statuses(r)
+
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: expected %v, got %v", name, status, got[name])
+		}
+	}
+
+	if r.OK() {
+		t.Errorf("expected the report not to be OK")
+	}
+
+	for _, res := range r.Results {
+		if res.Name == "a" && !errors.Is(res.Err, failure) {
+			t.Errorf("expected the error of a, got %v", res.Err)
+		}
+	}
+}
+
+// TestRunTargets tests that only the targets and their dependencies run.
+func TestRunTargets(t *testing.T) {
+	g, err := New(
+		Task{Name: "a", Func: succeed},
+		Task{Name: "b", Deps: []string{"a"}, Func: succeed},
+		Task{Name: "c", Func: succeed},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := g.Run(context.Background(), []string{"b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Results) != 2 || r.Results[0].Name != "a" || r.Results[1].Name != "b" {
+		t.Errorf("expected a then b, got %+v", r.Results)
+	}
+
+	var unknown *ErrUnknownTask
+
+	_, err = g.Run(context.Background(), []string{"missing"}, nil)
+	if !errors.As(err, &unknown) {
+		t.Errorf("expected an *ErrUnknownTask, got %v", err)
+	}
+}
+
+// TestRunParallel tests that no more than Options.Parallel tasks run at once.
+func TestRunParallel(t *testing.T) {
+	for _, parallel := range []int{1, 3} {
+		var active, peak atomic.Int32
+
+		work := func(ctx context.Context, out io.Writer) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+
+			return nil
+		}
+
+		var tasks []Task
+
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			tasks = append(tasks, Task{Name: name, Func: work})
+		}
+
+		g, err := New(tasks...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := g.Run(context.Background(), nil, &Options{Parallel: parallel})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !r.OK() {
+			t.Errorf("expected every task to succeed, got %+v", r.Results)
+		}
+
+		if got := int(peak.Load()); got != parallel {
+			t.Errorf("expected %d tasks at once, got %d", parallel, got)
+		}
+	}
+}
+
+// buildTask returns a task copying in to out, along with its number of runs.
+func buildTask(in, out string) (Task, *int) {
+	var mu sync.Mutex
+	var runs int
+
+	task := Task{
+		Name:    "build",
+		Inputs:  []string{in},
+		Outputs: []string{out},
+		Command: []string{"copy"},
+		Func: func(ctx context.Context, w io.Writer) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+
+			data, err := os.ReadFile(in)
+			if err != nil {
+				return err
+			}
+
+			return os.WriteFile(out, data, 0o644)
+		},
+	}
+
+	return task, &runs
+}
+
+// runOnce runs a graph and returns the status of its single task.
+func runOnce(t *testing.T, g *Graph, opts *Options) Status {
+	t.Helper()
+
+	r, err := g.Run(context.Background(), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Results[0].Err != nil {
+		t.Fatalf("unexpected error: %v", r.Results[0].Err)
+	}
+
+	return r.Results[0].Status
+}
+
+// setTime sets the modification time of a file.
+func setTime(t *testing.T, name string, mtime time.Time) {
+	t.Helper()
+
+	err := os.Chtimes(name, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunByTime tests that a task is skipped while its outputs are not older
+// than its inputs.
+func TestRunByTime(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+
+	err := os.WriteFile(in, []byte("v1"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, runs := buildTask(in, out)
+
+	g, err := New(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Options{Check: ByTime}
+	now := time.Now()
+
+	steps := []struct {
+		name string
+		prep func()
+		opts *Options
+		want Status
+		runs int
+	}{
+		{"missing output", func() {}, opts, Succeeded, 1},
+		{"up to date", func() { setTime(t, in, now.Add(-time.Hour)) }, opts, UpToDate, 1},
+		{"newer input", func() { setTime(t, in, now.Add(time.Hour)) }, opts, Succeeded, 2},
+		{"force", func() { setTime(t, in, now.Add(-time.Hour)) }, &Options{Check: ByTime, Force: true}, Succeeded, 3},
+	}
+
+	for _, step := range steps {
+		step.prep()
+
+		if got := runOnce(t, g, step.opts); got != step.want {
+			t.Errorf("%s: expected %v, got %v", step.name, step.want, got)
+		}
+
+		if *runs != step.runs {
+			t.Errorf("%s: expected %d runs, got %d", step.name, step.runs, *runs)
+		}
+	}
+}
+
+// TestRunByHash tests that a task is skipped while the content of its inputs
+// is unchanged, whatever their times.
+func TestRunByHash(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+
+	err := os.WriteFile(in, []byte("v1"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, runs := buildTask(in, out)
+
+	g, err := New(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Options{Check: ByHash, StampDir: filepath.Join(dir, "stamps")}
+
+	write := func(data string) func() {
+		return func() {
+			err := os.WriteFile(in, []byte(data), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			setTime(t, in, time.Now().Add(time.Hour))
+		}
+	}
+
+	steps := []struct {
+		name string
+		prep func()
+		want Status
+		runs int
+	}{
+		{"first run", func() {}, Succeeded, 1},
+		{"unchanged", func() {}, UpToDate, 1},
+		{"same content", write("v1"), UpToDate, 1},
+		{"new content", write("v2"), Succeeded, 2},
+		{"missing output", func() { _ = os.Remove(out) }, Succeeded, 3},
+		{"missing stamp", func() { _ = os.RemoveAll(opts.StampDir) }, Succeeded, 4},
+	}
+
+	for _, step := range steps {
+		step.prep()
+
+		if got := runOnce(t, g, opts); got != step.want {
+			t.Errorf("%s: expected %v, got %v", step.name, step.want, got)
+		}
+
+		if *runs != step.runs {
+			t.Errorf("%s: expected %d runs, got %d", step.name, step.runs, *runs)
+		}
+	}
+}
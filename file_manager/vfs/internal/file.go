@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+// File is an open file of a Tree.
+type File struct {
+	// tree is the tree the file belongs to.
+	tree *Tree
+
+	// node is the opened node.
+	node *Node
+
+	// name is the name the file was opened with.
+	name string
+
+	// flag are the flags the file was opened with.
+	flag int
+
+	// offset is the current read/write offset.
+	offset int64
+
+	// dir_offset is the number of directory entries already returned by ReadDir.
+	dir_offset int
+
+	// closed is true once the file has been closed.
+	closed bool
+}
+
+// access checks that the file is open and that the operation is allowed.
+// The caller must hold the lock of the tree.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - forbidden: The access mode that forbids the operation.
+//
+// Returns:
+//   - error: An *fs.PathError if the operation is not allowed.
+func (f *File) access(op string, forbidden int) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if f.flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == forbidden {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	}
+
+	err := f.tree.fault(op, f.name)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: f.name, Err: err}
+	}
+
+	return nil
+}
+
+// Stat implements fs.File.
+func (f *File) Stat() (fs.FileInfo, error) {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	info := f.node.Info(f.name)
+	return info, nil
+}
+
+// Read implements fs.File.
+func (f *File) Read(p []byte) (int, error) {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	err := f.access("read", os.O_WRONLY)
+	if err != nil {
+		return 0, err
+	}
+
+	if f.node.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: syscall.EISDIR}
+	}
+
+	if f.offset >= int64(len(f.node.Data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, f.node.Data[f.offset:])
+	f.offset += int64(n)
+
+	return n, nil
+}
+
+// Write implements io.Writer.
+func (f *File) Write(p []byte) (int, error) {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	err := f.access("write", os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.Data))
+	}
+
+	end := f.offset + int64(len(p))
+
+	if end > int64(len(f.node.Data)) {
+		if end > int64(cap(f.node.Data)) {
+			data := make([]byte, end, 2*end)
+			copy(data, f.node.Data)
+
+			f.node.Data = data
+		} else {
+			f.node.Data = f.node.Data[:end]
+		}
+	}
+
+	copy(f.node.Data[f.offset:], p)
+	f.offset = end
+	f.node.ModTime = time.Now()
+
+	return len(p), nil
+}
+
+// Seek implements io.Seeker.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.Data))
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	err := f.access("readdir", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if !f.node.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	entries := f.node.Entries()
+
+	if f.dir_offset >= len(entries) {
+		if n > 0 {
+			return nil, io.EOF
+		}
+
+		return nil, nil
+	}
+
+	entries = entries[f.dir_offset:]
+
+	if n > 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+
+	f.dir_offset += len(entries)
+
+	return entries, nil
+}
+
+// Close implements fs.File.
+func (f *File) Close() error {
+	f.tree.mu.Lock()
+	defer f.tree.mu.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	f.closed = true
+
+	return nil
+}
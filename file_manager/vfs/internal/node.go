@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// MaxHops is the maximum number of symbolic links followed while resolving
+	// a single name.
+	MaxHops int = 40
+)
+
+// Node is an entry of an in-memory file tree.
+type Node struct {
+	// Mode is the mode of the entry, including its type bits.
+	Mode fs.FileMode
+
+	// Data is the content of a regular file.
+	Data []byte
+
+	// Target is the target of a symbolic link.
+	Target string
+
+	// Children are the entries of a directory, by name.
+	Children map[string]*Node
+
+	// ModTime is the last modification time of the entry.
+	ModTime time.Time
+}
+
+// NewDir creates a new, empty directory node.
+//
+// Parameters:
+//   - perm: The permissions of the directory.
+//   - now: The modification time of the directory.
+//
+// Returns:
+//   - *Node: The new node. Never returns nil.
+func NewDir(perm fs.FileMode, now time.Time) *Node {
+	n := &Node{
+		Mode:     fs.ModeDir | (perm & fs.ModePerm),
+		Children: make(map[string]*Node),
+		ModTime:  now,
+	}
+
+	return n
+}
+
+// IsDir checks whether the node is a directory.
+//
+// Returns:
+//   - bool: True if the node is a directory, false otherwise.
+func (n Node) IsDir() bool {
+	return n.Mode.IsDir()
+}
+
+// IsSymlink checks whether the node is a symbolic link.
+//
+// Returns:
+//   - bool: True if the node is a symbolic link, false otherwise.
+func (n Node) IsSymlink() bool {
+	return n.Mode&fs.ModeSymlink != 0
+}
+
+// CanRead checks whether the owner may read the node.
+//
+// Returns:
+//   - bool: True if the node is readable, false otherwise.
+func (n Node) CanRead() bool {
+	return n.Mode&0o400 != 0
+}
+
+// CanWrite checks whether the owner may write the node.
+//
+// Returns:
+//   - bool: True if the node is writable, false otherwise.
+func (n Node) CanWrite() bool {
+	return n.Mode&0o200 != 0
+}
+
+// Info returns the information about the node.
+//
+// Parameters:
+//   - name: The name of the node. Only its last element is kept.
+//
+// Returns:
+//   - fs.FileInfo: The information about the node. Never returns nil.
+func (n *Node) Info(name string) fs.FileInfo {
+	var size int64
+
+	if n.IsSymlink() {
+		size = int64(len(n.Target))
+	} else if !n.IsDir() {
+		size = int64(len(n.Data))
+	}
+
+	info := &FileInfo{
+		name:    path.Base(name),
+		size:    size,
+		mode:    n.Mode,
+		modTime: n.ModTime,
+	}
+
+	return info
+}
+
+// Entries returns the entries of a directory node, sorted by name.
+//
+// Returns:
+//   - []fs.DirEntry: The entries of the directory.
+func (n *Node) Entries() []fs.DirEntry {
+	if len(n.Children) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(n.Children))
+
+	for name := range n.Children {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	entries := make([]fs.DirEntry, 0, len(names))
+
+	for _, name := range names {
+		info := n.Children[name].Info(name)
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	return entries
+}
+
+// FileInfo implements fs.FileInfo for the nodes.
+type FileInfo struct {
+	// name is the base name of the entry.
+	name string
+
+	// size is the size of the entry.
+	size int64
+
+	// mode is the mode of the entry.
+	mode fs.FileMode
+
+	// modTime is the modification time of the entry.
+	modTime time.Time
+}
+
+// Name implements fs.FileInfo.
+func (fi FileInfo) Name() string {
+	return fi.name
+}
+
+// Size implements fs.FileInfo.
+func (fi FileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode implements fs.FileInfo.
+func (fi FileInfo) Mode() fs.FileMode {
+	return fi.mode
+}
+
+// ModTime implements fs.FileInfo.
+func (fi FileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+// IsDir implements fs.FileInfo.
+func (fi FileInfo) IsDir() bool {
+	return fi.mode.IsDir()
+}
+
+// Sys implements fs.FileInfo.
+func (fi FileInfo) Sys() any {
+	return nil
+}
+
+// SplitPath cleans a slash-separated name and splits it into its elements.
+// Elements that would climb above the root are dropped.
+//
+// Parameters:
+//   - name: The name to split.
+//
+// Returns:
+//   - []string: The elements of the name. Nil for the root.
+func SplitPath(name string) []string {
+	cleaned := path.Clean("/" + name)
+	if cleaned == "/" {
+		return nil
+	}
+
+	parts := strings.Split(cleaned[1:], "/")
+	return parts
+}
+
+// Lookup is the result of resolving a name.
+type Lookup struct {
+	// Node is the resolved node, or nil if it does not exist.
+	Node *Node
+
+	// Parent is the directory that contains (or would contain) the node. It is
+	// nil for the root.
+	Parent *Node
+
+	// Base is the name of the node inside Parent.
+	Base string
+
+	// Path is the resolved name of the node, with every symbolic link followed.
+	Path string
+}
+
+// Resolve resolves a name starting from the given root. Symbolic links found in
+// the middle of the name are always followed; a trailing one only if follow is
+// true. Absolute link targets are resolved from the root.
+//
+// Parameters:
+//   - root: The root of the tree. Must not be nil.
+//   - name: The name to resolve.
+//   - follow: Whether to follow a trailing symbolic link.
+//
+// Returns:
+//   - Lookup: The result of the resolution.
+//   - error: An error if the name cannot be resolved.
+//
+// Errors:
+//   - fs.ErrNotExist: If the node does not exist. Lookup.Parent is set if only the last element is missing.
+//   - syscall.ENOTDIR: If an element other than the last is not a directory.
+//   - syscall.ELOOP: If too many symbolic links were followed.
+func Resolve(root *Node, name string, follow bool) (Lookup, error) {
+	parts := SplitPath(name)
+
+	for hops := 0; hops <= MaxHops; hops++ {
+		res := Lookup{
+			Node: root,
+			Path: ".",
+		}
+
+		restarted := false
+
+		for i, part := range parts {
+			if !res.Node.IsDir() {
+				return Lookup{}, syscall.ENOTDIR
+			}
+
+			child := res.Node.Children[part]
+			last := i == len(parts)-1
+
+			if child == nil {
+				if !last {
+					return Lookup{}, fs.ErrNotExist
+				}
+
+				res = Lookup{
+					Parent: res.Node,
+					Base:   part,
+					Path:   path.Join(res.Path, part),
+				}
+
+				return res, fs.ErrNotExist
+			}
+
+			if child.IsSymlink() && (!last || follow) {
+				var target string
+
+				if strings.HasPrefix(child.Target, "/") {
+					target = child.Target
+				} else {
+					target = path.Join(res.Path, child.Target)
+				}
+
+				rest := strings.Join(parts[i+1:], "/")
+
+				parts = SplitPath(path.Join(target, rest))
+				restarted = true
+
+				break
+			}
+
+			res = Lookup{
+				Node:   child,
+				Parent: res.Node,
+				Base:   part,
+				Path:   path.Join(res.Path, part),
+			}
+		}
+
+		if !restarted {
+			return res, nil
+		}
+	}
+
+	return Lookup{}, syscall.ELOOP
+}
@@ -0,0 +1,662 @@
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fault is an error injected on a name of a Tree.
+type Fault struct {
+	// Name is the name the fault applies to. It also applies to every name
+	// below it.
+	Name string
+
+	// Op is the operation the fault applies to (e.g. "open", "write"). If empty,
+	// the fault applies to every operation.
+	Op string
+
+	// Err is the error returned by the faulty operation.
+	Err error
+}
+
+// Tree is an in-memory file tree safe for concurrent use.
+type Tree struct {
+	// mu protects every field and node of the tree.
+	mu sync.Mutex
+
+	// root is the root directory.
+	root *Node
+
+	// faults are the injected faults.
+	faults []Fault
+}
+
+// NewTree creates a new tree whose root is an empty directory.
+//
+// Returns:
+//   - *Tree: The new tree. Never returns nil.
+func NewTree() *Tree {
+	t := &Tree{
+		root: NewDir(0o755, time.Now()),
+	}
+
+	return t
+}
+
+// AddFault injects a fault in the tree.
+//
+// Parameters:
+//   - fault: The fault to inject.
+func (t *Tree) AddFault(fault Fault) {
+	fault.Name = path.Clean(fault.Name)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.faults = append(t.faults, fault)
+}
+
+// ClearFaults removes every injected fault.
+func (t *Tree) ClearFaults() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.faults = nil
+}
+
+// fault returns the error injected for the given operation and name, if any.
+// The caller must hold the lock.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - name: The name the operation is performed on.
+//
+// Returns:
+//   - error: The injected error, or nil if there is none.
+func (t *Tree) fault(op, name string) error {
+	name = path.Clean(name)
+
+	for _, f := range t.faults {
+		if f.Op != "" && f.Op != op {
+			continue
+		}
+
+		if f.Name == "." || f.Name == name || strings.HasPrefix(name, f.Name+"/") {
+			return f.Err
+		}
+	}
+
+	return nil
+}
+
+// verify validates the name and returns the fault injected on it, if any. The
+// caller must hold the lock.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - name: The name the operation is performed on.
+//
+// Returns:
+//   - error: fs.ErrInvalid if the name is not valid, the injected error if
+//     there is one, nil otherwise.
+func (t *Tree) verify(op, name string) error {
+	ok := fs.ValidPath(name)
+	if !ok {
+		return fs.ErrInvalid
+	}
+
+	err := t.fault(op, name)
+	return err
+}
+
+// check is like verify but wraps the error in an *fs.PathError. The caller
+// must hold the lock.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - name: The name the operation is performed on.
+//
+// Returns:
+//   - error: An *fs.PathError if the name is not valid or a fault was injected.
+func (t *Tree) check(op, name string) error {
+	err := t.verify(op, name)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	return nil
+}
+
+// stat implements Stat and Lstat.
+//
+// Parameters:
+//   - op: The name of the operation.
+//   - name: The name of the entry.
+//   - follow: Whether to follow a trailing symbolic link.
+//
+// Returns:
+//   - fs.FileInfo: The information about the entry.
+//   - error: An *fs.PathError if the entry cannot be described.
+func (t *Tree) stat(op, name string, follow bool) (fs.FileInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Resolve(t.root, name, follow)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	info := res.Node.Info(name)
+	return info, nil
+}
+
+// Stat returns the information about the named entry, following symbolic
+// links.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - fs.FileInfo: The information about the entry.
+//   - error: An *fs.PathError if the entry cannot be described.
+func (t *Tree) Stat(name string) (fs.FileInfo, error) {
+	info, err := t.stat("stat", name, true)
+	return info, err
+}
+
+// Lstat returns the information about the named entry, without following a
+// trailing symbolic link.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - fs.FileInfo: The information about the entry.
+//   - error: An *fs.PathError if the entry cannot be described.
+func (t *Tree) Lstat(name string) (fs.FileInfo, error) {
+	info, err := t.stat("lstat", name, false)
+	return info, err
+}
+
+// ReadDir returns the entries of the named directory, sorted by name.
+//
+// Parameters:
+//   - name: The name of the directory.
+//
+// Returns:
+//   - []fs.DirEntry: The entries of the directory.
+//   - error: An *fs.PathError if the directory cannot be read.
+func (t *Tree) ReadDir(name string) ([]fs.DirEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Resolve(t.root, name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	if !res.Node.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+
+	if !res.Node.CanRead() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+
+	entries := res.Node.Entries()
+	return entries, nil
+}
+
+// ReadLink returns the target of the named symbolic link.
+//
+// Parameters:
+//   - name: The name of the symbolic link.
+//
+// Returns:
+//   - string: The target of the link.
+//   - error: An *fs.PathError if the link cannot be read.
+func (t *Tree) ReadLink(name string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("readlink", name)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := Resolve(t.root, name, false)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	if !res.Node.IsSymlink() {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return res.Node.Target, nil
+}
+
+// OpenFile opens the named file with the given flags and, if it is created,
+// the given permissions.
+//
+// Parameters:
+//   - name: The name of the file.
+//   - flag: The flags to open the file with, as in os.OpenFile.
+//   - perm: The permissions of the file, if created.
+//
+// Returns:
+//   - *File: The opened file.
+//   - error: An *fs.PathError if the file cannot be opened.
+func (t *Tree) OpenFile(name string, flag int, perm fs.FileMode) (*File, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	reads := access != os.O_WRONLY
+	writes := access != os.O_RDONLY
+
+	res, err := Resolve(t.root, name, true)
+	if err == fs.ErrNotExist && res.Parent != nil && flag&os.O_CREATE != 0 {
+		if !res.Parent.CanWrite() {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+		}
+
+		now := time.Now()
+
+		res.Node = &Node{
+			Mode:    perm & fs.ModePerm,
+			ModTime: now,
+		}
+
+		res.Parent.Children[res.Base] = res.Node
+		res.Parent.ModTime = now
+	} else if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	} else if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	} else if res.Node.IsDir() && writes {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	} else if (reads && !res.Node.CanRead()) || (writes && !res.Node.CanWrite()) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	} else if writes && flag&os.O_TRUNC != 0 {
+		res.Node.Data = nil
+		res.Node.ModTime = time.Now()
+	}
+
+	f := &File{
+		tree: t,
+		node: res.Node,
+		name: name,
+		flag: flag,
+	}
+
+	return f, nil
+}
+
+// create resolves the name of an entry about to be created and checks that it
+// can be created.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - Lookup: The resolution of the name. Its Parent and Base are set.
+//   - error: An error if the entry cannot be created.
+//
+// Errors:
+//   - fs.ErrExist: If the entry already exists.
+//   - fs.ErrPermission: If the parent directory is not writable.
+//   - any error returned by Resolve.
+func (t *Tree) create(name string) (Lookup, error) {
+	res, err := Resolve(t.root, name, false)
+	if err == nil {
+		return res, fs.ErrExist
+	} else if err != fs.ErrNotExist || res.Parent == nil {
+		return res, err
+	}
+
+	if !res.Parent.CanWrite() {
+		return res, fs.ErrPermission
+	}
+
+	return res, nil
+}
+
+// Mkdir creates the named directory.
+//
+// Parameters:
+//   - name: The name of the directory.
+//   - perm: The permissions of the directory.
+//
+// Returns:
+//   - error: An *fs.PathError if the directory cannot be created.
+func (t *Tree) Mkdir(name string, perm fs.FileMode) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	res, err := t.create(name)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+
+	now := time.Now()
+
+	res.Parent.Children[res.Base] = NewDir(perm, now)
+	res.Parent.ModTime = now
+
+	return nil
+}
+
+// MkdirAll creates the named directory along with any missing parent.
+//
+// Parameters:
+//   - name: The name of the directory.
+//   - perm: The permissions of every directory created.
+//
+// Returns:
+//   - error: An *fs.PathError if a directory cannot be created.
+func (t *Tree) MkdirAll(name string, perm fs.FileMode) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	parts := SplitPath(name)
+
+	for i := range parts {
+		sub := strings.Join(parts[:i+1], "/")
+
+		res, err := Resolve(t.root, sub, true)
+		if err == nil {
+			if !res.Node.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: sub, Err: syscall.ENOTDIR}
+			}
+
+			continue
+		}
+
+		if err != fs.ErrNotExist || res.Parent == nil {
+			return &fs.PathError{Op: "mkdir", Path: sub, Err: err}
+		}
+
+		if !res.Parent.CanWrite() {
+			return &fs.PathError{Op: "mkdir", Path: sub, Err: fs.ErrPermission}
+		}
+
+		now := time.Now()
+
+		res.Parent.Children[res.Base] = NewDir(perm, now)
+		res.Parent.ModTime = now
+	}
+
+	return nil
+}
+
+// remove implements Remove and RemoveAll.
+//
+// Parameters:
+//   - op: The name of the operation.
+//   - name: The name of the entry.
+//   - all: Whether non-empty directories and missing entries are accepted.
+//
+// Returns:
+//   - error: An *fs.PathError if the entry cannot be removed.
+func (t *Tree) remove(op, name string, all bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check(op, name)
+	if err != nil {
+		return err
+	}
+
+	res, err := Resolve(t.root, name, false)
+	if err == fs.ErrNotExist && all {
+		return nil
+	} else if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	if res.Parent == nil {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if !all && res.Node.IsDir() && len(res.Node.Children) > 0 {
+		return &fs.PathError{Op: op, Path: name, Err: syscall.ENOTEMPTY}
+	}
+
+	if !res.Parent.CanWrite() {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+
+	delete(res.Parent.Children, res.Base)
+	res.Parent.ModTime = time.Now()
+
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - error: An *fs.PathError if the entry cannot be removed.
+func (t *Tree) Remove(name string) error {
+	err := t.remove("remove", name, false)
+	return err
+}
+
+// RemoveAll removes the named entry and everything it contains.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - error: An *fs.PathError if the entry cannot be removed.
+func (t *Tree) RemoveAll(name string) error {
+	err := t.remove("removeall", name, true)
+	return err
+}
+
+// Rename moves oldname to newname.
+//
+// Parameters:
+//   - oldname: The current name of the entry.
+//   - newname: The new name of the entry.
+//
+// Returns:
+//   - error: An *os.LinkError if the entry cannot be moved.
+func (t *Tree) Rename(oldname, newname string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.rename(oldname, newname)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	return nil
+}
+
+// rename implements Rename. The caller must hold the lock.
+//
+// Parameters:
+//   - oldname: The current name of the entry.
+//   - newname: The new name of the entry.
+//
+// Returns:
+//   - error: An error if the entry cannot be moved.
+func (t *Tree) rename(oldname, newname string) error {
+	for _, name := range []string{oldname, newname} {
+		err := t.verify("rename", name)
+		if err != nil {
+			return err
+		}
+	}
+
+	src, err := Resolve(t.root, oldname, false)
+	if err != nil {
+		return err
+	}
+
+	dst, err := Resolve(t.root, newname, false)
+	if err != nil && (err != fs.ErrNotExist || dst.Parent == nil) {
+		return err
+	}
+
+	if src.Parent == nil || dst.Parent == nil {
+		return fs.ErrInvalid
+	}
+
+	if dst.Node == src.Node {
+		return nil
+	}
+
+	if src.Node.IsDir() && strings.HasPrefix(dst.Path+"/", src.Path+"/") {
+		return fs.ErrInvalid
+	}
+
+	if dst.Node != nil {
+		switch {
+		case dst.Node.IsDir() && !src.Node.IsDir():
+			return syscall.EISDIR
+		case !dst.Node.IsDir() && src.Node.IsDir():
+			return syscall.ENOTDIR
+		case dst.Node.IsDir() && len(dst.Node.Children) > 0:
+			return syscall.ENOTEMPTY
+		}
+	}
+
+	if !src.Parent.CanWrite() || !dst.Parent.CanWrite() {
+		return fs.ErrPermission
+	}
+
+	now := time.Now()
+
+	delete(src.Parent.Children, src.Base)
+	src.Parent.ModTime = now
+
+	dst.Parent.Children[dst.Base] = src.Node
+	dst.Parent.ModTime = now
+
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+//
+// Parameters:
+//   - oldname: The target of the link.
+//   - newname: The name of the link.
+//
+// Returns:
+//   - error: An *os.LinkError if the link cannot be created.
+func (t *Tree) Symlink(oldname, newname string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.verify("symlink", newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	res, err := t.create(newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	now := time.Now()
+
+	res.Parent.Children[res.Base] = &Node{
+		Mode:    fs.ModeSymlink | fs.ModePerm,
+		Target:  oldname,
+		ModTime: now,
+	}
+
+	res.Parent.ModTime = now
+
+	return nil
+}
+
+// Chmod changes the permissions of the named entry, following symbolic links.
+//
+// Parameters:
+//   - name: The name of the entry.
+//   - mode: The new permissions.
+//
+// Returns:
+//   - error: An *fs.PathError if the permissions cannot be changed.
+func (t *Tree) Chmod(name string, mode fs.FileMode) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("chmod", name)
+	if err != nil {
+		return err
+	}
+
+	res, err := Resolve(t.root, name, true)
+	if err != nil {
+		return &fs.PathError{Op: "chmod", Path: name, Err: err}
+	}
+
+	res.Node.Mode = (res.Node.Mode &^ fs.ModePerm) | (mode & fs.ModePerm)
+
+	return nil
+}
+
+// Chtimes changes the modification time of the named entry, following
+// symbolic links.
+//
+// Parameters:
+//   - name: The name of the entry.
+//   - mtime: The new modification time.
+//
+// Returns:
+//   - error: An *fs.PathError if the time cannot be changed.
+func (t *Tree) Chtimes(name string, mtime time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.check("chtimes", name)
+	if err != nil {
+		return err
+	}
+
+	res, err := Resolve(t.root, name, true)
+	if err != nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+
+	res.Node.ModTime = mtime
+
+	return nil
+}
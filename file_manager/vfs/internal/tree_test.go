@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// treeFS adapts a Tree to fs.FS.
+type treeFS struct {
+	*Tree
+}
+
+// Open implements fs.FS.
+func (t treeFS) Open(name string) (fs.File, error) {
+	f, err := t.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// writeFile creates the named file with the given content.
+func writeFile(t *testing.T, tree *Tree, name, data string) {
+	t.Helper()
+
+	f, err := tree.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+
+	_, err = io.WriteString(f, data)
+	if err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	_ = f.Close()
+}
+
+// TestTree tests the tree against the fs.FS contract.
+func TestTree(t *testing.T) {
+	tree := NewTree()
+
+	err := tree.MkdirAll("a/b", 0o755)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeFile(t, tree, "a/b/c.txt", "hello")
+	writeFile(t, tree, "d.txt", "world")
+
+	err = tree.Symlink("a/b", "link")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = fstest.TestFS(treeFS{tree}, "a/b/c.txt", "d.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := tree.Stat("link/c.txt")
+	if err != nil {
+		t.Errorf("expected link to be followed: %v", err)
+	} else if info.Size() != 5 {
+		t.Errorf("expected size 5, got %d", info.Size())
+	}
+
+	err = tree.Rename("a/b", "a/b/x")
+	if !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("expected fs.ErrInvalid, got %v", err)
+	}
+
+	err = tree.Remove("a")
+	if !errors.Is(err, syscall.ENOTEMPTY) {
+		t.Errorf("expected syscall.ENOTEMPTY, got %v", err)
+	}
+
+	err = tree.Rename("a/b/c.txt", "e.txt")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = tree.RemoveAll("a")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = tree.Stat("link")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected dangling link, got %v", err)
+	}
+}
+
+// TestTreeFaults tests fault injection and permissions.
+func TestTreeFaults(t *testing.T) {
+	tree := NewTree()
+
+	tree.AddFault(Fault{Name: "full", Op: "write", Err: syscall.ENOSPC})
+	tree.AddFault(Fault{Name: "secret", Err: syscall.EACCES})
+
+	f, err := tree.OpenFile("full", os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.Write([]byte("data"))
+	if !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("expected syscall.ENOSPC, got %v", err)
+	}
+
+	err = tree.Mkdir("secret", 0o755)
+	if !errors.Is(err, syscall.EACCES) {
+		t.Errorf("expected syscall.EACCES, got %v", err)
+	}
+
+	tree.ClearFaults()
+
+	err = tree.MkdirAll("secret/inner", 0o755)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = tree.Chmod("secret", 0o555)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = tree.Remove("secret/inner")
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("expected fs.ErrPermission, got %v", err)
+	}
+}
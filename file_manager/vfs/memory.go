@@ -0,0 +1,176 @@
+package vfs
+
+import (
+	"io/fs"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs/internal"
+)
+
+// Memory is an in-memory FS, safe for concurrent use. Only the owner bits of
+// the permissions are enforced: reading requires 0400, writing 0200 and
+// adding or removing entries of a directory requires 0200 on the directory.
+//
+// Faults can be injected on chosen names to exercise error paths, such as a
+// full disk or a permission problem:
+//
+//	m := vfs.NewMemory()
+//	_ = m.InjectFault("out", "write", syscall.ENOSPC)
+//	_ = m.InjectFault("secret", "", syscall.EACCES)
+type Memory struct {
+	// tree is the underlying tree.
+	tree *internal.Tree
+}
+
+// NewMemory creates a new, empty in-memory file system.
+//
+// Returns:
+//   - *Memory: The new file system. Never returns nil.
+func NewMemory() *Memory {
+	m := &Memory{
+		tree: internal.NewTree(),
+	}
+
+	return m
+}
+
+// InjectFault makes every future op performed on name, or on any entry below
+// it, fail with err. Faults are checked before anything else, in the order
+// they were injected.
+//
+// Parameters:
+//   - name: The name the fault applies to. "." applies to the whole file system.
+//   - op: The operation that fails, as reported in *fs.PathError (e.g. "open", "write", "mkdir"). If empty, every operation fails.
+//   - err: The error to report.
+//
+// Returns:
+//   - error: An error if the fault cannot be injected.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+//   - *errors.ErrBadParam: If err is nil.
+func (m *Memory) InjectFault(name, op string, err error) error {
+	if m == nil {
+		return gers.ErrNilReceiver
+	} else if err == nil {
+		return gers.NewErrNilParam("err")
+	}
+
+	m.tree.AddFault(internal.Fault{
+		Name: name,
+		Op:   op,
+		Err:  err,
+	})
+
+	return nil
+}
+
+// ClearFaults removes every injected fault.
+//
+// Returns:
+//   - error: An error if the faults cannot be cleared.
+//
+// Errors:
+//   - errors.ErrNilReceiver: If the receiver is nil.
+func (m *Memory) ClearFaults() error {
+	if m == nil {
+		return gers.ErrNilReceiver
+	}
+
+	m.tree.ClearFaults()
+
+	return nil
+}
+
+// Open implements fs.FS.
+func (m *Memory) Open(name string) (fs.File, error) {
+	f, err := m.tree.OpenFile(name, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Stat implements fs.StatFS.
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	info, err := m.tree.Stat(name)
+	return info, err
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (m *Memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := m.tree.ReadDir(name)
+	return entries, err
+}
+
+// Lstat implements FS.
+func (m *Memory) Lstat(name string) (fs.FileInfo, error) {
+	info, err := m.tree.Lstat(name)
+	return info, err
+}
+
+// ReadLink implements FS.
+func (m *Memory) ReadLink(name string) (string, error) {
+	target, err := m.tree.ReadLink(name)
+	return target, err
+}
+
+// OpenFile implements FS.
+func (m *Memory) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := m.tree.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Mkdir implements FS.
+func (m *Memory) Mkdir(name string, perm fs.FileMode) error {
+	err := m.tree.Mkdir(name, perm)
+	return err
+}
+
+// MkdirAll implements FS.
+func (m *Memory) MkdirAll(name string, perm fs.FileMode) error {
+	err := m.tree.MkdirAll(name, perm)
+	return err
+}
+
+// Remove implements FS.
+func (m *Memory) Remove(name string) error {
+	err := m.tree.Remove(name)
+	return err
+}
+
+// RemoveAll implements FS.
+func (m *Memory) RemoveAll(name string) error {
+	err := m.tree.RemoveAll(name)
+	return err
+}
+
+// Rename implements FS.
+func (m *Memory) Rename(oldname, newname string) error {
+	err := m.tree.Rename(oldname, newname)
+	return err
+}
+
+// Symlink implements FS.
+func (m *Memory) Symlink(oldname, newname string) error {
+	err := m.tree.Symlink(oldname, newname)
+	return err
+}
+
+// Chmod implements FS.
+func (m *Memory) Chmod(name string, mode fs.FileMode) error {
+	err := m.tree.Chmod(name, mode)
+	return err
+}
+
+// Chtimes implements FS. The access time is ignored.
+func (m *Memory) Chtimes(name string, atime, mtime time.Time) error {
+	err := m.tree.Chtimes(name, mtime)
+	return err
+}
@@ -0,0 +1,213 @@
+package vfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OS is the FS backed by the operating system.
+//
+// When Dir is empty, names are native paths handed as is to the os package,
+// which makes the zero value a drop-in replacement for direct os calls.
+// Otherwise, names must satisfy fs.ValidPath and are resolved inside Dir,
+// just like os.DirFS.
+type OS struct {
+	// Dir is the directory names are resolved against. If empty, names are
+	// native paths.
+	Dir string
+}
+
+// path converts a name into a native path.
+//
+// Parameters:
+//   - op: The operation being performed, used in the error.
+//   - name: The name to convert.
+//
+// Returns:
+//   - string: The native path.
+//   - error: An error if the name is not valid.
+//
+// Errors:
+//   - *fs.PathError: If Dir is set and the name is not valid.
+func (o OS) path(op, name string) (string, error) {
+	if o.Dir == "" {
+		return name, nil
+	}
+
+	ok := fs.ValidPath(name)
+	if !ok {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	loc := filepath.Join(o.Dir, filepath.FromSlash(name))
+	return loc, nil
+}
+
+// Open implements fs.FS.
+func (o OS) Open(name string) (fs.File, error) {
+	loc, err := o.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Stat implements fs.StatFS.
+func (o OS) Stat(name string) (fs.FileInfo, error) {
+	loc, err := o.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(loc)
+	return info, err
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (o OS) ReadDir(name string) ([]fs.DirEntry, error) {
+	loc, err := o.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(loc)
+	return entries, err
+}
+
+// Lstat implements FS.
+func (o OS) Lstat(name string) (fs.FileInfo, error) {
+	loc, err := o.path("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(loc)
+	return info, err
+}
+
+// ReadLink implements FS.
+func (o OS) ReadLink(name string) (string, error) {
+	loc, err := o.path("readlink", name)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := os.Readlink(loc)
+	return target, err
+}
+
+// OpenFile implements FS.
+func (o OS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	loc, err := o.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(loc, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Mkdir implements FS.
+func (o OS) Mkdir(name string, perm fs.FileMode) error {
+	loc, err := o.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Mkdir(loc, perm)
+	return err
+}
+
+// MkdirAll implements FS.
+func (o OS) MkdirAll(name string, perm fs.FileMode) error {
+	loc, err := o.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(loc, perm)
+	return err
+}
+
+// Remove implements FS.
+func (o OS) Remove(name string) error {
+	loc, err := o.path("remove", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(loc)
+	return err
+}
+
+// RemoveAll implements FS.
+func (o OS) RemoveAll(name string) error {
+	loc, err := o.path("removeall", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(loc)
+	return err
+}
+
+// Rename implements FS.
+func (o OS) Rename(oldname, newname string) error {
+	old_loc, err := o.path("rename", oldname)
+	if err != nil {
+		return err
+	}
+
+	new_loc, err := o.path("rename", newname)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(old_loc, new_loc)
+	return err
+}
+
+// Symlink implements FS.
+func (o OS) Symlink(oldname, newname string) error {
+	loc, err := o.path("symlink", newname)
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(oldname, loc)
+	return err
+}
+
+// Chmod implements FS.
+func (o OS) Chmod(name string, mode fs.FileMode) error {
+	loc, err := o.path("chmod", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(loc, mode)
+	return err
+}
+
+// Chtimes implements FS.
+func (o OS) Chtimes(name string, atime, mtime time.Time) error {
+	loc, err := o.path("chtimes", name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chtimes(loc, atime, mtime)
+	return err
+}
@@ -0,0 +1,851 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+)
+
+// Overlay is a FS that stacks a writable upper FS on top of a read-only lower
+// fs.FS, in the spirit of overlayfs. Reads see the upper entries first, then the
+// lower ones; writes only ever reach the upper layer. Lower files are copied up
+// before being modified and removed lower entries are hidden by whiteouts kept
+// in memory.
+//
+// Symbolic links are resolved within a single layer, and lower directories
+// cannot be renamed (syscall.EXDEV is reported instead), just like with
+// overlayfs without redirects.
+type Overlay struct {
+	// lower is the read-only layer.
+	lower fs.FS
+
+	// upper is the writable layer.
+	upper FS
+
+	// mu protects whiteouts and serializes the operations.
+	mu sync.Mutex
+
+	// whiteouts are the names of the lower entries that were removed.
+	whiteouts map[string]struct{}
+}
+
+// NewOverlay creates a new overlay on top of the given read-only file system.
+//
+// Parameters:
+//   - lower: The read-only layer. Must not be nil.
+//   - upper: The writable layer. If nil, a new Memory is used.
+//
+// Returns:
+//   - *Overlay: The new overlay.
+//   - error: An error if the layers are not valid.
+//
+// Errors:
+//   - *errors.ErrBadParam: If lower is nil.
+func NewOverlay(lower fs.FS, upper FS) (*Overlay, error) {
+	if lower == nil {
+		return nil, gers.NewErrNilParam("lower")
+	}
+
+	if upper == nil {
+		upper = NewMemory()
+	}
+
+	o := &Overlay{
+		lower:     lower,
+		upper:     upper,
+		whiteouts: make(map[string]struct{}),
+	}
+
+	return o, nil
+}
+
+// hidden checks whether the lower entry with the given name, or one of its
+// parents, was removed. The caller must hold the lock.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - bool: True if the lower entry is hidden, false otherwise.
+func (o *Overlay) hidden(name string) bool {
+	for p := path.Clean(name); ; p = path.Dir(p) {
+		_, ok := o.whiteouts[p]
+		if ok {
+			return true
+		}
+
+		if p == "." {
+			return false
+		}
+	}
+}
+
+// lowerStat describes a visible lower entry. The caller must hold the lock.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - name: The name of the entry.
+//   - follow: Whether to follow a trailing symbolic link, if the lower layer supports it.
+//
+// Returns:
+//   - fs.FileInfo: The information about the entry.
+//   - error: An error if the entry is hidden or cannot be described.
+func (o *Overlay) lowerStat(op, name string, follow bool) (fs.FileInfo, error) {
+	if o.hidden(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	if !follow {
+		lfs, ok := o.lower.(interface {
+			Lstat(name string) (fs.FileInfo, error)
+		})
+		if ok {
+			info, err := lfs.Lstat(name)
+			return info, err
+		}
+	}
+
+	info, err := fs.Stat(o.lower, name)
+	return info, err
+}
+
+// stat describes an entry of any layer. The caller must hold the lock.
+//
+// Parameters:
+//   - op: The operation being performed.
+//   - name: The name of the entry.
+//   - follow: Whether to follow a trailing symbolic link.
+//
+// Returns:
+//   - fs.FileInfo: The information about the entry.
+//   - error: An error if the entry cannot be described.
+func (o *Overlay) stat(op, name string, follow bool) (fs.FileInfo, error) {
+	ok := fs.ValidPath(name)
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	var info fs.FileInfo
+	var err error
+
+	if follow {
+		info, err = o.upper.Stat(name)
+	} else {
+		info, err = o.upper.Lstat(name)
+	}
+
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return info, err
+	}
+
+	info, err = o.lowerStat(op, name, follow)
+	return info, err
+}
+
+// inUpper checks whether the named entry exists in the upper layer. The caller
+// must hold the lock.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - bool: True if the entry exists in the upper layer, false otherwise.
+func (o *Overlay) inUpper(name string) bool {
+	_, err := o.upper.Lstat(name)
+	return err == nil
+}
+
+// inLower checks whether the named entry exists and is visible in the lower
+// layer. The caller must hold the lock.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - bool: True if the entry is visible in the lower layer, false otherwise.
+func (o *Overlay) inLower(name string) bool {
+	_, err := o.lowerStat("lstat", name, false)
+	return err == nil
+}
+
+// copyUpDir makes sure the named directory, and all its parents, exist in the
+// upper layer by copying them from the lower one. Copied directories are
+// always accessible to their owner. The caller must hold the lock.
+//
+// Parameters:
+//   - dir: The name of the directory.
+//
+// Returns:
+//   - error: An error if the directory cannot be copied up.
+func (o *Overlay) copyUpDir(dir string) error {
+	if dir == "." || o.inUpper(dir) {
+		return nil
+	}
+
+	info, err := o.lowerStat("mkdir", dir, true)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return &fs.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+
+	err = o.copyUpDir(path.Dir(dir))
+	if err != nil {
+		return err
+	}
+
+	// The owner must be able to write in the copied directory, otherwise its
+	// lower entries could never be copied up.
+	err = o.upper.Mkdir(dir, info.Mode().Perm()|0o700)
+	return err
+}
+
+// copyUp copies the named lower entry to the upper layer, along with its
+// parents. If the entry exists in neither layer, only its parents are copied.
+// The caller must hold the lock.
+//
+// Parameters:
+//   - name: The name of the entry.
+//
+// Returns:
+//   - error: An error if the entry cannot be copied up.
+func (o *Overlay) copyUp(name string) error {
+	if o.inUpper(name) {
+		return nil
+	}
+
+	err := o.copyUpDir(path.Dir(name))
+	if err != nil {
+		return err
+	}
+
+	info, err := o.lowerStat("open", name, false)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	perm := info.Mode().Perm()
+
+	if info.IsDir() {
+		err := o.upper.Mkdir(name, perm)
+		return err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		lfs, ok := o.lower.(interface {
+			ReadLink(name string) (string, error)
+		})
+		if !ok {
+			return &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+		}
+
+		target, err := lfs.ReadLink(name)
+		if err != nil {
+			return err
+		}
+
+		err = o.upper.Symlink(target, name)
+		return err
+	}
+
+	data, err := fs.ReadFile(o.lower, name)
+	if err != nil {
+		return err
+	}
+
+	err = WriteFile(o.upper, name, data, perm)
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Chmod(name, perm)
+	return err
+}
+
+// readDir merges the entries of the named directory in both layers. The caller
+// must hold the lock.
+//
+// Parameters:
+//   - name: The name of the directory.
+//
+// Returns:
+//   - []fs.DirEntry: The entries of the directory, sorted by name.
+//   - error: An error if the directory cannot be read.
+func (o *Overlay) readDir(name string) ([]fs.DirEntry, error) {
+	info, err := o.stat("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+
+	seen := make(map[string]struct{})
+
+	var entries []fs.DirEntry
+
+	upper, err := o.upper.ReadDir(name)
+	if err == nil {
+		for _, e := range upper {
+			seen[e.Name()] = struct{}{}
+		}
+
+		entries = append(entries, upper...)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if o.hidden(name) {
+		return entries, nil
+	}
+
+	lower, err := fs.ReadDir(o.lower, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, e := range lower {
+		_, ok := seen[e.Name()]
+		if ok || o.hidden(path.Join(name, e.Name())) {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// Open implements fs.FS.
+func (o *Overlay) Open(name string) (fs.File, error) {
+	f, err := o.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Stat implements fs.StatFS.
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.stat("stat", name, true)
+	return info, err
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, err := o.readDir(name)
+	return entries, err
+}
+
+// Lstat implements FS.
+func (o *Overlay) Lstat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.stat("lstat", name, false)
+	return info, err
+}
+
+// ReadLink implements FS.
+func (o *Overlay) ReadLink(name string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.inUpper(name) {
+		target, err := o.upper.ReadLink(name)
+		return target, err
+	}
+
+	_, err := o.lowerStat("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+
+	lfs, ok := o.lower.(interface {
+		ReadLink(name string) (string, error)
+	})
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	target, err := lfs.ReadLink(name)
+	return target, err
+}
+
+// OpenFile implements FS.
+func (o *Overlay) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	writes := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+
+	info, err := o.stat("open", name, true)
+	if err == nil && info.IsDir() {
+		if writes {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+
+		f := &overlayDir{
+			overlay: o,
+			name:    name,
+			info:    info,
+		}
+
+		return f, nil
+	}
+
+	if !writes {
+		if err != nil {
+			return nil, err
+		}
+
+		if o.inUpper(name) {
+			f, err := o.upper.OpenFile(name, flag, perm)
+			return f, err
+		}
+
+		f, err := o.lower.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		ro := &readOnlyFile{
+			File: f,
+			name: name,
+		}
+
+		return ro, nil
+	}
+
+	err = o.copyUp(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := o.upper.OpenFile(name, flag, perm)
+	return f, err
+}
+
+// Mkdir implements FS.
+func (o *Overlay) Mkdir(name string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	err := o.mkdir(name, perm)
+	return err
+}
+
+// mkdir implements Mkdir. The caller must hold the lock.
+//
+// Parameters:
+//   - name: The name of the directory.
+//   - perm: The permissions of the directory.
+//
+// Returns:
+//   - error: An error if the directory cannot be created.
+func (o *Overlay) mkdir(name string, perm fs.FileMode) error {
+	_, err := o.stat("mkdir", name, false)
+	if err == nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	err = o.copyUpDir(path.Dir(name))
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Mkdir(name, perm)
+	return err
+}
+
+// MkdirAll implements FS.
+func (o *Overlay) MkdirAll(name string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ok := fs.ValidPath(name)
+	if !ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return nil
+	}
+
+	parts := strings.Split(name, "/")
+
+	for i := range parts {
+		sub := strings.Join(parts[:i+1], "/")
+
+		info, err := o.stat("mkdir", sub, true)
+		if err == nil {
+			if !info.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: sub, Err: syscall.ENOTDIR}
+			}
+
+			continue
+		}
+
+		err = o.mkdir(sub, perm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove implements FS.
+func (o *Overlay) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.stat("remove", name, false)
+	if err != nil {
+		return err
+	}
+
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if info.IsDir() {
+		entries, err := o.readDir(name)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+
+	in_lower := o.inLower(name)
+
+	if o.inUpper(name) {
+		err := o.upper.RemoveAll(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if in_lower {
+		o.whiteouts[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// RemoveAll implements FS.
+func (o *Overlay) RemoveAll(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ok := fs.ValidPath(name)
+	if !ok || name == "." {
+		return &fs.PathError{Op: "removeall", Path: name, Err: fs.ErrInvalid}
+	}
+
+	in_lower := o.inLower(name)
+
+	err := o.upper.RemoveAll(name)
+	if err != nil {
+		return err
+	}
+
+	if in_lower {
+		o.whiteouts[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// Rename implements FS.
+func (o *Overlay) Rename(oldname, newname string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.stat("rename", oldname, false)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+
+	in_lower := o.inLower(oldname)
+
+	if in_lower && info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+
+	// The destination is checked as merged from both layers, since the upper
+	// layer alone does not know about the lower entries it would cover.
+	err = o.renameTarget(info, oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	covers_lower := o.inLower(newname)
+
+	err = o.copyUp(oldname)
+	if err != nil {
+		return err
+	}
+
+	err = o.copyUpDir(path.Dir(newname))
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Rename(oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	if in_lower {
+		o.whiteouts[oldname] = struct{}{}
+	}
+
+	// Whatever the destination covered in the lower layer is gone; only the
+	// renamed entry of the upper layer must remain visible.
+	if covers_lower {
+		o.whiteouts[newname] = struct{}{}
+	}
+
+	return nil
+}
+
+// renameTarget checks that the entry can be renamed onto the destination, as
+// rename(2) would: a directory may only replace an empty directory, and a
+// non-directory may only replace a non-directory. The caller must hold the
+// lock.
+//
+// Parameters:
+//   - info: The information about the renamed entry.
+//   - oldname: The name of the renamed entry.
+//   - newname: The name of the destination.
+//
+// Returns:
+//   - error: An error if the destination cannot be replaced.
+func (o *Overlay) renameTarget(info fs.FileInfo, oldname, newname string) error {
+	if path.Clean(oldname) == path.Clean(newname) {
+		return nil
+	}
+
+	target, err := o.stat("rename", newname, false)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+
+	switch {
+	case info.IsDir() && !target.IsDir():
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.ENOTDIR}
+	case !info.IsDir() && target.IsDir():
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EISDIR}
+	case !target.IsDir():
+		return nil
+	}
+
+	entries, err := o.readDir(newname)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > 0 {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.ENOTEMPTY}
+	}
+
+	return nil
+}
+
+// Symlink implements FS.
+func (o *Overlay) Symlink(oldname, newname string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := o.stat("symlink", newname, false)
+	if err == nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+
+	err = o.copyUpDir(path.Dir(newname))
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Symlink(oldname, newname)
+	return err
+}
+
+// Chmod implements FS.
+func (o *Overlay) Chmod(name string, mode fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := o.stat("chmod", name, false)
+	if err != nil {
+		return err
+	}
+
+	err = o.copyUp(name)
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Chmod(name, mode)
+	return err
+}
+
+// Chtimes implements FS.
+func (o *Overlay) Chtimes(name string, atime, mtime time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := o.stat("chtimes", name, false)
+	if err != nil {
+		return err
+	}
+
+	err = o.copyUp(name)
+	if err != nil {
+		return err
+	}
+
+	err = o.upper.Chtimes(name, atime, mtime)
+	return err
+}
+
+// readOnlyFile is a File of the lower layer of an Overlay.
+type readOnlyFile struct {
+	fs.File
+
+	// name is the name the file was opened with.
+	name string
+}
+
+// Write implements io.Writer.
+func (f *readOnlyFile) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.name, Err: syscall.EBADF}
+}
+
+// Seek implements io.Seeker.
+func (f *readOnlyFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.ErrUnsupported}
+	}
+
+	n, err := seeker.Seek(offset, whence)
+	return n, err
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *readOnlyFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+// overlayDir is an open directory of an Overlay, whose entries are merged from
+// both layers.
+type overlayDir struct {
+	// overlay is the overlay the directory belongs to.
+	overlay *Overlay
+
+	// name is the name the directory was opened with.
+	name string
+
+	// info is the information about the directory.
+	info fs.FileInfo
+
+	// entries are the remaining entries, loaded on the first call to ReadDir.
+	entries []fs.DirEntry
+
+	// loaded is true once entries were loaded.
+	loaded bool
+}
+
+// Stat implements fs.File.
+func (d *overlayDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// Read implements fs.File.
+func (d *overlayDir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: syscall.EISDIR}
+}
+
+// Write implements io.Writer.
+func (d *overlayDir) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: d.name, Err: syscall.EBADF}
+}
+
+// Seek implements io.Seeker. Only rewinding to the start is supported.
+func (d *overlayDir) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, &fs.PathError{Op: "seek", Path: d.name, Err: fs.ErrInvalid}
+	}
+
+	d.entries = nil
+	d.loaded = false
+
+	return 0, nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.overlay.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+
+		d.entries = entries
+		d.loaded = true
+	}
+
+	if len(d.entries) == 0 && n > 0 {
+		return nil, io.EOF
+	}
+
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
+
+// Close implements fs.File.
+func (d *overlayDir) Close() error {
+	return nil
+}
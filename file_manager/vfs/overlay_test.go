@@ -0,0 +1,240 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// newTestOverlay creates an overlay on top of a small lower layer.
+func newTestOverlay(t *testing.T) (*Overlay, fstest.MapFS) {
+	t.Helper()
+
+	lower := fstest.MapFS{
+		"etc/app.conf":    {Data: []byte("lower"), Mode: 0o644},
+		"etc/hosts":       {Data: []byte("127.0.0.1"), Mode: 0o644},
+		"var/log/old.log": {Data: []byte("log"), Mode: 0o600},
+		"empty":           {Mode: fs.ModeDir | 0o755},
+	}
+
+	o, err := NewOverlay(lower, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return o, lower
+}
+
+// names returns the names of the entries of the directory.
+func names(t *testing.T, fsys fs.FS, dir string) []string {
+	t.Helper()
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		t.Fatalf("readdir %s: %v", dir, err)
+	}
+
+	result := make([]string, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.Name())
+	}
+
+	return result
+}
+
+// TestOverlayFS tests the merged view against the fs.FS contract.
+func TestOverlayFS(t *testing.T) {
+	o, _ := newTestOverlay(t)
+
+	err := WriteFile(o, "etc/new.conf", []byte("upper"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = o.Remove("etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = fstest.TestFS(o, "etc/app.conf", "etc/new.conf", "var/log/old.log", "empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOverlayCopyUp tests that writing to a lower file copies it up and
+// leaves the lower layer untouched.
+func TestOverlayCopyUp(t *testing.T) {
+	o, lower := newTestOverlay(t)
+
+	err := WriteFile(o, "etc/app.conf", []byte("upper"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := fs.ReadFile(o, "etc/app.conf")
+	if err != nil || string(data) != "upper" {
+		t.Errorf("expected %q, got %q (%v)", "upper", data, err)
+	}
+
+	if got := string(lower["etc/app.conf"].Data); got != "lower" {
+		t.Errorf("lower layer was modified: %q", got)
+	}
+
+	err = o.Chmod("var/log/old.log", 0o640)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := o.Stat("var/log/old.log")
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("expected mode 0640 after copy-up, got %v (%v)", info, err)
+	}
+
+	data, err = fs.ReadFile(o, "var/log/old.log")
+	if err != nil || string(data) != "log" {
+		t.Errorf("expected content to be copied up, got %q (%v)", data, err)
+	}
+}
+
+// TestOverlayWhiteout tests that removed lower entries are hidden.
+func TestOverlayWhiteout(t *testing.T) {
+	o, lower := newTestOverlay(t)
+
+	err := o.Remove("etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = o.Stat("etc/hosts")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	if got := names(t, o, "etc"); !slices.Equal(got, []string{"app.conf"}) {
+		t.Errorf("expected [app.conf], got %v", got)
+	}
+
+	_, ok := lower["etc/hosts"]
+	if !ok {
+		t.Errorf("lower entry was removed")
+	}
+
+	err = WriteFile(o, "etc/hosts", []byte("new"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := fs.ReadFile(o, "etc/hosts")
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected recreated file, got %q (%v)", data, err)
+	}
+}
+
+// TestOverlayRemove tests Remove and RemoveAll on lower directories.
+func TestOverlayRemove(t *testing.T) {
+	o, _ := newTestOverlay(t)
+
+	err := o.Remove("var/log")
+	if !errors.Is(err, syscall.ENOTEMPTY) {
+		t.Errorf("expected syscall.ENOTEMPTY, got %v", err)
+	}
+
+	err = o.RemoveAll("var")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"var", "var/log", "var/log/old.log"} {
+		_, err = o.Stat(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected fs.ErrNotExist, got %v", name, err)
+		}
+	}
+
+	err = o.MkdirAll("var/log", 0o755)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := names(t, o, "var/log"); len(got) != 0 {
+		t.Errorf("expected recreated directory to be empty, got %v", got)
+	}
+}
+
+// TestOverlayRename tests renames across and onto layers.
+func TestOverlayRename(t *testing.T) {
+	o, _ := newTestOverlay(t)
+
+	err := o.Rename("etc/app.conf", "etc/app.conf.bak")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := names(t, o, "etc"); !slices.Equal(got, []string{"app.conf.bak", "hosts"}) {
+		t.Errorf("expected [app.conf.bak hosts], got %v", got)
+	}
+
+	err = o.Rename("var", "var2")
+	if !errors.Is(err, syscall.EXDEV) {
+		t.Errorf("expected syscall.EXDEV for a lower directory, got %v", err)
+	}
+
+	err = o.Mkdir("fresh", 0o755)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = o.Rename("fresh", "etc")
+	if !errors.Is(err, syscall.ENOTEMPTY) {
+		t.Errorf("expected syscall.ENOTEMPTY onto a non-empty lower directory, got %v", err)
+	}
+
+	err = o.Rename("etc/hosts", "empty")
+	if !errors.Is(err, syscall.EISDIR) {
+		t.Errorf("expected syscall.EISDIR, got %v", err)
+	}
+
+	err = o.Remove("var/log/old.log")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = WriteFile(o, "fresh/a", []byte("a"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = o.Rename("fresh", "var/log")
+	if err != nil {
+		t.Fatalf("unexpected error onto an empty lower directory: %v", err)
+	}
+
+	if got := names(t, o, "var/log"); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected [a], got %v", got)
+	}
+
+	_, err = o.Stat("fresh")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	err = o.Rename("var/log/a", "etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := fs.ReadFile(o, "etc/hosts")
+	if err != nil || string(data) != "a" {
+		t.Errorf("expected the renamed file to cover the lower one, got %q (%v)", data, err)
+	}
+
+	_, err = o.OpenFile("etc/hosts", os.O_RDONLY, 0)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+)
+
+// File is an open file of a FS.
+//
+// *os.File implements this interface.
+type File interface {
+	fs.File
+	io.Writer
+	io.Seeker
+
+	// ReadDir reads the contents of the directory and returns a slice of up to n
+	// entries, as described in fs.ReadDirFile.
+	//
+	// Parameters:
+	//   - n: The maximum number of entries to return. If n <= 0, all entries are returned.
+	//
+	// Returns:
+	//   - []fs.DirEntry: The entries read.
+	//   - error: An error if the entries could not be read.
+	ReadDir(n int) ([]fs.DirEntry, error)
+}
+
+// FS is a writable file system. It extends fs.FS with the operations needed to
+// modify a tree of files so that code written against it can be run on disk,
+// in memory or on top of a read-only tree.
+//
+// Unless stated otherwise by the implementation, names follow the rules of
+// fs.ValidPath and failures are reported as *fs.PathError or *os.LinkError
+// values wrapping the fs.Err* or syscall errors, just like the os package.
+type FS interface {
+	fs.FS
+	fs.StatFS
+	fs.ReadDirFS
+
+	// Lstat is like Stat but does not follow a trailing symbolic link.
+	//
+	// Parameters:
+	//   - name: The name of the entry.
+	//
+	// Returns:
+	//   - fs.FileInfo: The information about the entry.
+	//   - error: An error if the entry cannot be described.
+	Lstat(name string) (fs.FileInfo, error)
+
+	// ReadLink returns the target of the named symbolic link.
+	//
+	// Parameters:
+	//   - name: The name of the symbolic link.
+	//
+	// Returns:
+	//   - string: The target of the link.
+	//   - error: An error if the entry is not a symbolic link or cannot be read.
+	ReadLink(name string) (string, error)
+
+	// OpenFile opens the named file with the given flags (os.O_RDONLY, os.O_CREATE,
+	// etc.) and, if it is created, the given permissions.
+	//
+	// Parameters:
+	//   - name: The name of the file.
+	//   - flag: The flags to open the file with.
+	//   - perm: The permissions of the file, if created.
+	//
+	// Returns:
+	//   - File: The opened file.
+	//   - error: An error if the file cannot be opened.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+
+	// Mkdir creates the named directory. The parent directory must exist.
+	//
+	// Parameters:
+	//   - name: The name of the directory.
+	//   - perm: The permissions of the directory.
+	//
+	// Returns:
+	//   - error: An error if the directory cannot be created.
+	Mkdir(name string, perm fs.FileMode) error
+
+	// MkdirAll creates the named directory along with any missing parent. It
+	// does nothing if the directory already exists.
+	//
+	// Parameters:
+	//   - name: The name of the directory.
+	//   - perm: The permissions of every directory created.
+	//
+	// Returns:
+	//   - error: An error if a directory cannot be created.
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Remove removes the named file or empty directory.
+	//
+	// Parameters:
+	//   - name: The name of the entry to remove.
+	//
+	// Returns:
+	//   - error: An error if the entry cannot be removed.
+	Remove(name string) error
+
+	// RemoveAll removes the named entry and everything it contains. It does
+	// nothing if the entry does not exist.
+	//
+	// Parameters:
+	//   - name: The name of the entry to remove.
+	//
+	// Returns:
+	//   - error: An error if the entry cannot be removed.
+	RemoveAll(name string) error
+
+	// Rename moves oldname to newname, replacing newname if it already exists
+	// and is not a directory.
+	//
+	// Parameters:
+	//   - oldname: The current name of the entry.
+	//   - newname: The new name of the entry.
+	//
+	// Returns:
+	//   - error: An error if the entry cannot be moved.
+	Rename(oldname, newname string) error
+
+	// Symlink creates newname as a symbolic link to oldname.
+	//
+	// Parameters:
+	//   - oldname: The target of the link. It is stored as is.
+	//   - newname: The name of the link.
+	//
+	// Returns:
+	//   - error: An error if the link cannot be created.
+	Symlink(oldname, newname string) error
+
+	// Chmod changes the permissions of the named entry, following symbolic links.
+	//
+	// Parameters:
+	//   - name: The name of the entry.
+	//   - mode: The new permissions. Only the permission bits are used.
+	//
+	// Returns:
+	//   - error: An error if the permissions cannot be changed.
+	Chmod(name string, mode fs.FileMode) error
+
+	// Chtimes changes the access and modification times of the named entry,
+	// following symbolic links.
+	//
+	// Parameters:
+	//   - name: The name of the entry.
+	//   - atime: The new access time. Implementations may ignore it.
+	//   - mtime: The new modification time.
+	//
+	// Returns:
+	//   - error: An error if the times cannot be changed.
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// WriteFile writes data to the named file, creating it with the given
+// permissions if needed and truncating it otherwise.
+//
+// Parameters:
+//   - fsys: The file system to write to. Must not be nil.
+//   - name: The name of the file.
+//   - data: The data to write.
+//   - perm: The permissions of the file, if created.
+//
+// Returns:
+//   - error: An error if the file cannot be written.
+//
+// Errors:
+//   - *errors.ErrBadParam: If fsys is nil.
+//   - any other error: Implementation-specific error.
+func WriteFile(fsys FS, name string, data []byte, perm fs.FileMode) error {
+	if fsys == nil {
+		return gers.NewErrNilParam("fsys")
+	}
+
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+
+	err2 := f.Close()
+	if err == nil {
+		err = err2
+	}
+
+	return err
+}
@@ -0,0 +1,581 @@
+package file_manager
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/internal"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+const (
+	// DefaultWorkspaceTTL is the age after which the workspace of a dead
+	// process is removed when WorkspaceOptions.TTL is zero.
+	DefaultWorkspaceTTL time.Duration = 24 * time.Hour
+)
+
+// WorkspaceOptions are the options of a workspace.
+type WorkspaceOptions struct {
+	// Parent is the directory the workspace is created in. If empty,
+	// os.TempDir() is used.
+	Parent string
+
+	// TTL is the age after which the orphaned workspaces of the same prefix are
+	// removed when a new one is created. A workspace is orphaned when the
+	// process that created it is gone, which cannot be told on every platform.
+	// If zero, DefaultWorkspaceTTL is used; if negative, orphans are kept.
+	TTL time.Duration
+
+	// KeepOnFailure keeps the workspace on Close once Fail has been called, so
+	// that it can be inspected. It is still removed as an orphan later on.
+	KeepOnFailure bool
+
+	// CleanupOnSignal closes the workspace, as Close does, when the process
+	// receives an interrupt or a termination signal, after which the signal is
+	// delivered again. Unless the application handles that signal too, the
+	// process is then terminated.
+	CleanupOnSignal bool
+}
+
+// Workspace is a temporary directory that is removed once done with. Every
+// path handed to its methods is relative to the workspace and cannot escape
+// it.
+type Workspace struct {
+	// root confines the paths to the workspace.
+	root *Root
+
+	// keep_on_failure is whether to keep the workspace once failed.
+	keep_on_failure bool
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// failed is whether Fail has been called.
+	failed bool
+
+	// closed is whether the workspace has been closed.
+	closed bool
+
+	// kept is whether the workspace was kept on Close.
+	kept bool
+}
+
+// signalState holds the workspaces to remove on a signal.
+var signalState struct {
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// workspaces are the workspaces to remove.
+	workspaces map[*Workspace]struct{}
+
+	// ch receives the signals while workspaces is not empty.
+	ch chan os.Signal
+}
+
+// watchSignals closes the registered workspaces when a signal is received,
+// then delivers the signal again.
+//
+// Parameters:
+//   - ch: The channel the signals are received on.
+func watchSignals(ch chan os.Signal) {
+	sig, ok := <-ch
+	if !ok {
+		return
+	}
+
+	signalState.mu.Lock()
+
+	workspaces := signalState.workspaces
+
+	signal.Stop(ch)
+
+	signalState.workspaces = nil
+	signalState.ch = nil
+
+	signalState.mu.Unlock()
+
+	// The workspaces are closed, not just removed, as the signal may also be
+	// handled by the application, in which case the process goes on. They
+	// then report being closed, and the ones created later are watched again.
+	// Close takes the lock of the workspace before the one of signalState,
+	// which must thus be released by now.
+	for ws := range workspaces {
+		_ = ws.Close()
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// registerSignal removes the workspace on an interrupt or a termination signal.
+//
+// Parameters:
+//   - ws: The workspace to remove.
+func registerSignal(ws *Workspace) {
+	signalState.mu.Lock()
+	defer signalState.mu.Unlock()
+
+	if signalState.workspaces == nil {
+		signalState.workspaces = make(map[*Workspace]struct{})
+	}
+
+	signalState.workspaces[ws] = struct{}{}
+
+	if signalState.ch != nil {
+		return
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+
+	signalState.ch = ch
+
+	go watchSignals(ch)
+}
+
+// unregisterSignal stops removing the workspace on a signal.
+//
+// Parameters:
+//   - ws: The workspace.
+func unregisterSignal(ws *Workspace) {
+	signalState.mu.Lock()
+	defer signalState.mu.Unlock()
+
+	_, ok := signalState.workspaces[ws]
+	if !ok {
+		return
+	}
+
+	delete(signalState.workspaces, ws)
+
+	if len(signalState.workspaces) > 0 || signalState.ch == nil {
+		return
+	}
+
+	signal.Stop(signalState.ch)
+	close(signalState.ch)
+
+	signalState.ch = nil
+}
+
+// workspacePID returns the PID encoded in the name of a workspace.
+//
+// Parameters:
+//   - name: The base name of the directory.
+//   - prefix: The prefix of the workspaces.
+//
+// Returns:
+//   - int: The PID of the creator.
+//   - bool: False if the name is not the one of a workspace of the prefix.
+func workspacePID(name, prefix string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, prefix+"-")
+	if !ok {
+		return 0, false
+	}
+
+	digits, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(digits)
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// removeOrphans removes the workspaces of the prefix whose creator is gone and
+// that have not been modified for longer than the TTL. Errors are ignored, as
+// another process may be doing the same.
+//
+// Parameters:
+//   - parent: The directory the workspaces are in.
+//   - prefix: The prefix of the workspaces.
+//   - ttl: The minimum age of an orphan.
+func removeOrphans(parent, prefix string, ttl time.Duration) {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pid, ok := workspacePID(entry.Name(), prefix)
+		if !ok || pid == os.Getpid() || internal.ProcessAlive(pid) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < ttl {
+			continue
+		}
+
+		_ = os.RemoveAll(filepath.Join(parent, entry.Name()))
+	}
+}
+
+// NewWorkspace creates a new, empty workspace. Its directory is named after
+// the prefix and the PID of the process, which lets later calls with the same
+// prefix remove it if it is orphaned.
+//
+// Parameters:
+//   - prefix: The prefix of the name of the directory. Must not contain a path separator.
+//   - opts: The options of the workspace. If nil, the defaults are used.
+//
+// Returns:
+//   - *Workspace: The new workspace.
+//   - error: An error if the workspace could not be created.
+//
+// Errors:
+//   - *errors.ErrBadParam: If the prefix is empty or contains a path separator.
+//   - any other error: If the directory could not be created.
+func NewWorkspace(prefix string, opts *WorkspaceOptions) (*Workspace, error) {
+	if prefix == "" || strings.ContainsAny(prefix, `/\`) {
+		return nil, gers.NewErrBadParam("prefix", "must be a non-empty base name")
+	}
+
+	var o WorkspaceOptions
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Parent == "" {
+		o.Parent = os.TempDir()
+	}
+
+	if o.TTL == 0 {
+		o.TTL = DefaultWorkspaceTTL
+	}
+
+	if o.TTL > 0 {
+		removeOrphans(o.Parent, prefix, o.TTL)
+	}
+
+	dir, err := os.MkdirTemp(o.Parent, prefix+"-"+strconv.Itoa(os.Getpid())+"-*")
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := OpenRoot(dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	ws := &Workspace{
+		root:            root,
+		keep_on_failure: o.KeepOnFailure,
+	}
+
+	if o.CleanupOnSignal {
+		registerSignal(ws)
+	}
+
+	return ws, nil
+}
+
+// Dir returns the absolute path of the workspace.
+//
+// Returns:
+//   - string: The path of the workspace.
+func (ws *Workspace) Dir() string {
+	return ws.root.Name()
+}
+
+// FS returns the workspace as a file system, confined to its directory.
+//
+// Returns:
+//   - *Root: The file system of the workspace.
+func (ws *Workspace) FS() *Root {
+	return ws.root
+}
+
+// Path returns the native path of an entry of the workspace.
+//
+// Parameters:
+//   - name: The relative path of the entry.
+//
+// Returns:
+//   - string: The native path of the entry.
+//   - error: An error if the path leaves the workspace.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the workspace.
+//   - any other error: If an element of the path could not be inspected.
+func (ws *Workspace) Path(name string) (string, error) {
+	loc, err := ws.root.Resolve(name)
+	return loc, err
+}
+
+// Mkdir creates a directory in the workspace, along with any missing parent.
+//
+// Parameters:
+//   - name: The relative path of the directory.
+//   - perm: The permission bits of the new directories.
+//
+// Returns:
+//   - error: An error if the directory could not be created.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the workspace.
+//   - any other error: If the directory could not be created.
+func (ws *Workspace) Mkdir(name string, perm fs.FileMode) error {
+	err := ws.root.MkdirAll(name, perm)
+	return err
+}
+
+// WriteFile writes a file in the workspace, creating its parent directories
+// if needed.
+//
+// Parameters:
+//   - name: The relative path of the file.
+//   - data: The content of the file.
+//   - perm: The permission bits of the file if it is created.
+//
+// Returns:
+//   - error: An error if the file could not be written.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the workspace.
+//   - any other error: If the file could not be written.
+func (ws *Workspace) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	dir := path.Dir(filepath.ToSlash(name))
+
+	if dir != "." {
+		err := ws.root.MkdirAll(dir, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := vfs.WriteFile(ws.root, name, data, perm)
+	return err
+}
+
+// CopyIn copies a native file or directory tree into the workspace. Modes are
+// preserved and symbolic links are copied as links.
+//
+// Parameters:
+//   - src: The native path of the file or directory to copy.
+//   - name: The relative path of the copy inside the workspace.
+//
+// Returns:
+//   - error: An error if the copy could not be made.
+//
+// Errors:
+//   - *ErrEscape: If the path leaves the workspace.
+//   - any other error: If the source could not be read or the copy written.
+func (ws *Workspace) CopyIn(src, name string) error {
+	name = filepath.ToSlash(name)
+
+	parent := path.Dir(name)
+
+	if parent != "." {
+		err := ws.root.MkdirAll(parent, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	type dirMode struct {
+		name string
+		mode fs.FileMode
+	}
+
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(loc string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, loc)
+		if err != nil {
+			return err
+		}
+
+		dst := path.Join(name, filepath.ToSlash(rel))
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		mode := info.Mode()
+
+		switch {
+		case mode.IsDir():
+			err := ws.root.Mkdir(dst, 0o700)
+			if err != nil && !errors.Is(err, fs.ErrExist) {
+				return err
+			}
+
+			dirs = append(dirs, dirMode{name: dst, mode: mode.Perm()})
+		case mode&fs.ModeSymlink != 0:
+			target, err := os.Readlink(loc)
+			if err != nil {
+				return err
+			}
+
+			err = ws.root.Symlink(target, dst)
+			if err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			err := ws.copyFile(loc, dst, mode.Perm())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The modes are applied deepest first, so that read-only directories can
+	// be filled beforehand.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err := ws.root.Chmod(dirs[i].name, dirs[i].mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies a native file into the workspace.
+//
+// Parameters:
+//   - src: The native path of the file.
+//   - dst: The relative path of the copy.
+//   - perm: The permission bits of the copy.
+//
+// Returns:
+//   - error: An error if the copy could not be made.
+func (ws *Workspace) copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ws.root.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+
+	err = errors.Join(err, out.Close())
+	if err != nil {
+		return err
+	}
+
+	err = ws.root.Chmod(dst, perm)
+	return err
+}
+
+// Command creates a command, as NewCommand does, that runs in the workspace.
+//
+// Parameters:
+//   - name: The name of the command.
+//   - args: The arguments to the command.
+//
+// Returns:
+//   - *exec.Cmd: The command. Never returns nil.
+//
+// Panics:
+//   - If the operating system is not supported. (i.e. Windows and Linux)
+func (ws *Workspace) Command(name string, args ...string) *exec.Cmd {
+	cmd := NewCommand(name, args...)
+	cmd.Dir = ws.root.Name()
+
+	return cmd
+}
+
+// Fail marks the workspace as failed. If the workspace was created with
+// KeepOnFailure, Close then keeps it for inspection.
+func (ws *Workspace) Fail() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.failed = true
+}
+
+// Kept checks whether Close kept the workspace because it failed.
+//
+// Returns:
+//   - bool: True if the workspace was kept, false otherwise.
+func (ws *Workspace) Kept() bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.kept
+}
+
+// Close removes the workspace, unless it failed and was created with
+// KeepOnFailure. Calling Close more than once has no effect. It is meant to
+// be deferred right after NewWorkspace, which also covers panics.
+//
+// Returns:
+//   - error: An error if the workspace could not be removed.
+func (ws *Workspace) Close() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.closed {
+		return nil
+	}
+
+	ws.closed = true
+
+	unregisterSignal(ws)
+
+	if ws.failed && ws.keep_on_failure {
+		ws.kept = true
+		return nil
+	}
+
+	dir := ws.root.Name()
+
+	// Read-only directories would prevent their content from being removed.
+	_ = filepath.WalkDir(dir, func(loc string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(loc, 0o700)
+		}
+
+		return nil
+	})
+
+	err := os.RemoveAll(dir)
+	return err
+}
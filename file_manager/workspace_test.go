@@ -0,0 +1,328 @@
+//go:build unix
+
+package file_manager
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// workspaceHelperEnv is the environment variable that turns the test binary
+// into a helper process holding a workspace.
+const workspaceHelperEnv string = "MYGO_WORKSPACE_HELPER"
+
+// TestWorkspaceHelper is not a real test: when run as a helper process, it
+// creates a workspace cleaned up on signals in the given parent, reports its
+// path on stdout and waits until stdin is closed.
+func TestWorkspaceHelper(t *testing.T) {
+	parent := os.Getenv(workspaceHelperEnv)
+	if parent == "" {
+		t.Skip("only run as a helper process")
+	}
+
+	ws, err := NewWorkspace("sig", &WorkspaceOptions{Parent: parent, CleanupOnSignal: true})
+	if err != nil {
+		os.Exit(2)
+	}
+
+	_, _ = os.Stdout.WriteString(ws.Dir() + "\n")
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+
+	os.Exit(0)
+}
+
+// exists checks whether the given native path exists.
+func exists(t *testing.T, loc string) bool {
+	t.Helper()
+
+	_, err := os.Stat(loc)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	return true
+}
+
+// TestWorkspaceSignal tests that the workspace is removed when the process is
+// terminated, and that the signal still terminates it.
+func TestWorkspaceSignal(t *testing.T) {
+	parent := t.TempDir()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWorkspaceHelper$")
+	cmd.Env = append(os.Environ(), workspaceHelperEnv+"="+parent)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		_ = cmd.Process.Kill()
+		t.Fatalf("the helper did not create its workspace: %v", err)
+	}
+
+	dir := strings.TrimSuffix(line, "\n")
+
+	if !exists(t, dir) {
+		t.Fatalf("expected %q to exist", dir)
+	}
+
+	err = cmd.Process.Signal(syscall.SIGTERM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Wait()
+
+	var exit_err *exec.ExitError
+
+	if !errors.As(err, &exit_err) {
+		t.Fatalf("expected the helper to be terminated, got %v", err)
+	}
+
+	status, ok := exit_err.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("expected the helper to be terminated by SIGTERM, got %v", err)
+	}
+
+	if exists(t, dir) {
+		t.Errorf("expected %q to be removed", dir)
+	}
+}
+
+// TestWorkspaceSignalHandled tests that, when the application handles the
+// signal as well and the process goes on, the removed workspaces are closed
+// and the next ones are watched again.
+func TestWorkspaceSignalHandled(t *testing.T) {
+	app := make(chan os.Signal, 2)
+	signal.Notify(app, syscall.SIGTERM)
+	defer signal.Stop(app)
+
+	opts := &WorkspaceOptions{Parent: t.TempDir(), CleanupOnSignal: true}
+
+	ws, err := NewWorkspace("handled", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The application receives the signal, then again once re-raised.
+	for range 2 {
+		select {
+		case <-app:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the signal was not delivered again")
+		}
+	}
+
+	ws.mu.Lock()
+	closed := ws.closed
+	ws.mu.Unlock()
+
+	if !closed {
+		t.Error("expected the workspace to be closed")
+	}
+
+	if exists(t, ws.Dir()) {
+		t.Errorf("expected %q to be removed", ws.Dir())
+	}
+
+	err = ws.Close()
+	if err != nil {
+		t.Errorf("Close() after the signal = %v; want nil", err)
+	}
+
+	next, err := NewWorkspace("handled", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+
+	signalState.mu.Lock()
+	_, watched := signalState.workspaces[next]
+	signalState.mu.Unlock()
+
+	if !watched {
+		t.Error("expected the next workspace to be watched")
+	}
+}
+
+// TestWorkspaceUnregister tests that the signals stop being watched once the
+// last workspace is closed, and are watched again for the next one.
+func TestWorkspaceUnregister(t *testing.T) {
+	opts := &WorkspaceOptions{Parent: t.TempDir(), CleanupOnSignal: true}
+
+	for range 2 {
+		ws, err := NewWorkspace("unreg", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signalState.mu.Lock()
+		watched := signalState.ch != nil
+		signalState.mu.Unlock()
+
+		if !watched {
+			t.Errorf("expected the signals to be watched")
+		}
+
+		err = ws.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signalState.mu.Lock()
+		watched = signalState.ch != nil
+		signalState.mu.Unlock()
+
+		if watched {
+			t.Errorf("expected the signals to no longer be watched")
+		}
+	}
+}
+
+// makeOrphan creates the directory of a workspace of the prefix, as if made by
+// the given process, last modified at the given time.
+func makeOrphan(t *testing.T, parent, prefix string, pid int, mtime time.Time) string {
+	t.Helper()
+
+	dir := filepath.Join(parent, prefix+"-"+strconv.Itoa(pid)+"-"+strconv.FormatInt(mtime.UnixNano(), 10))
+
+	err := os.Mkdir(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chtimes(dir, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// TestWorkspaceOrphans tests that only the old workspaces of dead processes,
+// with the same prefix, are removed.
+func TestWorkspaceOrphans(t *testing.T) {
+	parent := t.TempDir()
+
+	old := time.Now().Add(-2 * time.Hour)
+	dead := deadPID(t)
+
+	orphan := makeOrphan(t, parent, "orph", dead, old)
+	recent := makeOrphan(t, parent, "orph", dead, time.Now())
+	alive := makeOrphan(t, parent, "orph", os.Getppid(), old)
+	other := makeOrphan(t, parent, "other", dead, old)
+
+	ws, err := NewWorkspace("orph", &WorkspaceOptions{Parent: parent, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if exists(t, orphan) {
+		t.Errorf("expected the orphan to be removed")
+	}
+
+	for name, dir := range map[string]string{"recent": recent, "alive": alive, "other": other} {
+		if !exists(t, dir) {
+			t.Errorf("expected the %s workspace to be kept", name)
+		}
+	}
+}
+
+// TestWorkspaceOrphansKept tests that a negative TTL keeps the orphans.
+func TestWorkspaceOrphansKept(t *testing.T) {
+	parent := t.TempDir()
+
+	orphan := makeOrphan(t, parent, "orph", deadPID(t), time.Now().Add(-1000*time.Hour))
+
+	ws, err := NewWorkspace("orph", &WorkspaceOptions{Parent: parent, TTL: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if !exists(t, orphan) {
+		t.Errorf("expected the orphan to be kept")
+	}
+}
+
+// TestWorkspaceKeepOnFailure tests that a failed workspace is only kept when
+// requested.
+func TestWorkspaceKeepOnFailure(t *testing.T) {
+	tests := map[string]struct {
+		keep, fail, kept bool
+	}{
+		"success":      {keep: true, fail: false, kept: false},
+		"failure":      {keep: true, fail: true, kept: true},
+		"failure only": {keep: false, fail: true, kept: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ws, err := NewWorkspace("keep", &WorkspaceOptions{Parent: t.TempDir(), KeepOnFailure: tt.keep})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ws.WriteFile("a/b.txt", []byte("b"), 0o444)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = os.Chmod(filepath.Join(ws.Dir(), "a"), 0o555)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.fail {
+				ws.Fail()
+			}
+
+			err = ws.Close()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ws.Kept() != tt.kept {
+				t.Errorf("expected Kept to be %t", tt.kept)
+			}
+
+			if exists(t, ws.Dir()) != tt.kept {
+				t.Errorf("expected the directory to be kept: %t", tt.kept)
+			}
+
+			if tt.kept {
+				_ = os.Chmod(filepath.Join(ws.Dir(), "a"), 0o755)
+			}
+		})
+	}
+}
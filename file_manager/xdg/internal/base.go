@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Base holds the base directories of the XDG Base Directory Specification.
+type Base struct {
+	// ConfigHome is $XDG_CONFIG_HOME.
+	ConfigHome string
+
+	// DataHome is $XDG_DATA_HOME.
+	DataHome string
+
+	// CacheHome is $XDG_CACHE_HOME.
+	CacheHome string
+
+	// StateHome is $XDG_STATE_HOME.
+	StateHome string
+
+	// RuntimeDir is $XDG_RUNTIME_DIR, or an empty string if it is not set.
+	RuntimeDir string
+
+	// ConfigDirs are the directories of $XDG_CONFIG_DIRS, by decreasing precedence.
+	ConfigDirs []string
+
+	// DataDirs are the directories of $XDG_DATA_DIRS, by decreasing precedence.
+	DataDirs []string
+}
+
+// single returns the value of a variable holding a single directory, or the
+// fallback if it is unset or not absolute, as the specification requires.
+//
+// Parameters:
+//   - getenv: The function to read the variable with.
+//   - key: The name of the variable.
+//   - fallback: The default value.
+//
+// Returns:
+//   - string: The directory.
+func single(getenv func(string) string, key, fallback string) string {
+	value := getenv(key)
+
+	ok := filepath.IsAbs(value)
+	if !ok {
+		return fallback
+	}
+
+	return filepath.Clean(value)
+}
+
+// list returns the absolute directories of a variable holding a list of them,
+// or the fallback if none is left.
+//
+// Parameters:
+//   - getenv: The function to read the variable with.
+//   - key: The name of the variable.
+//   - fallback: The default value.
+//
+// Returns:
+//   - []string: The directories, in order.
+func list(getenv func(string) string, key string, fallback []string) []string {
+	var dirs []string
+
+	for _, dir := range strings.Split(getenv(key), string(filepath.ListSeparator)) {
+		ok := filepath.IsAbs(dir)
+		if ok {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+
+	if len(dirs) == 0 {
+		return fallback
+	}
+
+	return dirs
+}
+
+// Resolve resolves the base directories with the fallbacks of the
+// specification.
+//
+// Parameters:
+//   - getenv: The function to read the environment with. Must not be nil.
+//   - home: The home directory of the user.
+//
+// Returns:
+//   - Base: The base directories.
+func Resolve(getenv func(string) string, home string) Base {
+	b := Base{
+		ConfigHome: single(getenv, "XDG_CONFIG_HOME", filepath.Join(home, ".config")),
+		DataHome:   single(getenv, "XDG_DATA_HOME", filepath.Join(home, ".local", "share")),
+		CacheHome:  single(getenv, "XDG_CACHE_HOME", filepath.Join(home, ".cache")),
+		StateHome:  single(getenv, "XDG_STATE_HOME", filepath.Join(home, ".local", "state")),
+		RuntimeDir: single(getenv, "XDG_RUNTIME_DIR", ""),
+		ConfigDirs: list(getenv, "XDG_CONFIG_DIRS", []string{filepath.FromSlash("/etc/xdg")}),
+		DataDirs:   list(getenv, "XDG_DATA_DIRS", []string{filepath.FromSlash("/usr/local/share"), filepath.FromSlash("/usr/share")}),
+	}
+
+	return b
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestResolve tests the Resolve function.
+func TestResolve(t *testing.T) {
+	env := map[string]string{
+		"XDG_CONFIG_HOME": "relative/is/ignored",
+		"XDG_CACHE_HOME":  "/var/cache/me/",
+		"XDG_CONFIG_DIRS": "/opt/xdg::relative:/etc/xdg",
+	}
+
+	getenv := func(key string) string {
+		return env[key]
+	}
+
+	b := Resolve(getenv, "/home/me")
+
+	if b.ConfigHome != "/home/me/.config" {
+		t.Errorf("ConfigHome = %q", b.ConfigHome)
+	}
+
+	if b.DataHome != "/home/me/.local/share" {
+		t.Errorf("DataHome = %q", b.DataHome)
+	}
+
+	if b.CacheHome != "/var/cache/me" {
+		t.Errorf("CacheHome = %q", b.CacheHome)
+	}
+
+	if b.StateHome != "/home/me/.local/state" {
+		t.Errorf("StateHome = %q", b.StateHome)
+	}
+
+	if b.RuntimeDir != "" {
+		t.Errorf("RuntimeDir = %q", b.RuntimeDir)
+	}
+
+	if want := []string{"/opt/xdg", "/etc/xdg"}; !slices.Equal(b.ConfigDirs, want) {
+		t.Errorf("ConfigDirs = %v, want %v", b.ConfigDirs, want)
+	}
+
+	if want := []string{"/usr/local/share", "/usr/share"}; !slices.Equal(b.DataDirs, want) {
+		t.Errorf("DataDirs = %v, want %v", b.DataDirs, want)
+	}
+}
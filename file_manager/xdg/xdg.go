@@ -0,0 +1,443 @@
+package xdg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	fm "github.com/PlayerR9/mygo-lib/file_manager"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+	"github.com/PlayerR9/mygo-lib/file_manager/xdg/internal"
+)
+
+// Kind is a kind of directory of the XDG Base Directory Specification.
+type Kind int
+
+const (
+	// Config is the kind of the directory of the configuration files.
+	Config Kind = iota
+
+	// Data is the kind of the directory of the data files.
+	Data
+
+	// Cache is the kind of the directory of the non-essential cached files.
+	Cache
+
+	// State is the kind of the directory of the state files, such as logs and
+	// history, that should persist but are not worth backing up.
+	State
+
+	// Runtime is the kind of the directory of the runtime files, such as
+	// sockets and named pipes.
+	Runtime
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case Config:
+		return "config"
+	case Data:
+		return "data"
+	case Cache:
+		return "cache"
+	case State:
+		return "state"
+	case Runtime:
+		return "runtime"
+	default:
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Dirs are the directories of an application, resolved from the environment
+// with the fallbacks of the XDG Base Directory Specification. The same rules
+// are applied on every platform. Every path is native and absolute.
+type Dirs struct {
+	// App is the name of the application.
+	App string
+
+	// ConfigHome is $XDG_CONFIG_HOME, which defaults to ~/.config.
+	ConfigHome string
+
+	// DataHome is $XDG_DATA_HOME, which defaults to ~/.local/share.
+	DataHome string
+
+	// CacheHome is $XDG_CACHE_HOME, which defaults to ~/.cache.
+	CacheHome string
+
+	// StateHome is $XDG_STATE_HOME, which defaults to ~/.local/state.
+	StateHome string
+
+	// RuntimeDir is $XDG_RUNTIME_DIR. The specification has no default for it,
+	// so it is empty if not set.
+	RuntimeDir string
+
+	// ConfigDirs are the directories of $XDG_CONFIG_DIRS, by decreasing
+	// precedence, which defaults to /etc/xdg.
+	ConfigDirs []string
+
+	// DataDirs are the directories of $XDG_DATA_DIRS, by decreasing precedence,
+	// which defaults to /usr/local/share and /usr/share.
+	DataDirs []string
+}
+
+// New resolves the directories of the given application from the environment
+// of the process. Values that are not absolute paths are ignored, as the
+// specification requires.
+//
+// Parameters:
+//   - app: The name of the application. Must be a single path element.
+//
+// Returns:
+//   - *Dirs: The directories of the application.
+//   - error: An error if the application name is invalid or the home directory is unknown.
+//
+// Errors:
+//   - *errors.ErrBadParam: If app is empty, "." or "..", or contains a path separator.
+//   - any other error: If the home directory of the user could not be determined.
+func New(app string) (*Dirs, error) {
+	if app == "" || app == "." || app == ".." || strings.ContainsAny(app, `/\`) {
+		return nil, gers.NewErrBadParam("app", "must be a single path element")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	b := internal.Resolve(os.Getenv, home)
+
+	d := &Dirs{
+		App:        app,
+		ConfigHome: b.ConfigHome,
+		DataHome:   b.DataHome,
+		CacheHome:  b.CacheHome,
+		StateHome:  b.StateHome,
+		RuntimeDir: b.RuntimeDir,
+		ConfigDirs: b.ConfigDirs,
+		DataDirs:   b.DataDirs,
+	}
+
+	return d, nil
+}
+
+// base returns the base directory of the given kind.
+//
+// Parameters:
+//   - kind: The kind of directory.
+//
+// Returns:
+//   - string: The base directory, or an empty string if it is not known.
+func (d Dirs) base(kind Kind) string {
+	switch kind {
+	case Config:
+		return d.ConfigHome
+	case Data:
+		return d.DataHome
+	case Cache:
+		return d.CacheHome
+	case State:
+		return d.StateHome
+	case Runtime:
+		return d.RuntimeDir
+	default:
+		return ""
+	}
+}
+
+// Home returns the directory of the application for the given kind, that is,
+// the base directory followed by the name of the application.
+//
+// Parameters:
+//   - kind: The kind of directory.
+//
+// Returns:
+//   - string: The directory of the application.
+//   - bool: False if the kind is unknown or, for Runtime, if $XDG_RUNTIME_DIR is not set.
+func (d Dirs) Home(kind Kind) (string, bool) {
+	base := d.base(kind)
+	if base == "" {
+		return "", false
+	}
+
+	return filepath.Join(base, d.App), true
+}
+
+// ConfigCandidates returns every path a configuration file of the application
+// may be found at, by decreasing precedence:
+//
+//  1. the current directory;
+//  2. $XDG_CONFIG_HOME/<app>;
+//  3. every directory of $XDG_CONFIG_DIRS, followed by <app>;
+//  4. /etc/<app>, except on Windows.
+//
+// Parameters:
+//   - name: The name of the configuration file, relative to the directories above.
+//
+// Returns:
+//   - []string: The candidate paths. The first one is relative to the current directory.
+func (d Dirs) ConfigCandidates(name string) []string {
+	name = filepath.FromSlash(name)
+
+	candidates := []string{
+		filepath.Clean(name),
+		filepath.Join(d.ConfigHome, d.App, name),
+	}
+
+	for _, dir := range d.ConfigDirs {
+		candidates = append(candidates, filepath.Join(dir, d.App, name))
+	}
+
+	if runtime.GOOS != "windows" {
+		candidates = append(candidates, filepath.Join(string(filepath.Separator)+"etc", d.App, name))
+	}
+
+	return candidates
+}
+
+// DataCandidates returns every path a data file of the application may be
+// found at, by decreasing precedence: $XDG_DATA_HOME/<app>, then every
+// directory of $XDG_DATA_DIRS followed by <app>.
+//
+// Parameters:
+//   - name: The name of the data file, relative to the directories above.
+//
+// Returns:
+//   - []string: The candidate paths.
+func (d Dirs) DataCandidates(name string) []string {
+	name = filepath.FromSlash(name)
+
+	candidates := []string{
+		filepath.Join(d.DataHome, d.App, name),
+	}
+
+	for _, dir := range d.DataDirs {
+		candidates = append(candidates, filepath.Join(dir, d.App, name))
+	}
+
+	return candidates
+}
+
+// fsName converts a native path into a name of the given file system. The
+// native file system takes it as is, while any other file system stands for
+// the root of the native one: "/home/u/.config" is named "home/u/.config" there.
+//
+// Parameters:
+//   - fsys: The file system. If nil, the native file system is assumed.
+//   - loc: The native path.
+//
+// Returns:
+//   - string: The name of the path in fsys.
+func fsName(fsys vfs.FS, loc string) string {
+	if fsys == nil {
+		return loc
+	}
+
+	native, ok := fsys.(vfs.OS)
+	if ok && native.Dir == "" {
+		return loc
+	}
+
+	loc = filepath.ToSlash(loc[len(filepath.VolumeName(loc)):])
+	loc = path.Clean(strings.TrimLeft(loc, "/"))
+
+	return loc
+}
+
+// checkName checks that the name of a file stays inside the directories it is
+// looked up in.
+//
+// Parameters:
+//   - name: The name of the file.
+//
+// Returns:
+//   - error: An error if the name is not valid.
+//
+// Errors:
+//   - *errors.ErrBadParam: If name is empty or has a ".." element.
+func checkName(name string) error {
+	if name == "" {
+		return gers.NewErrBadParam("name", "must not be empty")
+	}
+
+	for _, elem := range strings.FieldsFunc(name, isSeparator) {
+		if elem == ".." {
+			return gers.NewErrBadParam("name", "must not contain \"..\"")
+		}
+	}
+
+	return nil
+}
+
+// isSeparator checks whether the rune separates the elements of a path. Both
+// '/' and the native separator are accepted.
+//
+// Parameters:
+//   - r: The rune to check.
+//
+// Returns:
+//   - bool: True if the rune is a separator, false otherwise.
+func isSeparator(r rune) bool {
+	return r == '/' || r == filepath.Separator
+}
+
+// unreachable checks whether the error only says that a candidate cannot be
+// reached, because it is missing, a directory on its way cannot be searched or
+// an element of its parent is not a directory.
+//
+// Parameters:
+//   - err: The error to check.
+//
+// Returns:
+//   - bool: True if the candidate should be skipped, false otherwise.
+func unreachable(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.ENOTDIR)
+}
+
+// existing filters the candidates down to the regular files. The candidates
+// that cannot be reached are skipped, so that an unreadable directory in
+// XDG_CONFIG_DIRS does not hide the files that follow it.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - candidates: The candidate paths.
+//
+// Returns:
+//   - []string: The candidates that are regular files, in the same order.
+//   - error: An error if a candidate could not be inspected, for another
+//     reason than being unreachable.
+func existing(fsys vfs.FS, candidates []string) ([]string, error) {
+	var found []string
+
+	for _, loc := range candidates {
+		ok, err := fm.IsRegular(fsys, fsName(fsys, loc))
+		if err != nil && !unreachable(err) {
+			return nil, err
+		} else if ok {
+			found = append(found, loc)
+		}
+	}
+
+	return found, nil
+}
+
+// FindConfig returns the configuration files of the application that exist,
+// by decreasing precedence, so that a caller can either use the first one or
+// merge them all, the first one winning. See ConfigCandidates for the order.
+//
+// The paths returned are native. A file system other than the native one
+// stands for the root of the native file system, as described by Ensure.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - name: The name of the configuration file. Must not have a ".." element.
+//
+// Returns:
+//   - []string: The existing configuration files, possibly none.
+//   - error: An error if a candidate could not be inspected.
+//
+// Errors:
+//   - *errors.ErrBadParam: If name is empty or has a ".." element.
+//   - *errors.ErrWhile: If a candidate could not be inspected, for another
+//     reason than being missing, behind a directory that cannot be searched or
+//     behind a file.
+func (d Dirs) FindConfig(fsys vfs.FS, name string) ([]string, error) {
+	err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := existing(fsys, d.ConfigCandidates(name))
+	return found, err
+}
+
+// FindData returns the data files of the application that exist, by
+// decreasing precedence. See DataCandidates for the order.
+//
+// The paths returned are native. A file system other than the native one
+// stands for the root of the native file system, as described by Ensure.
+//
+// Parameters:
+//   - fsys: The file system to look into. If nil, the native file system is used.
+//   - name: The name of the data file. Must not have a ".." element.
+//
+// Returns:
+//   - []string: The existing data files, possibly none.
+//   - error: An error if a candidate could not be inspected.
+//
+// Errors:
+//   - *errors.ErrBadParam: If name is empty or has a ".." element.
+//   - *errors.ErrWhile: If a candidate could not be inspected, for another
+//     reason than being missing, behind a directory that cannot be searched or
+//     behind a file.
+func (d Dirs) FindData(fsys vfs.FS, name string) ([]string, error) {
+	err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := existing(fsys, d.DataCandidates(name))
+	return found, err
+}
+
+// Ensure creates the directory of the application for the given kind if it is
+// missing. The base directory is created with mode 0700 if needed, as the
+// specification requires, and so is the directory of the application.
+//
+// The directories are native paths. A file system other than the native one,
+// such as vfs.Memory, stands for the root of the native file system: for
+// instance, "/home/u/.config/app" is created as "home/u/.config/app" there.
+//
+// Parameters:
+//   - fsys: The file system to create the directory in. If nil, the native file system is used.
+//   - kind: The kind of directory.
+//
+// Returns:
+//   - string: The native path of the directory of the application.
+//   - error: An error if the directory could not be created.
+//
+// Errors:
+//   - *errors.ErrBadParam: If the kind is unknown or, for Runtime, if $XDG_RUNTIME_DIR is not set.
+//   - fs.ErrExist: If something else than a directory is in the way.
+//   - any other error: If the directory could not be created.
+func (d Dirs) Ensure(fsys vfs.FS, kind Kind) (string, error) {
+	dir, ok := d.Home(kind)
+	if !ok {
+		return "", gers.NewErrBadParam("kind", "has no directory for "+kind.String())
+	}
+
+	if fsys == nil {
+		fsys = vfs.OS{}
+	}
+
+	name := fsName(fsys, dir)
+
+	err := fsys.MkdirAll(fsName(fsys, d.base(kind)), 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	err = fm.CreateDirectory(fsys, name, 0o700, false)
+	if err == nil {
+		return dir, nil
+	} else if !errors.Is(err, fs.ErrExist) {
+		return "", err
+	}
+
+	ok, err = fm.IsDir(fsys, name)
+	if err != nil {
+		return "", err
+	} else if !ok {
+		return "", &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+	}
+
+	return dir, nil
+}
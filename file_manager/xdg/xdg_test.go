@@ -0,0 +1,266 @@
+package xdg
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/file_manager/vfs"
+)
+
+// testDirs returns the directories of the application "app" in a fake home.
+func testDirs() Dirs {
+	return Dirs{
+		App:        "app",
+		ConfigHome: filepath.FromSlash("/home/u/.config"),
+		DataHome:   filepath.FromSlash("/home/u/.local/share"),
+		CacheHome:  filepath.FromSlash("/home/u/.cache"),
+		StateHome:  filepath.FromSlash("/home/u/.local/state"),
+		ConfigDirs: []string{filepath.FromSlash("/etc/xdg")},
+		DataDirs:   []string{filepath.FromSlash("/usr/local/share"), filepath.FromSlash("/usr/share")},
+	}
+}
+
+// newMemory creates the given files in memory.
+func newMemory(t *testing.T, names ...string) *vfs.Memory {
+	t.Helper()
+
+	fsys := vfs.NewMemory()
+
+	for _, name := range names {
+		err := fsys.MkdirAll(filepath.ToSlash(filepath.Dir(name)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = vfs.WriteFile(fsys, name, []byte(name), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return fsys
+}
+
+// TestNew tests that the application name must be a single path element.
+func TestNew(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	d, err := New("app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d.ConfigHome != home {
+		t.Errorf("expected %q, got %q", home, d.ConfigHome)
+	}
+
+	for _, app := range []string{"", ".", "..", "a/b", `a\b`} {
+		_, err := New(app)
+
+		var bad *gers.ErrBadParam
+
+		if !errors.As(err, &bad) {
+			t.Errorf("New(%q): expected an *errors.ErrBadParam, got %v", app, err)
+		}
+	}
+}
+
+// TestFindConfig tests that the existing configuration files are found by
+// decreasing precedence.
+func TestFindConfig(t *testing.T) {
+	fsys := newMemory(t,
+		"app.toml",
+		"home/u/.config/app/app.toml",
+		"etc/xdg/app/app.toml",
+		"etc/app/app.toml",
+		"etc/xdg/app/other.toml",
+	)
+
+	found, err := testDirs().FindConfig(fsys, "app.toml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"app.toml",
+		filepath.FromSlash("/home/u/.config/app/app.toml"),
+		filepath.FromSlash("/etc/xdg/app/app.toml"),
+	}
+
+	if runtime.GOOS != "windows" {
+		want = append(want, "/etc/app/app.toml")
+	}
+
+	if !slices.Equal(found, want) {
+		t.Errorf("expected %q, got %q", want, found)
+	}
+}
+
+// TestFindData tests that the existing data files are found by decreasing
+// precedence.
+func TestFindData(t *testing.T) {
+	fsys := newMemory(t,
+		"usr/share/app/icons/app.png",
+		"home/u/.local/share/app/icons/app.png",
+	)
+
+	found, err := testDirs().FindData(fsys, "icons/app.png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		filepath.FromSlash("/home/u/.local/share/app/icons/app.png"),
+		filepath.FromSlash("/usr/share/app/icons/app.png"),
+	}
+
+	if !slices.Equal(found, want) {
+		t.Errorf("expected %q, got %q", want, found)
+	}
+}
+
+// TestFindUnreachable tests that candidates behind an unreadable directory or
+// behind a file are skipped instead of hiding the files that follow them, and
+// that other errors are still reported.
+func TestFindUnreachable(t *testing.T) {
+	d := testDirs()
+	d.ConfigDirs = []string{filepath.FromSlash("/etc/locked"), filepath.FromSlash("/etc/file"), filepath.FromSlash("/etc/xdg")}
+
+	fsys := newMemory(t,
+		"etc/locked/app/app.toml",
+		"etc/file",
+		"etc/xdg/app/app.toml",
+	)
+
+	err := fsys.InjectFault("etc/locked", "lstat", fs.ErrPermission)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := d.FindConfig(fsys, "app.toml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := filepath.FromSlash("/etc/xdg/app/app.toml"); !slices.Contains(found, want) || slices.Contains(found, filepath.FromSlash("/etc/locked/app/app.toml")) {
+		t.Errorf("expected %q without the locked candidate, got %q", want, found)
+	}
+
+	failure := errors.New("I/O error")
+
+	err = fsys.InjectFault("etc/xdg", "lstat", failure)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err = d.FindConfig(fsys, "app.toml")
+	if !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %q and %v", failure, found, err)
+	}
+}
+
+// TestFindInvalid tests that names leaving the directories are rejected.
+func TestFindInvalid(t *testing.T) {
+	d := testDirs()
+	fsys := newMemory(t, "home/u/.config/secret")
+
+	for _, name := range []string{"", "..", "../secret", "sub/../../secret", "./../secret"} {
+		for kind, find := range map[string]func(vfs.FS, string) ([]string, error){"config": d.FindConfig, "data": d.FindData} {
+			found, err := find(fsys, name)
+
+			var bad *gers.ErrBadParam
+
+			if !errors.As(err, &bad) {
+				t.Errorf("%s %q: expected an *errors.ErrBadParam, got %q and %v", kind, name, found, err)
+			}
+		}
+	}
+}
+
+// TestEnsure tests that the directory of the application is created with
+// mode 0700, along with its base directory.
+func TestEnsure(t *testing.T) {
+	fsys := vfs.NewMemory()
+	d := testDirs()
+
+	dir, err := d.Ensure(fsys, State)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := filepath.FromSlash("/home/u/.local/state/app"); dir != want {
+		t.Errorf("expected %q, got %q", want, dir)
+	}
+
+	for _, name := range []string{"home/u/.local/state", "home/u/.local/state/app"} {
+		info, err := fsys.Stat(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !info.IsDir() || info.Mode().Perm() != 0o700 {
+			t.Errorf("%s: expected a directory with mode 0700, got %v", name, info.Mode())
+		}
+	}
+
+	// A second call is a no-op.
+	_, err = d.Ensure(fsys, State)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = d.Ensure(fsys, Runtime)
+
+	var bad *gers.ErrBadParam
+
+	if !errors.As(err, &bad) {
+		t.Errorf("expected an *errors.ErrBadParam without $XDG_RUNTIME_DIR, got %v", err)
+	}
+
+	err = fsys.MkdirAll("home/u/.cache", 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vfs.WriteFile(fsys, "home/u/.cache/app", nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Ensure(fsys, Cache)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist, got %v", err)
+	}
+}
+
+// TestEnsureNative tests Ensure against the native file system.
+func TestEnsureNative(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "config")
+
+	d := Dirs{App: "app", ConfigHome: base}
+
+	dir, err := d.Ensure(nil, Config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := filepath.Join(base, "app"); dir != want {
+		t.Errorf("expected %q, got %q", want, dir)
+	}
+
+	info, err := vfs.OS{}.Stat(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !info.IsDir() || (runtime.GOOS != "windows" && info.Mode().Perm() != 0o700) {
+		t.Errorf("expected a directory with mode 0700, got %v", info.Mode())
+	}
+}
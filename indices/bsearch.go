@@ -0,0 +1,139 @@
+package indices
+
+import "github.com/PlayerR9/mygo-lib/indices/internal"
+
+// The binary searches below take a comparator cmp(elem, target) that returns a negative number
+// if elem sorts before target, 0 if it is equal, and a positive number if it sorts after. The
+// slice must be sorted accordingly; when built with the "indices_debug" tag, every search checks
+// it first, in linear time. When elements and target have the same type, the check compares
+// adjacent elements, so any unsorted slice is caught; otherwise, elements can only be compared to
+// the target, and the check only catches slices that are not partitioned around it, which are the
+// ones the search would get wrong.
+
+// checkSorted panics if debug is on and the slice is not sorted. If E and T are distinct types, it
+// only checks that the slice is partitioned around the target.
+//
+// Panics:
+//   - *ErrNotSorted: If debug is on and the slice is not sorted.
+func checkSorted[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) {
+	if !debug {
+		return
+	}
+
+	var idx uint
+	var ok bool
+
+	if pair, is_pair := any(cmp).(func(E, E) int); is_pair {
+		idx, ok = internal.CheckSorted(s, pair)
+	} else {
+		idx, ok = internal.CheckPartitioned(s, target, cmp)
+	}
+
+	if !ok {
+		panic(NewErrNotSorted(idx))
+	}
+}
+
+// Search returns the first index of the sorted slice whose element equals the target, or an empty
+// index if there is none.
+//
+// Parameters:
+//   - s: The sorted slice to search.
+//   - target: The target to search for.
+//   - cmp: The comparator.
+//
+// Returns:
+//   - Index: The first index of target, or an empty index if it does not occur or cmp is nil.
+//
+// Panics:
+//   - *ErrNotSorted: In debug mode, if the slice is not sorted.
+func Search[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) Index {
+	if cmp == nil {
+		return None()
+	}
+
+	checkSorted(s, target, cmp)
+
+	idx := internal.LowerBound(s, target, cmp)
+	if idx == uint(len(s)) || cmp(s[idx], target) != 0 {
+		return None()
+	}
+
+	return Index(idx)
+}
+
+// LowerBound returns the first index of the sorted slice whose element does not sort before the
+// target; that is, the first position at which target can be inserted keeping the slice sorted.
+//
+// Parameters:
+//   - s: The sorted slice to search.
+//   - target: The target to search for.
+//   - cmp: The comparator.
+//
+// Returns:
+//   - Index: The insertion point, between 0 and len(s), or an empty index if cmp is nil.
+//
+// Panics:
+//   - *ErrNotSorted: In debug mode, if the slice is not sorted.
+func LowerBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) Index {
+	if cmp == nil {
+		return None()
+	}
+
+	checkSorted(s, target, cmp)
+
+	idx := internal.LowerBound(s, target, cmp)
+
+	return Index(idx)
+}
+
+// UpperBound returns the first index of the sorted slice whose element sorts after the target;
+// that is, the last position at which target can be inserted keeping the slice sorted.
+//
+// Parameters:
+//   - s: The sorted slice to search.
+//   - target: The target to search for.
+//   - cmp: The comparator.
+//
+// Returns:
+//   - Index: The insertion point, between 0 and len(s), or an empty index if cmp is nil.
+//
+// Panics:
+//   - *ErrNotSorted: In debug mode, if the slice is not sorted.
+func UpperBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) Index {
+	if cmp == nil {
+		return None()
+	}
+
+	checkSorted(s, target, cmp)
+
+	idx := internal.UpperBound(s, target, cmp)
+
+	return Index(idx)
+}
+
+// EqualRange returns the span of the sorted slice whose elements equal the target. If there are
+// none, the span is empty and starts at the insertion point of target.
+//
+// Parameters:
+//   - s: The sorted slice to search.
+//   - target: The target to search for.
+//   - cmp: The comparator.
+//
+// Returns:
+//   - Span: The span of the elements equal to target. Both bounds are empty if cmp is nil.
+//
+// Panics:
+//   - *ErrNotSorted: In debug mode, if the slice is not sorted.
+func EqualRange[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) Span {
+	if cmp == nil {
+		return noSpan()
+	}
+
+	checkSorted(s, target, cmp)
+
+	lower := internal.LowerBound(s, target, cmp)
+	upper := lower + internal.UpperBound(s[lower:], target, cmp)
+
+	return Span{Start: Index(lower), End: Index(upper)}
+}
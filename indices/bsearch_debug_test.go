@@ -0,0 +1,89 @@
+//go:build indices_debug
+
+package indices
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+)
+
+// notSortedAt runs fn and returns the index reported by the *ErrNotSorted it panics with.
+func notSortedAt(t *testing.T, fn func()) (idx uint, ok bool) {
+	t.Helper()
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		err, is_err := r.(error)
+
+		var e *ErrNotSorted
+
+		if !is_err || !errors.As(err, &e) {
+			t.Fatalf("panicked with %v; want *ErrNotSorted", r)
+		}
+
+		idx, ok = e.Index, true
+	}()
+
+	fn()
+
+	return 0, false
+}
+
+// TestSearchDebug tests that, in debug mode, the searches panic on unsorted slices, even those
+// partitioned around the target.
+func TestSearchDebug(t *testing.T) {
+	unsorted := []int{5, 1, 3}
+
+	searches := map[string]func(){
+		"Search":     func() { _ = Search(unsorted, 10, cmp.Compare[int]) },
+		"LowerBound": func() { _ = LowerBound(unsorted, 10, cmp.Compare[int]) },
+		"UpperBound": func() { _ = UpperBound(unsorted, 0, cmp.Compare[int]) },
+		"EqualRange": func() { _ = EqualRange(unsorted, 3, cmp.Compare[int]) },
+	}
+
+	for name, search := range searches {
+		idx, ok := notSortedAt(t, search)
+		if !ok || idx != 1 {
+			t.Errorf("%s did not panic at index 1 (got %d, %t)", name, idx, ok)
+		}
+	}
+
+	sorted := []int{1, 3, 3, 5}
+
+	idx, ok := notSortedAt(t, func() { _ = EqualRange(sorted, 3, cmp.Compare[int]) })
+	if ok {
+		t.Errorf("EqualRange panicked at index %d on a sorted slice", idx)
+	}
+}
+
+// TestSearchDebugMixed tests that, in debug mode, a search whose target has another type than the
+// elements panics when the slice is not partitioned around it.
+func TestSearchDebugMixed(t *testing.T) {
+	byLen := func(s string, n int) int {
+		return cmp.Compare(len(s), n)
+	}
+
+	unsorted := []string{"aaa", "a", "aa"}
+
+	idx, ok := notSortedAt(t, func() { _ = Search(unsorted, 2, byLen) })
+	if !ok || idx != 1 {
+		t.Errorf("Search did not panic at index 1 (got %d, %t)", idx, ok)
+	}
+
+	// Every element is shorter than 10: the slice is partitioned around it and the result is
+	// correct, even though the slice is not sorted.
+	_, ok = notSortedAt(t, func() {
+		got := LowerBound(unsorted, 10, byLen)
+		if got != Index(len(unsorted)) {
+			t.Errorf("LowerBound = %v; want %d", got, len(unsorted))
+		}
+	})
+	if ok {
+		t.Error("LowerBound panicked on a slice partitioned around the target")
+	}
+}
@@ -0,0 +1,90 @@
+package indices
+
+import (
+	"cmp"
+	"testing"
+)
+
+// TestBinarySearch tests Search, LowerBound and UpperBound with duplicates, targets outside of the
+// slice and an empty slice.
+func TestBinarySearch(t *testing.T) {
+	s := []int{1, 3, 3, 3, 5, 7, 7}
+
+	tests := []struct {
+		s                    []int
+		target               int
+		search, lower, upper Index
+	}{
+		{s, 0, None(), 0, 0},
+		{s, 1, 0, 0, 1},
+		{s, 2, None(), 1, 1},
+		{s, 3, 1, 1, 4},
+		{s, 5, 4, 4, 5},
+		{s, 7, 5, 5, 7},
+		{s, 8, None(), 7, 7},
+		{nil, 3, None(), 0, 0},
+		{[]int{3}, 3, 0, 0, 1},
+	}
+
+	for _, test := range tests {
+		if got := Search(test.s, test.target, cmp.Compare[int]); got != test.search {
+			t.Errorf("Search(%v, %d) = %v; want %v", test.s, test.target, got, test.search)
+		}
+
+		if got := LowerBound(test.s, test.target, cmp.Compare[int]); got != test.lower {
+			t.Errorf("LowerBound(%v, %d) = %v; want %v", test.s, test.target, got, test.lower)
+		}
+
+		if got := UpperBound(test.s, test.target, cmp.Compare[int]); got != test.upper {
+			t.Errorf("UpperBound(%v, %d) = %v; want %v", test.s, test.target, got, test.upper)
+		}
+	}
+}
+
+// TestEqualRange tests that EqualRange spans the duplicates of the target, and is empty at the
+// insertion point when there is no match.
+func TestEqualRange(t *testing.T) {
+	s := []int{1, 3, 3, 3, 5, 7, 7}
+
+	tests := []struct {
+		s          []int
+		target     int
+		start, end uint
+	}{
+		{s, 3, 1, 4},
+		{s, 7, 5, 7},
+		{s, 1, 0, 1},
+		{s, 4, 4, 4},
+		{s, 0, 0, 0},
+		{s, 9, 7, 7},
+		{nil, 3, 0, 0},
+	}
+
+	for _, test := range tests {
+		got := EqualRange(test.s, test.target, cmp.Compare[int])
+		if want := span(t, test.start, test.end); got != want {
+			t.Errorf("EqualRange(%v, %d) = %v; want %v", test.s, test.target, got, want)
+		}
+	}
+}
+
+// TestBinarySearchNilCmp tests that every search reports an empty result when cmp is nil.
+func TestBinarySearchNilCmp(t *testing.T) {
+	s := []int{1, 2, 3}
+
+	var nil_cmp func(int, int) int
+
+	for name, got := range map[string]Index{
+		"Search":     Search(s, 2, nil_cmp),
+		"LowerBound": LowerBound(s, 2, nil_cmp),
+		"UpperBound": UpperBound(s, 2, nil_cmp),
+	} {
+		if got.IsPresent() {
+			t.Errorf("%s with a nil cmp = %v; want None", name, got)
+		}
+	}
+
+	if got := EqualRange(s, 2, nil_cmp); !isNoSpan(got) {
+		t.Errorf("EqualRange with a nil cmp = %v; want both bounds empty", got)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build indices_debug
+
+package indices
+
+// debug enables the sanity checks of the binary searches. Build with the
+// "indices_debug" tag to turn it on.
+const debug bool = true
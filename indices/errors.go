@@ -1,6 +1,9 @@
 package indices
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 var (
 	// ErrMissingValue occurs when a value is not present.
@@ -8,8 +11,88 @@ var (
 	// Format:
 	// 	"value is not present"
 	ErrMissingValue error
+
+	// ErrReservedValue occurs when a value is reserved to represent an empty index.
+	//
+	// Format:
+	// 	"value is reserved for an empty index"
+	ErrReservedValue error
 )
 
 func init() {
 	ErrMissingValue = errors.New("value is not present")
+	ErrReservedValue = errors.New("value is reserved for an empty index")
+}
+
+// ErrNotSorted occurs when a slice given to a binary search is not sorted with respect to the
+// comparator.
+type ErrNotSorted struct {
+	// Index is the index of the first out-of-order element.
+	Index uint
+}
+
+// Error implements error.
+func (e ErrNotSorted) Error() string {
+	return "slice is not sorted at index " + strconv.FormatUint(uint64(e.Index), 10)
+}
+
+// NewErrNotSorted creates a new ErrNotSorted error.
+//
+// Parameters:
+//   - idx: The index of the first out-of-order element.
+//
+// Returns:
+//   - error: An instance of ErrNotSorted. Never returns nil.
+//
+// Format:
+//
+//	"slice is not sorted at index <idx>"
+//
+// Where:
+//   - <idx> is the index of the first out-of-order element.
+func NewErrNotSorted(idx uint) error {
+	e := &ErrNotSorted{
+		Index: idx,
+	}
+
+	return e
+}
+
+// ErrBadQuery occurs when a full-text query cannot be parsed.
+type ErrBadQuery struct {
+	// Offset is the byte offset of the offending part of the query.
+	Offset int
+
+	// Reason is why the query is invalid.
+	Reason string
+}
+
+// Error implements error.
+func (e ErrBadQuery) Error() string {
+	return "bad query at offset " + strconv.Itoa(e.Offset) + ": " + e.Reason
+}
+
+// NewErrBadQuery creates a new ErrBadQuery error.
+//
+// Parameters:
+//   - offset: The byte offset of the offending part of the query.
+//   - reason: Why the query is invalid.
+//
+// Returns:
+//   - error: An instance of ErrBadQuery. Never returns nil.
+//
+// Format:
+//
+//	"bad query at offset <offset>: <reason>"
+//
+// Where:
+//   - <offset> is the byte offset of the offending part of the query.
+//   - <reason> is why the query is invalid.
+func NewErrBadQuery(offset int, reason string) error {
+	e := &ErrBadQuery{
+		Offset: offset,
+		Reason: reason,
+	}
+
+	return e
 }
@@ -1,8 +1,10 @@
 package indices
 
+import "github.com/PlayerR9/mygo-lib/indices/internal"
+
 const (
 	// MaxUint is the maximum value of a uint.
-	MaxUint uint = ^uint(0)
+	MaxUint uint = internal.Sentinel
 )
 
 // Index is a type alias for uint. MaxUint is used to represent an empty index.
@@ -15,10 +17,18 @@ type Index uint
 //
 // Returns:
 //   - Index: The newly created Index.
-func Some(idx uint) Index {
+//   - error: An error if idx cannot be represented.
+//
+// Errors:
+//   - ErrReservedValue: If idx is MaxUint, which represents an empty index.
+func Some(idx uint) (Index, error) {
+	if idx == MaxUint {
+		return None(), ErrReservedValue
+	}
+
 	opt := Index(idx)
 
-	return opt
+	return opt, nil
 }
 
 // None creates an Index with no value.
@@ -71,3 +81,91 @@ func (idx Index) OrElse(fallback uint) uint {
 
 	return fallback
 }
+
+// Add adds n to the index.
+//
+// Parameters:
+//   - n: The amount to add.
+//
+// Returns:
+//   - Index: The sum, or an empty index if the index is empty or the sum overflows
+//     or collides with MaxUint.
+func (idx Index) Add(n uint) Index {
+	underlying := uint(idx)
+	if underlying == MaxUint {
+		return idx
+	}
+
+	sum, ok := internal.Add(underlying, n)
+	if !ok {
+		return None()
+	}
+
+	return Index(sum)
+}
+
+// Sub subtracts n from the index.
+//
+// Parameters:
+//   - n: The amount to subtract.
+//
+// Returns:
+//   - Index: The difference, or an empty index if the index is empty or the
+//     difference underflows.
+func (idx Index) Sub(n uint) Index {
+	underlying := uint(idx)
+	if underlying == MaxUint {
+		return idx
+	}
+
+	diff, ok := internal.Sub(underlying, n)
+	if !ok {
+		return None()
+	}
+
+	return Index(diff)
+}
+
+// Offset moves the index by delta, in either direction.
+//
+// Parameters:
+//   - delta: The signed amount to move by.
+//
+// Returns:
+//   - Index: The moved index, or an empty index if the index is empty or the
+//     result is out of range or collides with MaxUint.
+func (idx Index) Offset(delta int) Index {
+	underlying := uint(idx)
+	if underlying == MaxUint {
+		return idx
+	}
+
+	moved, ok := internal.Offset(underlying, delta)
+	if !ok {
+		return None()
+	}
+
+	return Index(moved)
+}
+
+// Clamp restricts the index to the valid positions of a sequence of the given
+// length; that is, to [0, length).
+//
+// Parameters:
+//   - length: The length of the sequence.
+//
+// Returns:
+//   - Index: The index, length - 1 if the index is past the end, or an empty
+//     index if the index is empty or length is 0.
+func (idx Index) Clamp(length uint) Index {
+	underlying := uint(idx)
+	if underlying == MaxUint || length == 0 {
+		return None()
+	}
+
+	if underlying >= length {
+		return Index(length - 1)
+	}
+
+	return idx
+}
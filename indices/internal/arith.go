@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Sentinel is the value reserved to represent an empty index.
+const Sentinel uint = math.MaxUint
+
+// Add adds n to idx.
+//
+// Parameters:
+//   - idx: The index.
+//   - n: The amount to add.
+//
+// Returns:
+//   - uint: The sum.
+//   - bool: False if the sum overflows or is the sentinel, true otherwise.
+func Add(idx, n uint) (uint, bool) {
+	sum, carry := bits.Add(idx, n, 0)
+	if carry != 0 || sum == Sentinel {
+		return 0, false
+	}
+
+	return sum, true
+}
+
+// Sub subtracts n from idx.
+//
+// Parameters:
+//   - idx: The index.
+//   - n: The amount to subtract.
+//
+// Returns:
+//   - uint: The difference.
+//   - bool: False if the difference underflows, true otherwise.
+func Sub(idx, n uint) (uint, bool) {
+	diff, borrow := bits.Sub(idx, n, 0)
+	if borrow != 0 {
+		return 0, false
+	}
+
+	return diff, true
+}
+
+// Offset moves idx by delta, in either direction.
+//
+// Parameters:
+//   - idx: The index.
+//   - delta: The signed amount to move by.
+//
+// Returns:
+//   - uint: The moved index.
+//   - bool: False if the result is out of range or is the sentinel, true otherwise.
+func Offset(idx uint, delta int) (uint, bool) {
+	if delta >= 0 {
+		return Add(idx, uint(delta))
+	}
+
+	// Negating math.MinInt overflows, so delta + 1 is negated instead.
+	return Sub(idx, uint(-(delta+1))+1)
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+// TestOffset tests Offset.
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		idx   uint
+		delta int
+		want  uint
+		ok    bool
+	}{
+		{5, 3, 8, true},
+		{5, -5, 0, true},
+		{5, -6, 0, false},
+		{Sentinel - 1, 1, 0, false},
+		{Sentinel - 2, 1, Sentinel - 1, true},
+		{math.MaxInt, math.MinInt, 0, false},
+		{uint(math.MaxInt) + 1, math.MinInt, 0, true},
+	}
+
+	for _, test := range tests {
+		got, ok := Offset(test.idx, test.delta)
+		if ok != test.ok || got != test.want {
+			t.Errorf("Offset(%d, %d) = %d, %t; want %d, %t", test.idx, test.delta, got, ok, test.want, test.ok)
+		}
+	}
+}
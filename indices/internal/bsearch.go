@@ -0,0 +1,108 @@
+package internal
+
+// LowerBound returns the first index of the slice whose element does not compare below the target.
+// The slice must be partitioned by cmp: every element below the target comes before every other.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - target: The target to compare against.
+//   - cmp: The comparison, negative if the element is below the target, 0 if equal, and positive if above.
+//
+// Returns:
+//   - uint: The insertion point of target; len(s) if every element is below it.
+func LowerBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) uint {
+	lo, hi := uint(0), uint(len(s))
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		if cmp(s[mid], target) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo
+}
+
+// UpperBound returns the first index of the slice whose element compares above the target.
+// The slice must be partitioned by cmp, as for LowerBound.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - target: The target to compare against.
+//   - cmp: The comparison, as for LowerBound.
+//
+// Returns:
+//   - uint: The insertion point after every element equal to target.
+func UpperBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) uint {
+	lo, hi := uint(0), uint(len(s))
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		if cmp(s[mid], target) <= 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo
+}
+
+// CheckPartitioned checks that the slice is partitioned by cmp around the target: every element
+// below it comes first, then every element equal to it, then every element above it. This is all
+// a search for target relies on, but it does not imply that the slice is sorted: elements on the
+// same side of the target are not compared with each other. Use CheckSorted for that.
+//
+// Parameters:
+//   - s: The slice to check.
+//   - target: The target to compare against.
+//   - cmp: The comparison, as for LowerBound.
+//
+// Returns:
+//   - uint: The index of the first out-of-order element.
+//   - bool: True if the slice is partitioned, false otherwise.
+func CheckPartitioned[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) (uint, bool) {
+	prev := -1
+
+	for i, elem := range s {
+		sign := cmp(elem, target)
+
+		switch {
+		case sign < 0:
+			sign = -1
+		case sign > 0:
+			sign = 1
+		}
+
+		if sign < prev {
+			return uint(i), false
+		}
+
+		prev = sign
+	}
+
+	return 0, true
+}
+
+// CheckSorted checks that every element of the slice compares below or equal to the next one.
+//
+// Parameters:
+//   - s: The slice to check.
+//   - cmp: The comparison between two elements.
+//
+// Returns:
+//   - uint: The index of the first element that compares below the previous one.
+//   - bool: True if the slice is sorted, false otherwise.
+func CheckSorted[S ~[]E, E any](s S, cmp func(E, E) int) (uint, bool) {
+	for i := 1; i < len(s); i++ {
+		if cmp(s[i-1], s[i]) > 0 {
+			return uint(i), false
+		}
+	}
+
+	return 0, true
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+// TestBounds tests LowerBound and UpperBound.
+func TestBounds(t *testing.T) {
+	s := []int{1, 3, 3, 3, 5, 8}
+
+	tests := []struct {
+		target       int
+		lower, upper uint
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{3, 1, 4},
+		{4, 4, 4},
+		{8, 5, 6},
+		{9, 6, 6},
+	}
+
+	for _, test := range tests {
+		lower := LowerBound(s, test.target, cmp.Compare[int])
+		upper := UpperBound(s, test.target, cmp.Compare[int])
+
+		if lower != test.lower || upper != test.upper {
+			t.Errorf("bounds of %d = [%d, %d); want [%d, %d)", test.target, lower, upper, test.lower, test.upper)
+		}
+	}
+
+	idx, ok := CheckPartitioned([]int{1, 5, 3}, 3, cmp.Compare[int])
+	if ok || idx != 2 {
+		t.Errorf("CheckPartitioned = %d, %t; want 2, false", idx, ok)
+	}
+}
+
+// TestCheckSorted tests that CheckSorted catches unsorted slices that are nonetheless
+// partitioned around a target.
+func TestCheckSorted(t *testing.T) {
+	tests := []struct {
+		s   []int
+		idx uint
+		ok  bool
+	}{
+		{nil, 0, true},
+		{[]int{1}, 0, true},
+		{[]int{1, 3, 3, 5}, 0, true},
+		{[]int{5, 1, 3}, 1, false},
+		{[]int{1, 2, 4, 3}, 3, false},
+	}
+
+	for _, test := range tests {
+		idx, ok := CheckSorted(test.s, cmp.Compare[int])
+		if ok != test.ok || idx != test.idx {
+			t.Errorf("CheckSorted(%v) = %d, %t; want %d, %t", test.s, idx, ok, test.idx, test.ok)
+		}
+	}
+
+	_, ok := CheckPartitioned([]int{5, 1, 3}, 10, cmp.Compare[int])
+	if !ok {
+		t.Error("CheckPartitioned([5 1 3], 10) = false; want true, as every element is below 10")
+	}
+}
+
+// benchSlice returns a sorted slice of a million even numbers.
+func benchSlice() []int {
+	s := make([]int, 1<<20)
+	for i := range s {
+		s[i] = 2 * i
+	}
+
+	return s
+}
+
+// BenchmarkLowerBound benchmarks LowerBound.
+func BenchmarkLowerBound(b *testing.B) {
+	s := benchSlice()
+
+	for i := 0; i < b.N; i++ {
+		_ = LowerBound(s, i%len(s)*2, cmp.Compare[int])
+	}
+}
+
+// BenchmarkBinarySearchFunc benchmarks slices.BinarySearchFunc, for comparison with LowerBound.
+func BenchmarkBinarySearchFunc(b *testing.B) {
+	s := benchSlice()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = slices.BinarySearchFunc(s, i%len(s)*2, cmp.Compare[int])
+	}
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Posting records the occurrences of a term in a document.
+type Posting struct {
+	// Doc is the index of the document.
+	Doc uint
+
+	// Freq is the number of occurrences of the term in the document.
+	Freq uint
+}
+
+// Fold maps every rune of the text to the smallest rune of its case folding orbit, so that
+// two texts that differ only by case fold to the same text.
+//
+// Parameters:
+//   - text: The text to fold.
+//
+// Returns:
+//   - string: The folded text.
+func Fold(text string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			folded = min(folded, f)
+		}
+
+		return folded
+	}, text)
+}
+
+// BM25 scores a term of a query against a document with the Okapi BM25 formula.
+//
+// Parameters:
+//   - tf: The number of occurrences of the term in the document.
+//   - df: The number of documents containing the term.
+//   - n: The number of documents.
+//   - dl: The length of the document, in terms.
+//   - avgdl: The average length of the documents, in terms.
+//   - k1: The saturation of term frequencies.
+//   - b: The normalization by document length, between 0 and 1.
+//
+// Returns:
+//   - float64: The score.
+func BM25(tf, df, n uint, dl, avgdl, k1, b float64) float64 {
+	// The "+ 1" keeps the idf positive for terms present in most documents.
+	idf := math.Log(1 + (float64(n)-float64(df)+0.5)/(float64(df)+0.5))
+
+	norm := 1 - b
+	if avgdl > 0 {
+		norm += b * dl / avgdl
+	}
+
+	f := float64(tf)
+
+	return idf * f * (k1 + 1) / (f + k1*norm)
+}
+
+// Intersect returns the elements present in both sorted sets.
+//
+// Parameters:
+//   - a: The first sorted set.
+//   - b: The second sorted set.
+//
+// Returns:
+//   - []uint: The sorted intersection.
+func Intersect(a, b []uint) []uint {
+	var result []uint
+
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			result = append(result, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+
+	return result
+}
+
+// Union returns the elements present in either sorted set.
+//
+// Parameters:
+//   - a: The first sorted set.
+//   - b: The second sorted set.
+//
+// Returns:
+//   - []uint: The sorted union.
+func Union(a, b []uint) []uint {
+	result := make([]uint, 0, len(a)+len(b))
+
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			result = append(result, a[0])
+			a = a[1:]
+		case a[0] > b[0]:
+			result = append(result, b[0])
+			b = b[1:]
+		default:
+			result = append(result, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+
+	result = append(result, a...)
+	result = append(result, b...)
+
+	return result
+}
+
+// Complement returns the elements of [0, n) absent from the sorted set.
+//
+// Parameters:
+//   - a: The sorted set.
+//   - n: The size of the universe.
+//
+// Returns:
+//   - []uint: The sorted complement.
+func Complement(a []uint, n uint) []uint {
+	var result []uint
+
+	for i := uint(0); i < n; i++ {
+		if len(a) > 0 && a[0] == i {
+			a = a[1:]
+		} else {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
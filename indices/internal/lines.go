@@ -0,0 +1,134 @@
+package internal
+
+import "unicode/utf8"
+
+// Counts is a length of text measured in every unit at once.
+type Counts struct {
+	// Bytes is the length in UTF-8 bytes.
+	Bytes uint
+
+	// Runes is the length in runes. Every invalid byte counts as one rune.
+	Runes uint
+
+	// UTF16 is the length in UTF-16 code units.
+	UTF16 uint
+}
+
+// Plus returns the sum of two lengths.
+//
+// Parameters:
+//   - other: The length to add.
+//
+// Returns:
+//   - Counts: The sum.
+func (c Counts) Plus(other Counts) Counts {
+	return Counts{
+		Bytes: c.Bytes + other.Bytes,
+		Runes: c.Runes + other.Runes,
+		UTF16: c.UTF16 + other.UTF16,
+	}
+}
+
+// Minus returns the difference of two lengths. other must not exceed c in any unit.
+//
+// Parameters:
+//   - other: The length to subtract.
+//
+// Returns:
+//   - Counts: The difference.
+func (c Counts) Minus(other Counts) Counts {
+	return Counts{
+		Bytes: c.Bytes - other.Bytes,
+		Runes: c.Runes - other.Runes,
+		UTF16: c.UTF16 - other.UTF16,
+	}
+}
+
+// step returns the length of the rune at the start of the text.
+//
+// Parameters:
+//   - text: The text. Must not be empty.
+//
+// Returns:
+//   - Counts: The length of the first rune.
+func step(text string) Counts {
+	r, size := utf8.DecodeRuneInString(text)
+
+	c := Counts{
+		Bytes: uint(size),
+		Runes: 1,
+		UTF16: 1,
+	}
+
+	if r >= 0x10000 {
+		c.UTF16 = 2
+	}
+
+	return c
+}
+
+// Measure returns the length of the text.
+//
+// Parameters:
+//   - text: The text to measure.
+//
+// Returns:
+//   - Counts: The length of the text.
+func Measure(text string) Counts {
+	var c Counts
+
+	for c.Bytes < uint(len(text)) {
+		c = c.Plus(step(text[c.Bytes:]))
+	}
+
+	return c
+}
+
+// LineStarts returns the offsets of the lines that start within the text; that is, the offsets
+// right after every "\n".
+//
+// Parameters:
+//   - text: The text to scan.
+//   - base: The offset of the text itself, added to every result.
+//
+// Returns:
+//   - []Counts: The offsets of the line starts, in increasing order.
+func LineStarts(text string, base Counts) []Counts {
+	var starts []Counts
+
+	c := base
+	offset := 0
+
+	for offset < len(text) {
+		s := step(text[offset:])
+
+		c = c.Plus(s)
+		offset += int(s.Bytes)
+
+		if text[offset-1] == '\n' {
+			starts = append(starts, c)
+		}
+	}
+
+	return starts
+}
+
+// Advance walks the text until the given length, in the unit picked by key, is reached.
+//
+// Parameters:
+//   - text: The text to walk.
+//   - n: The length to reach.
+//   - key: The unit of n.
+//
+// Returns:
+//   - Counts: The length walked.
+//   - bool: False if n falls past the end of the text or within a rune, true otherwise.
+func Advance(text string, n uint, key func(Counts) uint) (Counts, bool) {
+	var c Counts
+
+	for key(c) < n && c.Bytes < uint(len(text)) {
+		c = c.Plus(step(text[c.Bytes:]))
+	}
+
+	return c, key(c) == n
+}
@@ -0,0 +1,37 @@
+package internal
+
+import "testing"
+
+// TestLineStarts tests LineStarts and Advance over mixed-width text.
+func TestLineStarts(t *testing.T) {
+	text := "aé\n\U0001F600b\r\nc"
+
+	starts := LineStarts(text, Counts{})
+
+	want := []Counts{
+		{Bytes: 4, Runes: 3, UTF16: 3},
+		{Bytes: 11, Runes: 7, UTF16: 8},
+	}
+
+	if len(starts) != len(want) {
+		t.Fatalf("expected %d line starts, got %d", len(want), len(starts))
+	}
+
+	for i, got := range starts {
+		if got != want[i] {
+			t.Errorf("line start %d: want %+v, got %+v", i, want[i], got)
+		}
+	}
+
+	utf16 := func(c Counts) uint { return c.UTF16 }
+
+	c, ok := Advance(text[4:], 2, utf16)
+	if !ok || c.Bytes != 4 {
+		t.Errorf("Advance(2 UTF-16 units) = %+v, %t; want 4 bytes, true", c, ok)
+	}
+
+	_, ok = Advance(text[4:], 1, utf16)
+	if ok {
+		t.Errorf("Advance(1 UTF-16 unit) should fall within the emoji")
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// CheckEvery is the number of elements between two checks of the context.
+	CheckEvery int = 256
+
+	// MinChunk is the smallest number of elements a worker takes at once.
+	MinChunk int = 1024
+)
+
+// FirstIndexOfCtx is FirstIndexOf that stops when the context is done.
+//
+// Parameters:
+//   - ctx: The context.
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - uint: The first index of the slice that satisfies the predicate.
+//   - bool: True if an element satisfies the predicate, false otherwise.
+//   - error: The error of the context, if it is done before the search ends.
+func FirstIndexOfCtx[S ~[]E, E any](ctx context.Context, s S, predicate func(e E) bool) (uint, bool, error) {
+	for i, elem := range s {
+		if i%CheckEvery == 0 {
+			err := ctx.Err()
+			if err != nil {
+				return 0, false, err
+			}
+		}
+
+		ok := predicate(elem)
+		if ok {
+			return uint(i), true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// ParallelFirstIndexOf is FirstIndexOfCtx with several workers.
+//
+// The slice is cut into chunks that the workers take in increasing order. The lowest hit so far
+// is shared, and a worker abandons its chunk as soon as it reaches that hit; since every chunk
+// below the hit is scanned up to it, the result is exactly the lowest matching index.
+//
+// Parameters:
+//   - ctx: The context.
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching. Must be safe for concurrent use.
+//   - workers: The number of workers. Must be positive.
+//
+// Returns:
+//   - uint: The first index of the slice that satisfies the predicate.
+//   - bool: True if an element satisfies the predicate, false otherwise.
+//   - error: The error of the context, if it is done before the search ends.
+func ParallelFirstIndexOf[S ~[]E, E any](ctx context.Context, s S, predicate func(e E) bool, workers int) (uint, bool, error) {
+	// Several chunks per worker balance the load when the predicate is uneven.
+	chunk := max(len(s)/(workers*4), MinChunk)
+
+	var best atomic.Int64
+	best.Store(math.MaxInt64)
+
+	var next atomic.Int64
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				start := int(next.Add(int64(chunk))) - chunk
+				if start >= len(s) || int64(start) >= best.Load() {
+					return
+				}
+
+				end := min(start+chunk, len(s))
+
+				for i := start; i < end; i++ {
+					if (i-start)%CheckEvery == 0 && ctx.Err() != nil {
+						return
+					}
+
+					if int64(i) >= best.Load() {
+						break
+					}
+
+					ok := predicate(s[i])
+					if !ok {
+						continue
+					}
+
+					for {
+						old := best.Load()
+						if int64(i) >= old || best.CompareAndSwap(old, int64(i)) {
+							break
+						}
+					}
+
+					break
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// A canceled search may have skipped a lower hit, so its result cannot be trusted.
+	err := ctx.Err()
+	if err != nil {
+		return 0, false, err
+	}
+
+	idx := best.Load()
+	if idx == math.MaxInt64 {
+		return 0, false, nil
+	}
+
+	return uint(idx), true, nil
+}
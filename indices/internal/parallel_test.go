@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestParallelFirstIndexOf tests that ParallelFirstIndexOf finds the lowest hit, however the
+// chunks are scheduled.
+func TestParallelFirstIndexOf(t *testing.T) {
+	elems := make([]int, 100000)
+	for i := range elems {
+		elems[i] = i % 9973
+	}
+
+	for _, target := range []int{0, 42, 9972, -1} {
+		want, want_ok := FirstIndexOf(elems, func(e int) bool { return e == target })
+
+		for _, workers := range []int{1, 3, 16} {
+			got, ok, err := ParallelFirstIndexOf(context.Background(), elems, func(e int) bool { return e == target }, workers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != want || ok != want_ok {
+				t.Errorf("target %d with %d workers: got %d, %t; want %d, %t", target, workers, got, ok, want, want_ok)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := ParallelFirstIndexOf(ctx, elems, func(e int) bool { return false }, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NodeKind is the kind of a node of a query.
+type NodeKind int
+
+const (
+	// TermNode matches the documents containing Term.
+	TermNode NodeKind = iota
+
+	// PrefixNode matches the documents containing a term that starts with Term.
+	PrefixNode
+
+	// AndNode matches the documents matched by every child.
+	AndNode
+
+	// OrNode matches the documents matched by any child.
+	OrNode
+
+	// NotNode matches the documents not matched by its only child.
+	NotNode
+)
+
+// Node is a node of a parsed query.
+type Node struct {
+	// Kind is the kind of the node.
+	Kind NodeKind
+
+	// Term is the term of a TermNode or PrefixNode.
+	Term string
+
+	// Children are the operands of an AndNode, OrNode or NotNode.
+	Children []*Node
+}
+
+// SyntaxError occurs when a query cannot be parsed.
+type SyntaxError struct {
+	// Offset is the byte offset of the offending token.
+	Offset int
+
+	// Reason is why the query is invalid.
+	Reason string
+}
+
+// Error implements error.
+func (e SyntaxError) Error() string {
+	return "at offset " + strconv.Itoa(e.Offset) + ": " + e.Reason
+}
+
+// token is a lexical token of a query.
+type token struct {
+	// text is the text of the token.
+	text string
+
+	// offset is the byte offset of the token.
+	offset int
+}
+
+// lex splits a query into words and parentheses.
+//
+// Parameters:
+//   - query: The query.
+//
+// Returns:
+//   - []token: The tokens.
+func lex(query string) []token {
+	var tokens []token
+
+	start := -1
+
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{text: query[start:end], offset: start})
+			start = -1
+		}
+	}
+
+	for i, r := range query {
+		switch {
+		case r == '(' || r == ')':
+			flush(i)
+			tokens = append(tokens, token{text: string(r), offset: i})
+		case unicode.IsSpace(r):
+			flush(i)
+		case start < 0:
+			start = i
+		}
+	}
+
+	flush(len(query))
+
+	return tokens
+}
+
+// parser is a recursive-descent parser of queries.
+type parser struct {
+	// tokens are the remaining tokens.
+	tokens []token
+
+	// end is the length of the query, reported for errors at its end.
+	end int
+
+	// terms splits a word into folded terms.
+	terms func(string) []string
+}
+
+// Parse parses a query. Words are ANDed unless separated by "OR"; "NOT" or a leading "-"
+// negates what follows; a trailing "*" makes the last term of a word a prefix; parentheses
+// group. A word made of several terms matches documents containing all of them.
+//
+// Parameters:
+//   - query: The query.
+//   - terms: The function that splits a word into folded terms.
+//
+// Returns:
+//   - *Node: The root of the query, or nil if the query is empty.
+//   - error: An error if the query is invalid.
+//
+// Errors:
+//   - *SyntaxError: If the query is invalid.
+func Parse(query string, terms func(string) []string) (*Node, error) {
+	p := &parser{
+		tokens: lex(query),
+		end:    len(query),
+		terms:  terms,
+	}
+
+	if len(p.tokens) == 0 {
+		return nil, nil
+	}
+
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.tokens) > 0 {
+		return nil, p.fail("unexpected " + strconv.Quote(p.tokens[0].text))
+	}
+
+	return node, nil
+}
+
+// fail returns a syntax error at the current token.
+func (p *parser) fail(reason string) error {
+	offset := p.end
+	if len(p.tokens) > 0 {
+		offset = p.tokens[0].offset
+	}
+
+	return &SyntaxError{Offset: offset, Reason: reason}
+}
+
+// peek checks if the current token is the given text.
+func (p *parser) peek(text string) bool {
+	return len(p.tokens) > 0 && p.tokens[0].text == text
+}
+
+// or parses: and ("OR" and)*.
+func (p *parser) or() (*Node, error) {
+	node, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*Node{node}
+
+	for p.peek("OR") {
+		p.tokens = p.tokens[1:]
+
+		node, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return &Node{Kind: OrNode, Children: children}, nil
+}
+
+// and parses: unary (["AND"] unary)*.
+func (p *parser) and() (*Node, error) {
+	node, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*Node{node}
+
+	for len(p.tokens) > 0 && !p.peek("OR") && !p.peek(")") {
+		if p.peek("AND") {
+			p.tokens = p.tokens[1:]
+		}
+
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return &Node{Kind: AndNode, Children: children}, nil
+}
+
+// unary parses: ("NOT" | "-") unary | atom.
+func (p *parser) unary() (*Node, error) {
+	switch {
+	case p.peek("NOT"), p.peek("-"):
+		p.tokens = p.tokens[1:]
+	case len(p.tokens) > 0 && p.tokens[0].text[0] == '-':
+		p.tokens[0].text = p.tokens[0].text[1:]
+		p.tokens[0].offset++
+	default:
+		return p.atom()
+	}
+
+	node, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{Kind: NotNode, Children: []*Node{node}}, nil
+}
+
+// atom parses: "(" or ")" | word.
+func (p *parser) atom() (*Node, error) {
+	if len(p.tokens) == 0 {
+		return nil, p.fail("unexpected end of query")
+	}
+
+	tok := p.tokens[0]
+
+	switch tok.text {
+	case "(":
+		p.tokens = p.tokens[1:]
+
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.peek(")") {
+			return nil, p.fail("missing \")\"")
+		}
+
+		p.tokens = p.tokens[1:]
+
+		return node, nil
+	case ")", "OR", "AND":
+		return nil, p.fail("unexpected " + strconv.Quote(tok.text))
+	}
+
+	word, prefix := strings.CutSuffix(tok.text, "*")
+
+	terms := p.terms(word)
+	if len(terms) == 0 {
+		return nil, p.fail(strconv.Quote(tok.text) + " has no searchable terms")
+	}
+
+	p.tokens = p.tokens[1:]
+
+	children := make([]*Node, 0, len(terms))
+
+	for _, term := range terms {
+		children = append(children, &Node{Kind: TermNode, Term: term})
+	}
+
+	if prefix {
+		children[len(children)-1].Kind = PrefixNode
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return &Node{Kind: AndNode, Children: children}, nil
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// render prints a query tree in prefix notation.
+func render(node *Node) string {
+	switch node.Kind {
+	case TermNode:
+		return node.Term
+	case PrefixNode:
+		return node.Term + "*"
+	}
+
+	names := map[NodeKind]string{AndNode: "and", OrNode: "or", NotNode: "not"}
+
+	parts := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		parts = append(parts, render(child))
+	}
+
+	return names[node.Kind] + "(" + strings.Join(parts, " ") + ")"
+}
+
+// TestParse tests Parse.
+func TestParse(t *testing.T) {
+	terms := func(word string) []string {
+		return strings.FieldsFunc(strings.ToLower(word), func(r rune) bool { return r == '-' })
+	}
+
+	tests := []struct {
+		query, want string
+	}{
+		{"a", "a"},
+		{"a b OR c", "or(and(a b) c)"},
+		{"a AND (b OR c*)", "and(a or(b c*))"},
+		{"-a NOT b - (c)", "and(not(a) not(b) not(c))"},
+		{"Foo-Bar*", "and(foo bar*)"},
+	}
+
+	for _, test := range tests {
+		node, err := Parse(test.query, terms)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error: %v", test.query, err)
+		} else if got := render(node); got != test.want {
+			t.Errorf("Parse(%q) = %s; want %s", test.query, got, test.want)
+		}
+	}
+
+	for query, offset := range map[string]int{"(a": 2, "a OR": 4, "a )": 2, "a -": 3, "a - -": 5} {
+		_, err := Parse(query, terms)
+
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) || syntax.Offset != offset {
+			t.Errorf("Parse(%q): expected a syntax error at %d, got %v", query, offset, err)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package internal
 
+import (
+	"iter"
+	"slices"
+)
+
 // FirstIndexOf returns the first index of the slice for which the predicate returns true, or an empty index
 // if no element satisfies the predicate.
 //
@@ -20,3 +25,175 @@ func FirstIndexOf[S ~[]E, E any](s S, predicate func(e E) bool) (uint, bool) {
 
 	return 0, false
 }
+
+// LastIndexOf returns the last index of the slice for which the predicate returns true.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - uint: The last index of the slice that satisfies the predicate.
+//   - bool: True if an element satisfies the predicate, false otherwise.
+func LastIndexOf[S ~[]E, E any](s S, predicate func(e E) bool) (uint, bool) {
+	for i := len(s) - 1; i >= 0; i-- {
+		ok := predicate(s[i])
+		if ok {
+			return uint(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// NthIndexOf returns the index of the n-th element of the slice, counting from 0, for which
+// the predicate returns true.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - n: The number of matches to skip.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - uint: The index of the n-th element that satisfies the predicate.
+//   - bool: True if at least n + 1 elements satisfy the predicate, false otherwise.
+func NthIndexOf[S ~[]E, E any](s S, n uint, predicate func(e E) bool) (uint, bool) {
+	for i, elem := range s {
+		ok := predicate(elem)
+		if !ok {
+			continue
+		}
+
+		if n == 0 {
+			return uint(i), true
+		}
+
+		n--
+	}
+
+	return 0, false
+}
+
+// AllIndicesOf returns an iterator over the indices of the slice for which the predicate
+// returns true, in increasing order.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - iter.Seq[uint]: The indices of the elements that satisfy the predicate. Never returns nil.
+func AllIndicesOf[S ~[]E, E any](s S, predicate func(e E) bool) iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		for i, elem := range s {
+			ok := predicate(elem)
+			if !ok {
+				continue
+			}
+
+			ok = yield(uint(i))
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// CountMatching returns the number of elements of the slice for which the predicate returns true.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - uint: The number of elements that satisfy the predicate.
+func CountMatching[S ~[]E, E any](s S, predicate func(e E) bool) uint {
+	var count uint
+
+	for _, elem := range s {
+		ok := predicate(elem)
+		if ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// NaiveThreshold is the length of a needle below which IndexOfSubslice compares every
+// position directly instead of building the KMP failure table.
+const NaiveThreshold int = 8
+
+// IndexOfSubslice returns the first index at which sub occurs in s. An empty sub occurs at 0.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - sub: The subslice to search for.
+//
+// Returns:
+//   - uint: The first index of sub in s.
+//   - bool: True if sub occurs in s, false otherwise.
+func IndexOfSubslice[S ~[]E, E comparable](s, sub S) (uint, bool) {
+	switch {
+	case len(sub) == 0:
+		return 0, true
+	case len(sub) > len(s):
+		return 0, false
+	case len(sub) < NaiveThreshold:
+		return naiveIndex(s, sub)
+	default:
+		return kmpIndex(s, sub)
+	}
+}
+
+// naiveIndex is IndexOfSubslice by comparing sub at every position of s.
+func naiveIndex[S ~[]E, E comparable](s, sub S) (uint, bool) {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		ok := slices.Equal(s[i:i+len(sub)], sub)
+		if ok {
+			return uint(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// kmpIndex is IndexOfSubslice by the Knuth-Morris-Pratt algorithm, which runs in
+// O(len(s) + len(sub)) time.
+func kmpIndex[S ~[]E, E comparable](s, sub S) (uint, bool) {
+	// failure[i] is the length of the longest proper prefix of sub[:i+1] that is
+	// also a suffix of it.
+	failure := make([]int, len(sub))
+
+	k := 0
+
+	for i := 1; i < len(sub); i++ {
+		for k > 0 && sub[i] != sub[k] {
+			k = failure[k-1]
+		}
+
+		if sub[i] == sub[k] {
+			k++
+		}
+
+		failure[i] = k
+	}
+
+	k = 0
+
+	for i, elem := range s {
+		for k > 0 && elem != sub[k] {
+			k = failure[k-1]
+		}
+
+		if elem == sub[k] {
+			k++
+		}
+
+		if k == len(sub) {
+			return uint(i - len(sub) + 1), true
+		}
+	}
+
+	return 0, false
+}
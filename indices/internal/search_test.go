@@ -19,3 +19,26 @@ func TestFirstIndexOf(t *testing.T) {
 		t.Errorf("expected index to be 101, got %d", idx)
 	}
 }
+
+// TestIndexOfSubslice tests IndexOfSubslice on both the naive and the KMP paths.
+func TestIndexOfSubslice(t *testing.T) {
+	tests := []struct {
+		s, sub string
+		want   uint
+		ok     bool
+	}{
+		{"hello", "", 0, true},
+		{"hello", "llo", 2, true},
+		{"hello", "lol", 0, false},
+		{"aaaaaaaaaaaaaaaaab", "aaaaaaaaab", 8, true},
+		{"abababababcabababababcababababababd", "ababababababd", 22, true},
+		{"abababababcabababababc", "ababababababd", 0, false},
+	}
+
+	for _, test := range tests {
+		got, ok := IndexOfSubslice([]byte(test.s), []byte(test.sub))
+		if ok != test.ok || got != test.want {
+			t.Errorf("IndexOfSubslice(%q, %q) = %d, %t; want %d, %t", test.s, test.sub, got, ok, test.want, test.ok)
+		}
+	}
+}
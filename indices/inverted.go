@@ -0,0 +1,472 @@
+package indices
+
+import (
+	"cmp"
+	"encoding/gob"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/indices/internal"
+	"github.com/PlayerR9/mygo-lib/runes"
+)
+
+const (
+	// BM25K1 is the saturation of term frequencies used to rank results.
+	BM25K1 float64 = 1.2
+
+	// BM25B is the normalization by document length used to rank results.
+	BM25B float64 = 0.75
+)
+
+// Tokenizer splits a text into terms.
+//
+// Parameters:
+//   - text: The text to split.
+//
+// Returns:
+//   - []string: The terms of the text, in order. Empty terms are ignored.
+type Tokenizer func(text string) []string
+
+// Words is the default Tokenizer: it splits the text on every rune that is neither a letter
+// nor a digit.
+//
+// Parameters:
+//   - text: The text to split.
+//
+// Returns:
+//   - []string: The words of the text, in order.
+func Words(text string) []string {
+	parts := runes.Split([]rune(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		words = append(words, string(part))
+	}
+
+	return words
+}
+
+// InvertedOptions are the options of an InvertedIndex.
+type InvertedOptions struct {
+	// Tokenizer splits documents and query words into terms. If nil, Words is used.
+	Tokenizer Tokenizer
+
+	// FoldCase makes the index case-insensitive.
+	FoldCase bool
+}
+
+// Hit is a document matching a query.
+type Hit struct {
+	// Doc is the index of the document, in the order it was added.
+	Doc uint
+
+	// Score is the BM25 relevance of the document to the query. Higher is better.
+	Score float64
+}
+
+// InvertedIndex is an in-memory full-text index over a collection of strings. Documents are
+// identified by the order in which they are added, so adding the elements of a slice in order
+// makes the hits indices of that slice.
+//
+// An InvertedIndex is safe for concurrent use.
+type InvertedIndex struct {
+	// mu protects the fields below.
+	mu sync.RWMutex
+
+	// tokenizer splits texts into terms.
+	tokenizer Tokenizer
+
+	// fold is whether terms are case folded.
+	fold bool
+
+	// lengths are the lengths, in terms, of the documents.
+	lengths []uint
+
+	// total is the sum of lengths.
+	total uint
+
+	// postings are the postings of every term, by increasing document.
+	postings map[string][]internal.Posting
+
+	// terms are the keys of postings, sorted for prefix queries.
+	terms []string
+}
+
+// NewInvertedIndex creates an empty InvertedIndex.
+//
+// Parameters:
+//   - opts: The options of the index.
+//
+// Returns:
+//   - *InvertedIndex: The new index. Never returns nil.
+func NewInvertedIndex(opts InvertedOptions) *InvertedIndex {
+	tokenizer := opts.Tokenizer
+	if tokenizer == nil {
+		tokenizer = Words
+	}
+
+	ii := &InvertedIndex{
+		tokenizer: tokenizer,
+		fold:      opts.FoldCase,
+		postings:  make(map[string][]internal.Posting),
+	}
+
+	return ii
+}
+
+// analyze splits the text into the terms of the index.
+//
+// Parameters:
+//   - text: The text to split.
+//
+// Returns:
+//   - []string: The terms of the text.
+func (ii *InvertedIndex) analyze(text string) []string {
+	terms := ii.tokenizer(text)
+
+	result := terms[:0:0]
+
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+
+		if ii.fold {
+			term = internal.Fold(term)
+		}
+
+		result = append(result, term)
+	}
+
+	return result
+}
+
+// Add indexes a document.
+//
+// Parameters:
+//   - doc: The text of the document.
+//
+// Returns:
+//   - uint: The index of the document; that is, the number of documents added before it.
+func (ii *InvertedIndex) Add(doc string) uint {
+	terms := ii.analyze(doc)
+
+	counts := make(map[string]uint, len(terms))
+	for _, term := range terms {
+		counts[term]++
+	}
+
+	ii.mu.Lock()
+	defer ii.mu.Unlock()
+
+	id := uint(len(ii.lengths))
+
+	for term, freq := range counts {
+		postings, ok := ii.postings[term]
+		if !ok {
+			pos, _ := slices.BinarySearch(ii.terms, term)
+			ii.terms = slices.Insert(ii.terms, pos, term)
+		}
+
+		ii.postings[term] = append(postings, internal.Posting{Doc: id, Freq: freq})
+	}
+
+	ii.lengths = append(ii.lengths, uint(len(terms)))
+	ii.total += uint(len(terms))
+
+	return id
+}
+
+// Len returns the number of documents in the index.
+//
+// Returns:
+//   - uint: The number of documents.
+func (ii *InvertedIndex) Len() uint {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+
+	return uint(len(ii.lengths))
+}
+
+// Search returns the documents matching the query, from the most relevant to the least
+// relevant, ranked by BM25. Ties are broken by document index.
+//
+// Words are ANDed unless separated by "OR"; "NOT" or a leading "-" excludes what follows; a
+// trailing "*" matches every term starting with the word; parentheses group. For example:
+//
+//	parser (json OR yaml) -deprecated conf*
+//
+// Words go through the tokenizer and case folding of the index; a word made of several terms,
+// such as "utf-8", matches the documents containing all of them.
+//
+// Parameters:
+//   - query: The query.
+//
+// Returns:
+//   - []Hit: The matching documents. Nil if the query is empty.
+//   - error: An error if the query is invalid.
+//
+// Errors:
+//   - *ErrBadQuery: If the query is invalid.
+func (ii *InvertedIndex) Search(query string) ([]Hit, error) {
+	root, err := internal.Parse(query, ii.analyze)
+	if err != nil {
+		var syntax *internal.SyntaxError
+
+		ok := errors.As(err, &syntax)
+		if ok {
+			return nil, NewErrBadQuery(syntax.Offset, syntax.Reason)
+		}
+
+		return nil, err
+	} else if root == nil {
+		return nil, nil
+	}
+
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+
+	scored := make(map[string]struct{})
+
+	docs := ii.eval(root, false, scored)
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	n := uint(len(ii.lengths))
+	avgdl := float64(ii.total) / float64(n)
+
+	hits := make([]Hit, 0, len(docs))
+
+	for _, doc := range docs {
+		hit := Hit{Doc: doc}
+
+		for term := range scored {
+			postings := ii.postings[term]
+
+			pos, ok := slices.BinarySearchFunc(postings, doc, func(p internal.Posting, doc uint) int {
+				return cmp.Compare(p.Doc, doc)
+			})
+			if !ok {
+				continue
+			}
+
+			hit.Score += internal.BM25(postings[pos].Freq, uint(len(postings)), n, float64(ii.lengths[doc]), avgdl, BM25K1, BM25B)
+		}
+
+		hits = append(hits, hit)
+	}
+
+	slices.SortStableFunc(hits, func(a, b Hit) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return hits, nil
+}
+
+// eval returns the documents matched by a node of a query. The read lock must be held.
+//
+// Parameters:
+//   - node: The node to evaluate.
+//   - negated: Whether the node is under an odd number of negations.
+//   - scored: The terms that count towards the score, filled in by eval. Negated terms do not.
+//
+// Returns:
+//   - []uint: The sorted indices of the matching documents.
+func (ii *InvertedIndex) eval(node *internal.Node, negated bool, scored map[string]struct{}) []uint {
+	switch node.Kind {
+	case internal.TermNode:
+		return ii.docsOf(node.Term, negated, scored)
+	case internal.PrefixNode:
+		var docs []uint
+
+		start, _ := slices.BinarySearch(ii.terms, node.Term)
+
+		for _, term := range ii.terms[start:] {
+			ok := strings.HasPrefix(term, node.Term)
+			if !ok {
+				break
+			}
+
+			docs = internal.Union(docs, ii.docsOf(term, negated, scored))
+		}
+
+		return docs
+	case internal.AndNode:
+		docs := ii.eval(node.Children[0], negated, scored)
+
+		for _, child := range node.Children[1:] {
+			docs = internal.Intersect(docs, ii.eval(child, negated, scored))
+		}
+
+		return docs
+	case internal.OrNode:
+		var docs []uint
+
+		for _, child := range node.Children {
+			docs = internal.Union(docs, ii.eval(child, negated, scored))
+		}
+
+		return docs
+	default:
+		docs := ii.eval(node.Children[0], !negated, scored)
+
+		return internal.Complement(docs, uint(len(ii.lengths)))
+	}
+}
+
+// docsOf returns the documents containing the term. The read lock must be held.
+//
+// Parameters:
+//   - term: The term.
+//   - negated: Whether the term is under an odd number of negations.
+//   - scored: The terms that count towards the score. The term is added unless negated.
+//
+// Returns:
+//   - []uint: The sorted indices of the documents containing the term.
+func (ii *InvertedIndex) docsOf(term string, negated bool, scored map[string]struct{}) []uint {
+	postings := ii.postings[term]
+
+	if !negated && len(postings) > 0 {
+		scored[term] = struct{}{}
+	}
+
+	docs := make([]uint, 0, len(postings))
+
+	for _, p := range postings {
+		docs = append(docs, p.Doc)
+	}
+
+	return docs
+}
+
+// invertedVersion is the version of the format written by Save.
+const invertedVersion uint = 1
+
+// invertedSnapshot is the serialized form of an InvertedIndex.
+type invertedSnapshot struct {
+	// Version is the version of the format.
+	Version uint
+
+	// FoldCase is whether terms are case folded.
+	FoldCase bool
+
+	// Lengths are the lengths of the documents.
+	Lengths []uint
+
+	// Terms are the sorted terms.
+	Terms []string
+
+	// Postings are the postings of every term, in the order of Terms.
+	Postings [][]internal.Posting
+}
+
+// Save writes the index, so that LoadInvertedIndex can read it back. The tokenizer is not
+// saved.
+//
+// Parameters:
+//   - w: The writer to write to.
+//
+// Returns:
+//   - error: An error if the index could not be written.
+//
+// Errors:
+//   - *gers.ErrBadParam: If w is nil.
+//   - any other error: If the writer fails.
+func (ii *InvertedIndex) Save(w io.Writer) error {
+	if w == nil {
+		return gers.NewErrNilParam("w")
+	}
+
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+
+	snapshot := invertedSnapshot{
+		Version:  invertedVersion,
+		FoldCase: ii.fold,
+		Lengths:  ii.lengths,
+		Terms:    ii.terms,
+		Postings: make([][]internal.Posting, 0, len(ii.terms)),
+	}
+
+	for _, term := range ii.terms {
+		snapshot.Postings = append(snapshot.Postings, ii.postings[term])
+	}
+
+	return gob.NewEncoder(w).Encode(&snapshot)
+}
+
+// LoadInvertedIndex reads an index written by Save. Case folding is restored as saved.
+//
+// Parameters:
+//   - r: The reader to read from.
+//   - tokenizer: The tokenizer of the saved index, used for queries and new documents. If
+//     nil, Words is used.
+//
+// Returns:
+//   - *InvertedIndex: The index.
+//   - error: An error if the index could not be read.
+//
+// Errors:
+//   - *gers.ErrBadParam: If r is nil.
+//   - *gers.ErrUnexpected: If the data is not a valid index.
+//   - any other error: If the reader fails.
+func LoadInvertedIndex(r io.Reader, tokenizer Tokenizer) (*InvertedIndex, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParam("r")
+	}
+
+	var snapshot invertedSnapshot
+
+	err := gob.NewDecoder(r).Decode(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.Version != invertedVersion {
+		return nil, gers.NewErrUnexpected("version", strconv.FormatUint(uint64(invertedVersion), 10), strconv.FormatUint(uint64(snapshot.Version), 10))
+	} else if len(snapshot.Postings) != len(snapshot.Terms) {
+		return nil, gers.NewErrUnexpected("number of postings", strconv.Itoa(len(snapshot.Terms)), strconv.Itoa(len(snapshot.Postings)))
+	}
+
+	ii := NewInvertedIndex(InvertedOptions{
+		Tokenizer: tokenizer,
+		FoldCase:  snapshot.FoldCase,
+	})
+
+	ii.lengths = snapshot.Lengths
+	ii.terms = snapshot.Terms
+
+	for _, length := range ii.lengths {
+		ii.total += length
+	}
+
+	for i, term := range ii.terms {
+		if i > 0 && ii.terms[i-1] >= term {
+			return nil, gers.NewErrUnexpected("terms", "in sorted order", strconv.Quote(term))
+		}
+
+		postings := snapshot.Postings[i]
+
+		for j, p := range postings {
+			if p.Doc >= uint(len(ii.lengths)) {
+				return nil, gers.NewErrUnexpected("document", "less than "+strconv.Itoa(len(ii.lengths)), strconv.FormatUint(uint64(p.Doc), 10))
+			} else if j > 0 && postings[j-1].Doc >= p.Doc {
+				return nil, gers.NewErrUnexpected("postings of "+strconv.Quote(term), "in document order", strconv.FormatUint(uint64(p.Doc), 10))
+			}
+		}
+
+		ii.postings[term] = snapshot.Postings[i]
+	}
+
+	return ii, nil
+}
@@ -0,0 +1,301 @@
+package indices
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/indices/internal"
+)
+
+// invertedDocs is the collection indexed by the tests.
+var invertedDocs = []string{
+	"go parser for json",
+	"json json json parser",
+	"yaml parser",
+	"a long document about parsing json and many other unrelated words",
+	"nothing relevant",
+}
+
+// newInverted indexes the documents.
+func newInverted(t *testing.T, opts InvertedOptions, docs []string) *InvertedIndex {
+	t.Helper()
+
+	ii := NewInvertedIndex(opts)
+
+	for i, doc := range docs {
+		if id := ii.Add(doc); id != uint(i) {
+			t.Fatalf("Add(%q) = %d; want %d", doc, id, i)
+		}
+	}
+
+	return ii
+}
+
+// search runs the query and returns the documents of the hits, in order.
+func search(t *testing.T, ii *InvertedIndex, query string) []uint {
+	t.Helper()
+
+	hits, err := ii.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+
+	var docs []uint
+
+	for _, hit := range hits {
+		docs = append(docs, hit.Doc)
+	}
+
+	return docs
+}
+
+// TestInvertedRanking tests that hits are ranked by BM25, and ties broken by document index.
+func TestInvertedRanking(t *testing.T) {
+	ii := newInverted(t, InvertedOptions{}, invertedDocs)
+
+	hits, err := ii.Search("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Document 1 repeats the term; document 0 is shorter than document 3.
+	want := []uint{1, 0, 3}
+
+	if len(hits) != len(want) {
+		t.Fatalf("Search(json) = %+v; want documents %v", hits, want)
+	}
+
+	for i, hit := range hits {
+		if hit.Doc != want[i] {
+			t.Errorf("hit %d = %+v; want document %d", i, hit, want[i])
+		}
+
+		if hit.Score <= 0 || (i > 0 && hit.Score >= hits[i-1].Score) {
+			t.Errorf("hit %d score = %g; want positive and strictly decreasing", i, hit.Score)
+		}
+	}
+
+	ties := newInverted(t, InvertedOptions{}, []string{"x", "a b", "b a", "a b"})
+
+	if got := search(t, ties, "a b"); !slices.Equal(got, []uint{1, 2, 3}) {
+		t.Errorf("Search(a b) over equal documents = %v; want [1 2 3]", got)
+	}
+}
+
+// TestInvertedOperators tests the evaluation of AND, OR, NOT and prefix queries.
+func TestInvertedOperators(t *testing.T) {
+	ii := newInverted(t, InvertedOptions{}, invertedDocs)
+
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		{"", nil},
+		{"parser json", []uint{0, 1}},
+		{"parser AND json", []uint{0, 1}},
+		{"json OR yaml", []uint{0, 1, 2, 3}},
+		{"parser -json", []uint{2}},
+		{"parser NOT json", []uint{2}},
+		{"NOT json", []uint{2, 4}},
+		{"NOT NOT yaml", []uint{2}},
+		{"-(json OR yaml)", []uint{4}},
+		{"pars*", []uint{0, 1, 2, 3}},
+		{"-pars*", []uint{4}},
+		{"parsing*", []uint{3}},
+		{"zzz*", nil},
+		{"missing", nil},
+		{"json OR missing", []uint{0, 1, 3}},
+	}
+
+	for _, test := range tests {
+		got := search(t, ii, test.query)
+		slices.Sort(got)
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Search(%q) = %v; want %v", test.query, got, test.want)
+		}
+	}
+
+	for _, query := range []string{"(json", "json)", "OR json", "json AND", "*", "-"} {
+		_, err := ii.Search(query)
+
+		var bad *ErrBadQuery
+
+		if !errors.As(err, &bad) {
+			t.Errorf("Search(%q) error = %v; want *ErrBadQuery", query, err)
+		}
+	}
+}
+
+// TestInvertedNegatedScore tests that negated terms do not count towards the score.
+func TestInvertedNegatedScore(t *testing.T) {
+	ii := newInverted(t, InvertedOptions{}, invertedDocs)
+
+	plain, err := ii.Search("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	negated, err := ii.Search("json -yaml -nothing*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(plain, negated) {
+		t.Errorf("Search(json -yaml -nothing*) = %+v; want %+v", negated, plain)
+	}
+
+	hits, err := ii.Search("NOT json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hit := range hits {
+		if hit.Score != 0 {
+			t.Errorf("Search(NOT json) hit %+v has a score; want 0", hit)
+		}
+	}
+}
+
+// TestInvertedFoldCase tests that FoldCase makes documents, queries and prefixes
+// case-insensitive, and that the index is case-sensitive otherwise.
+func TestInvertedFoldCase(t *testing.T) {
+	docs := []string{"JSON Parser", "json parser", "Éclair", "ÉCLAIR au café"}
+
+	folded := newInverted(t, InvertedOptions{FoldCase: true}, docs)
+	exact := newInverted(t, InvertedOptions{}, docs)
+
+	tests := []struct {
+		query         string
+		folded, exact []uint
+	}{
+		{"json", []uint{0, 1}, []uint{1}},
+		{"JSON", []uint{0, 1}, []uint{0}},
+		{"Js*", []uint{0, 1}, nil},
+		{"éclair", []uint{2, 3}, nil},
+		{"éCLAIR -CAFÉ", []uint{2}, nil},
+	}
+
+	for _, test := range tests {
+		got := search(t, folded, test.query)
+		slices.Sort(got)
+
+		if !slices.Equal(got, test.folded) {
+			t.Errorf("folded Search(%q) = %v; want %v", test.query, got, test.folded)
+		}
+
+		got = search(t, exact, test.query)
+		slices.Sort(got)
+
+		if !slices.Equal(got, test.exact) {
+			t.Errorf("exact Search(%q) = %v; want %v", test.query, got, test.exact)
+		}
+	}
+}
+
+// TestInvertedSaveLoad tests that a loaded index answers queries as the saved one did, and
+// keeps numbering the documents added afterwards.
+func TestInvertedSaveLoad(t *testing.T) {
+	tokenizer := func(text string) []string {
+		return strings.Fields(text)
+	}
+
+	ii := newInverted(t, InvertedOptions{Tokenizer: tokenizer, FoldCase: true}, invertedDocs)
+
+	var buf bytes.Buffer
+
+	err := ii.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadInvertedIndex(&buf, tokenizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Len() != ii.Len() {
+		t.Fatalf("Len() = %d; want %d", loaded.Len(), ii.Len())
+	}
+
+	for _, query := range []string{"JSON", "pars* -yaml", "NOT json", "json OR yaml", "missing"} {
+		want, err := ii.Search(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := loaded.Search(query)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("loaded Search(%q) = %+v, %v; want %+v, nil", query, got, err, want)
+		}
+	}
+
+	if id := loaded.Add("Another JSON document"); id != uint(len(invertedDocs)) {
+		t.Errorf("Add() after Load = %d; want %d", id, len(invertedDocs))
+	}
+
+	if got := search(t, loaded, "another"); !slices.Equal(got, []uint{uint(len(invertedDocs))}) {
+		t.Errorf("Search(another) after Add = %v; want [%d]", got, len(invertedDocs))
+	}
+
+	err = ii.Save(nil)
+	if err == nil {
+		t.Error("Save(nil) succeeded")
+	}
+}
+
+// TestInvertedLoadInvalid tests that LoadInvertedIndex rejects inconsistent data.
+func TestInvertedLoadInvalid(t *testing.T) {
+	tests := map[string]invertedSnapshot{
+		"version": {Version: invertedVersion + 1},
+		"postings": {
+			Version: invertedVersion,
+			Terms:   []string{"a"},
+		},
+		"unsorted terms": {
+			Version:  invertedVersion,
+			Lengths:  []uint{1, 1},
+			Terms:    []string{"b", "a"},
+			Postings: [][]internal.Posting{{{Doc: 0, Freq: 1}}, {{Doc: 1, Freq: 1}}},
+		},
+		"missing document": {
+			Version:  invertedVersion,
+			Lengths:  []uint{1},
+			Terms:    []string{"a"},
+			Postings: [][]internal.Posting{{{Doc: 1, Freq: 1}}},
+		},
+		"unsorted postings": {
+			Version:  invertedVersion,
+			Lengths:  []uint{1, 1},
+			Terms:    []string{"a"},
+			Postings: [][]internal.Posting{{{Doc: 1, Freq: 1}, {Doc: 0, Freq: 1}}},
+		},
+	}
+
+	for name, snapshot := range tests {
+		var buf bytes.Buffer
+
+		err := gob.NewEncoder(&buf).Encode(&snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = LoadInvertedIndex(&buf, nil)
+
+		var unexpected *gers.ErrUnexpected
+
+		if !errors.As(err, &unexpected) {
+			t.Errorf("%s: LoadInvertedIndex() error = %v; want *errors.ErrUnexpected", name, err)
+		}
+	}
+
+	_, err := LoadInvertedIndex(strings.NewReader("not gob"), nil)
+	if err == nil {
+		t.Error("LoadInvertedIndex of garbage succeeded")
+	}
+}
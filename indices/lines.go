@@ -0,0 +1,382 @@
+package indices
+
+import (
+	"cmp"
+	"strconv"
+	"unicode/utf8"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/indices/internal"
+)
+
+// Unit is a unit in which offsets and columns are measured.
+type Unit int
+
+const (
+	// Bytes measures in UTF-8 bytes, as Go strings are indexed.
+	Bytes Unit = iota
+
+	// Runes measures in runes, as []rune are indexed.
+	Runes
+
+	// UTF16 measures in UTF-16 code units, as the Language Server Protocol does.
+	UTF16
+)
+
+// String implements fmt.Stringer.
+func (u Unit) String() string {
+	switch u {
+	case Bytes:
+		return "Bytes"
+	case Runes:
+		return "Runes"
+	case UTF16:
+		return "UTF16"
+	default:
+		return "Unit(" + strconv.Itoa(int(u)) + ")"
+	}
+}
+
+// key returns the function that picks the length in this unit.
+//
+// Returns:
+//   - func(internal.Counts) uint: The picker.
+//   - error: An error if the unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the unit is not valid.
+func (u Unit) key() (func(internal.Counts) uint, error) {
+	switch u {
+	case Bytes:
+		return func(c internal.Counts) uint { return c.Bytes }, nil
+	case Runes:
+		return func(c internal.Counts) uint { return c.Runes }, nil
+	case UTF16:
+		return func(c internal.Counts) uint { return c.UTF16 }, nil
+	default:
+		return nil, gers.NewErrBadParam("unit", "must be Bytes, Runes or UTF16, got "+u.String())
+	}
+}
+
+// Position is a location in a text. Both fields are 0-based, and the column is
+// measured in the unit the position was asked in.
+type Position struct {
+	// Line is the line number.
+	Line uint
+
+	// Column is the offset within the line.
+	Column uint
+}
+
+// LineIndex maps offsets of a text to lines and columns, in O(log n) time. Lines are
+// separated by "\n"; a "\r" before it belongs to the terminator.
+//
+// A LineIndex is not safe for concurrent use while it is edited.
+type LineIndex struct {
+	// text is the indexed text.
+	text string
+
+	// starts are the offsets of the line starts. The first is always zero.
+	starts []internal.Counts
+
+	// total is the length of the text.
+	total internal.Counts
+}
+
+// NewLineIndex indexes the lines of the given text.
+//
+// Parameters:
+//   - text: The text to index.
+//
+// Returns:
+//   - *LineIndex: The index. Never returns nil.
+func NewLineIndex(text string) *LineIndex {
+	li := &LineIndex{
+		text:   text,
+		starts: append([]internal.Counts{{}}, internal.LineStarts(text, internal.Counts{})...),
+		total:  internal.Measure(text),
+	}
+
+	return li
+}
+
+// NewLineIndexRunes indexes the lines of the given text.
+//
+// Parameters:
+//   - text: The text to index.
+//
+// Returns:
+//   - *LineIndex: The index. Never returns nil.
+func NewLineIndexRunes(text []rune) *LineIndex {
+	return NewLineIndex(string(text))
+}
+
+// Text returns the indexed text.
+//
+// Returns:
+//   - string: The indexed text.
+func (li *LineIndex) Text() string {
+	return li.text
+}
+
+// LineCount returns the number of lines. A text has one more line than it has "\n".
+//
+// Returns:
+//   - uint: The number of lines. At least 1.
+func (li *LineIndex) LineCount() uint {
+	return uint(len(li.starts))
+}
+
+// Len returns the length of the text.
+//
+// Parameters:
+//   - unit: The unit to measure in.
+//
+// Returns:
+//   - uint: The length of the text.
+//   - error: An error if the unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the unit is not valid.
+func (li *LineIndex) Len(unit Unit) (uint, error) {
+	key, err := unit.key()
+	if err != nil {
+		return 0, err
+	}
+
+	return key(li.total), nil
+}
+
+// Position returns the line and column of the given offset.
+//
+// Parameters:
+//   - offset: The offset, from the start of the text. The length of the text is allowed.
+//   - unit: The unit of both the offset and the column.
+//
+// Returns:
+//   - Position: The position of the offset.
+//   - error: An error if the offset or the unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the unit is not valid or the offset is past the end of the text.
+func (li *LineIndex) Position(offset uint, unit Unit) (Position, error) {
+	key, err := unit.key()
+	if err != nil {
+		return Position{}, err
+	}
+
+	if offset > key(li.total) {
+		return Position{}, gers.NewErrBadParam("offset", "must not exceed the length of the text")
+	}
+
+	line := internal.UpperBound(li.starts, offset, func(c internal.Counts, target uint) int {
+		return cmp.Compare(key(c), target)
+	}) - 1
+
+	pos := Position{
+		Line:   line,
+		Column: offset - key(li.starts[line]),
+	}
+
+	return pos, nil
+}
+
+// Offset returns the offset of the given position; it is the inverse of Position.
+//
+// Parameters:
+//   - pos: The position. The column may point into the terminator of the line, but not past it.
+//   - unit: The unit of both the column and the offset.
+//
+// Returns:
+//   - uint: The offset of the position, from the start of the text.
+//   - error: An error if the position or the unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the unit is not valid or the position is outside of the text.
+func (li *LineIndex) Offset(pos Position, unit Unit) (uint, error) {
+	key, err := unit.key()
+	if err != nil {
+		return 0, err
+	}
+
+	start, limit, err := li.bounds(pos.Line)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := key(start) + pos.Column
+	if offset > key(limit) {
+		return 0, gers.NewErrBadParam("pos", "column is past the end of line "+strconv.FormatUint(uint64(pos.Line), 10))
+	}
+
+	return offset, nil
+}
+
+// Convert changes the unit of the column of the given position; for example, from the byte
+// columns of Go to the UTF-16 columns of the Language Server Protocol. It takes time linear in
+// the length of the line.
+//
+// Parameters:
+//   - pos: The position.
+//   - from: The unit of the column of pos.
+//   - to: The unit of the column of the result.
+//
+// Returns:
+//   - Position: The same position, with the column measured in to.
+//   - error: An error if the position or a unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If a unit is not valid, the position is outside of the text, or the
+//     column falls within a rune that to cannot split.
+func (li *LineIndex) Convert(pos Position, from, to Unit) (Position, error) {
+	from_key, err := from.key()
+	if err != nil {
+		return Position{}, err
+	}
+
+	to_key, err := to.key()
+	if err != nil {
+		return Position{}, err
+	}
+
+	start, limit, err := li.bounds(pos.Line)
+	if err != nil {
+		return Position{}, err
+	}
+
+	c, ok := internal.Advance(li.text[start.Bytes:limit.Bytes], pos.Column, from_key)
+	if !ok {
+		return Position{}, gers.NewErrBadParam("pos", "column is not at a rune boundary within line "+strconv.FormatUint(uint64(pos.Line), 10))
+	}
+
+	pos.Column = to_key(c)
+
+	return pos, nil
+}
+
+// Line returns the span of the given line, without its terminator.
+//
+// Parameters:
+//   - line: The 0-based line number.
+//   - unit: The unit of the span.
+//
+// Returns:
+//   - Span: The span of the line, from the start of the text.
+//   - error: An error if the line or the unit is not valid.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the unit is not valid or the line does not exist.
+func (li *LineIndex) Line(line uint, unit Unit) (Span, error) {
+	key, err := unit.key()
+	if err != nil {
+		return noSpan(), err
+	}
+
+	start, limit, err := li.bounds(line)
+	if err != nil {
+		return noSpan(), err
+	}
+
+	// The terminator is ASCII, so it has the same length in every unit.
+	end := key(limit)
+	if limit.Bytes > start.Bytes && li.text[limit.Bytes-1] == '\r' {
+		end--
+	}
+
+	return Span{Start: Index(key(start)), End: Index(end)}, nil
+}
+
+// bounds returns the offsets of the start of the given line and of its "\n", or of the end of
+// the text for the last line.
+//
+// Parameters:
+//   - line: The 0-based line number.
+//
+// Returns:
+//   - internal.Counts: The offset of the start of the line.
+//   - internal.Counts: The offset of the "\n" that ends the line.
+//   - error: An error if the line does not exist.
+//
+// Errors:
+//   - *gers.ErrBadParam: If the line does not exist.
+func (li *LineIndex) bounds(line uint) (internal.Counts, internal.Counts, error) {
+	if line >= uint(len(li.starts)) {
+		return internal.Counts{}, internal.Counts{}, gers.NewErrBadParam("line", "must be less than "+strconv.FormatUint(uint64(len(li.starts)), 10))
+	}
+
+	if line+1 == uint(len(li.starts)) {
+		return li.starts[line], li.total, nil
+	}
+
+	// Every line start but the first follows a one-unit "\n".
+	limit := li.starts[line+1].Minus(internal.Counts{Bytes: 1, Runes: 1, UTF16: 1})
+
+	return li.starts[line], limit, nil
+}
+
+// Edit replaces the bytes [start, end) of the text, and updates the index without rescanning
+// the text outside of the edit.
+//
+// Parameters:
+//   - start: The byte offset of the first replaced byte.
+//   - end: The byte offset past the last replaced byte.
+//   - replacement: The new text.
+//
+// Returns:
+//   - error: An error if the offsets are not valid.
+//
+// Errors:
+//   - gers.ErrNilReceiver: If the receiver is nil.
+//   - *gers.ErrBadParam: If start > end, end is past the end of the text, or either does
+//     not fall at a rune boundary.
+func (li *LineIndex) Edit(start, end uint, replacement string) error {
+	if li == nil {
+		return gers.ErrNilReceiver
+	}
+
+	if start > end || end > li.total.Bytes {
+		return gers.NewErrBadParam("end", "must lie within [start, length of the text]")
+	}
+
+	if !li.isBoundary(start) || !li.isBoundary(end) {
+		return gers.NewErrBadParam("start", "and end must fall at rune boundaries")
+	}
+
+	by_bytes := func(c internal.Counts, target uint) int {
+		return cmp.Compare(c.Bytes, target)
+	}
+
+	first := internal.UpperBound(li.starts, start, by_bytes) - 1
+	after := internal.UpperBound(li.starts, end, by_bytes)
+
+	at := li.starts[first].Plus(internal.Measure(li.text[li.starts[first].Bytes:start]))
+	removed := internal.Measure(li.text[start:end])
+	inserted := internal.Measure(replacement)
+
+	tail := li.starts[after:]
+
+	starts := make([]internal.Counts, 0, int(first)+1+len(tail))
+	starts = append(starts, li.starts[:first+1]...)
+	starts = append(starts, internal.LineStarts(replacement, at)...)
+
+	for _, c := range tail {
+		starts = append(starts, c.Minus(removed).Plus(inserted))
+	}
+
+	li.text = li.text[:start] + replacement + li.text[end:]
+	li.starts = starts
+	li.total = li.total.Minus(removed).Plus(inserted)
+
+	return nil
+}
+
+// isBoundary checks if the byte offset falls at a rune boundary.
+//
+// Parameters:
+//   - offset: The byte offset. Must not exceed the length of the text.
+//
+// Returns:
+//   - bool: True if offset is at a rune boundary, false otherwise.
+func (li *LineIndex) isBoundary(offset uint) bool {
+	return offset == uint(len(li.text)) || utf8.RuneStart(li.text[offset])
+}
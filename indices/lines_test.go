@@ -0,0 +1,245 @@
+package indices
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// linesText mixes one-, two- and four-byte runes with "\n" and "\r\n" terminators.
+const linesText = "aé\n\U0001F600b\r\n\nc"
+
+// TestLineIndexPosition tests Position and Offset in every unit, and that they are inverses.
+func TestLineIndexPosition(t *testing.T) {
+	li := NewLineIndex(linesText)
+
+	if got := li.LineCount(); got != 4 {
+		t.Fatalf("LineCount() = %d; want 4", got)
+	}
+
+	tests := []struct {
+		offset uint
+		unit   Unit
+		want   Position
+	}{
+		{0, Bytes, Position{0, 0}},
+		{3, Bytes, Position{0, 3}},
+		{4, Bytes, Position{1, 0}},
+		{8, Bytes, Position{1, 4}},
+		{11, Bytes, Position{2, 0}},
+		{12, Bytes, Position{3, 0}},
+		{13, Bytes, Position{3, 1}},
+		{3, Runes, Position{1, 0}},
+		{5, Runes, Position{1, 2}},
+		{9, Runes, Position{3, 1}},
+		{3, UTF16, Position{1, 0}},
+		{5, UTF16, Position{1, 2}},
+		{8, UTF16, Position{2, 0}},
+		{10, UTF16, Position{3, 1}},
+	}
+
+	for _, test := range tests {
+		got, err := li.Position(test.offset, test.unit)
+		if err != nil || got != test.want {
+			t.Errorf("Position(%d, %v) = %+v, %v; want %+v, nil", test.offset, test.unit, got, err, test.want)
+			continue
+		}
+
+		offset, err := li.Offset(got, test.unit)
+		if err != nil || offset != test.offset {
+			t.Errorf("Offset(%+v, %v) = %d, %v; want %d, nil", got, test.unit, offset, err, test.offset)
+		}
+	}
+
+	_, err := li.Position(14, Bytes)
+	if err == nil {
+		t.Error("Position past the end succeeded")
+	}
+
+	_, err = li.Position(0, Unit(7))
+	if err == nil {
+		t.Error("Position with an invalid unit succeeded")
+	}
+
+	_, err = li.Offset(Position{0, 4}, Bytes)
+	if err == nil {
+		t.Error("Offset past the terminator of line 0 succeeded")
+	}
+
+	_, err = li.Offset(Position{4, 0}, Bytes)
+	if err == nil {
+		t.Error("Offset of a missing line succeeded")
+	}
+}
+
+// TestLineIndexConvert tests Convert between units, and that it rejects columns within a rune.
+func TestLineIndexConvert(t *testing.T) {
+	li := NewLineIndex(linesText)
+
+	tests := []struct {
+		pos      Position
+		from, to Unit
+		want     Position
+	}{
+		{Position{0, 3}, Bytes, Runes, Position{0, 2}},
+		{Position{0, 3}, Bytes, UTF16, Position{0, 2}},
+		{Position{1, 4}, Bytes, UTF16, Position{1, 2}},
+		{Position{1, 4}, Bytes, Runes, Position{1, 1}},
+		{Position{1, 2}, UTF16, Bytes, Position{1, 4}},
+		{Position{1, 2}, Runes, UTF16, Position{1, 3}},
+		{Position{3, 1}, Runes, Bytes, Position{3, 1}},
+	}
+
+	for _, test := range tests {
+		got, err := li.Convert(test.pos, test.from, test.to)
+		if err != nil || got != test.want {
+			t.Errorf("Convert(%+v, %v, %v) = %+v, %v; want %+v, nil", test.pos, test.from, test.to, got, err, test.want)
+		}
+	}
+
+	bad := []struct {
+		pos      Position
+		from, to Unit
+	}{
+		{Position{0, 2}, Bytes, Runes},
+		{Position{1, 1}, UTF16, Bytes},
+		{Position{0, 9}, Bytes, Runes},
+		{Position{4, 0}, Bytes, Runes},
+		{Position{0, 0}, Bytes, Unit(-1)},
+	}
+
+	for _, test := range bad {
+		_, err := li.Convert(test.pos, test.from, test.to)
+		if err == nil {
+			t.Errorf("Convert(%+v, %v, %v) succeeded", test.pos, test.from, test.to)
+		}
+	}
+}
+
+// TestLineIndexLine tests that Line excludes both kinds of terminators.
+func TestLineIndexLine(t *testing.T) {
+	li := NewLineIndex(linesText)
+
+	want := []Span{
+		{Start: 0, End: 2},
+		{Start: 3, End: 5},
+		{Start: 7, End: 7},
+		{Start: 8, End: 9},
+	}
+
+	for line, want := range want {
+		got, err := li.Line(uint(line), Runes)
+		if err != nil || got != want {
+			t.Errorf("Line(%d, Runes) = %v, %v; want %v, nil", line, got, err, want)
+		}
+	}
+
+	got, err := li.Line(4, Runes)
+	if err == nil || got.IsValid() {
+		t.Errorf("Line(4, Runes) = %v, %v; want an invalid span and an error", got, err)
+	}
+}
+
+// checkEdit applies the edit to li and to a copy of its text, and checks that li matches a
+// LineIndex built from scratch over the edited text.
+func checkEdit(t *testing.T, li *LineIndex, start, end uint, replacement string) {
+	t.Helper()
+
+	text := li.Text()
+
+	err := li.Edit(start, end, replacement)
+	if err != nil {
+		t.Fatalf("Edit(%d, %d, %q) on %q: %v", start, end, replacement, text, err)
+	}
+
+	want := NewLineIndex(text[:start] + replacement + text[end:])
+
+	if li.text != want.text || li.total != want.total || !slices.Equal(li.starts, want.starts) {
+		t.Fatalf("Edit(%d, %d, %q) on %q = %q %+v %+v; want %q %+v %+v",
+			start, end, replacement, text, li.text, li.starts, li.total, want.text, want.starts, want.total)
+	}
+}
+
+// TestLineIndexEdit tests edits around line starts and "\r\n" terminators.
+func TestLineIndexEdit(t *testing.T) {
+	tests := []struct {
+		text        string
+		start, end  uint
+		replacement string
+	}{
+		{"a\nb\nc", 2, 2, "x"},
+		{"a\nb\nc", 2, 2, "x\n"},
+		{"a\nb\nc", 1, 2, ""},
+		{"a\nb\nc", 0, 5, ""},
+		{"a\nb\nc", 5, 5, "\n"},
+		{"a\r\nb", 2, 3, ""},
+		{"a\r\nb", 1, 2, ""},
+		{"a\rb", 2, 2, "\n"},
+		{"a\r\nb", 2, 2, "\n\r"},
+		{"é\n\U0001F600\r\nx", 3, 7, "\r\n\n"},
+		{"", 0, 0, "\r\n"},
+	}
+
+	for _, test := range tests {
+		checkEdit(t, NewLineIndex(test.text), test.start, test.end, test.replacement)
+	}
+
+	li := NewLineIndex("é")
+
+	err := li.Edit(1, 2, "")
+	if err == nil {
+		t.Error("Edit within a rune succeeded")
+	}
+
+	err = li.Edit(1, 0, "")
+	if err == nil {
+		t.Error("Edit with start > end succeeded")
+	}
+
+	err = li.Edit(0, 3, "")
+	if err == nil {
+		t.Error("Edit past the end succeeded")
+	}
+}
+
+// TestLineIndexEditRandom tests that any sequence of edits leaves the index as NewLineIndex
+// would build it.
+func TestLineIndexEditRandom(t *testing.T) {
+	pieces := []string{"a", "é", "\U0001F600", "\n", "\r", "\r\n"}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	random := func(max_len int) string {
+		var b strings.Builder
+
+		for range rng.IntN(max_len + 1) {
+			b.WriteString(pieces[rng.IntN(len(pieces))])
+		}
+
+		return b.String()
+	}
+
+	for range 200 {
+		li := NewLineIndex(random(10))
+
+		for range 20 {
+			var boundaries []uint
+
+			for i := range li.Text() {
+				boundaries = append(boundaries, uint(i))
+			}
+
+			boundaries = append(boundaries, uint(len(li.Text())))
+
+			start := boundaries[rng.IntN(len(boundaries))]
+			end := boundaries[rng.IntN(len(boundaries))]
+
+			if start > end {
+				start, end = end, start
+			}
+
+			checkEdit(t, li, start, end, random(4))
+		}
+	}
+}
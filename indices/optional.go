@@ -0,0 +1,290 @@
+package indices
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+)
+
+// Optional is a value of type T that may or may not be present. The zero value
+// is None.
+//
+// Unlike Index, which reserves MaxUint to mean None, an Optional can hold any
+// value of its type.
+type Optional[T any] struct {
+	// value is the value, if present.
+	value T
+
+	// present is whether the value is present.
+	present bool
+}
+
+// SomeOf creates an Optional with the given value.
+//
+// Parameters:
+//   - value: The value to wrap in the Optional.
+//
+// Returns:
+//   - Optional[T]: The newly created Optional.
+func SomeOf[T any](value T) Optional[T] {
+	return Optional[T]{
+		value:   value,
+		present: true,
+	}
+}
+
+// NoneOf creates an Optional with no value.
+//
+// Returns:
+//   - Optional[T]: The newly created empty Optional.
+func NoneOf[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// IsPresent checks if the Optional is not empty.
+//
+// Returns:
+//   - bool: True if the Optional is not empty, false otherwise.
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+
+// IsZero checks if the Optional is empty. This lets the "omitzero" option of
+// encoding/json omit empty Optionals.
+//
+// Returns:
+//   - bool: True if the Optional is empty, false otherwise.
+func (o Optional[T]) IsZero() bool {
+	return !o.present
+}
+
+// Get retrieves the value of the Optional if it is present.
+//
+// Returns:
+//   - T: The value of the Optional.
+//   - error: An error if the Optional is empty.
+//
+// Errors:
+//   - ErrMissingValue: If the Optional is empty.
+func (o Optional[T]) Get() (T, error) {
+	if !o.present {
+		return *new(T), ErrMissingValue
+	}
+
+	return o.value, nil
+}
+
+// OrElse returns the value of the Optional if it is not empty, otherwise it returns the fallback value.
+//
+// Parameters:
+//   - fallback: The value to return if the Optional is empty.
+//
+// Returns:
+//   - T: The value of the Optional if not empty, otherwise fallback.
+func (o Optional[T]) OrElse(fallback T) T {
+	if o.present {
+		return o.value
+	}
+
+	return fallback
+}
+
+// MustGet returns the value of the Optional if it is present, or panics if it is empty.
+//
+// Returns:
+//   - T: The value of the Optional.
+//
+// Panics:
+//   - ErrMissingValue: If the Optional is empty.
+func (o Optional[T]) MustGet() T {
+	if !o.present {
+		panic(ErrMissingValue)
+	}
+
+	return o.value
+}
+
+// Filter returns the Optional if it is present and its value satisfies the predicate, or an
+// empty Optional otherwise.
+//
+// Parameters:
+//   - predicate: The predicate to check the value against.
+//
+// Returns:
+//   - Optional[T]: The filtered Optional.
+//
+// Panics:
+//   - ErrNilParam: If the Optional is present and predicate is nil.
+func (o Optional[T]) Filter(predicate func(T) bool) Optional[T] {
+	if !o.present {
+		return o
+	}
+
+	if predicate == nil {
+		panic(gers.NewErrNilParam("predicate"))
+	}
+
+	ok := predicate(o.value)
+	if !ok {
+		return Optional[T]{}
+	}
+
+	return o
+}
+
+// Map applies the function to the value of the Optional, if present.
+//
+// Parameters:
+//   - o: The Optional to map.
+//   - fn: The function to apply.
+//
+// Returns:
+//   - Optional[U]: The result of fn, or an empty Optional if o is empty.
+//
+// Panics:
+//   - ErrNilParam: If o is present and fn is nil.
+func Map[T, U any](o Optional[T], fn func(T) U) Optional[U] {
+	if !o.present {
+		return Optional[U]{}
+	}
+
+	if fn == nil {
+		panic(gers.NewErrNilParam("fn"))
+	}
+
+	return SomeOf(fn(o.value))
+}
+
+// FlatMap applies the function to the value of the Optional, if present, and returns its result
+// as is.
+//
+// Parameters:
+//   - o: The Optional to map.
+//   - fn: The function to apply.
+//
+// Returns:
+//   - Optional[U]: The result of fn, or an empty Optional if o is empty.
+//
+// Panics:
+//   - ErrNilParam: If o is present and fn is nil.
+func FlatMap[T, U any](o Optional[T], fn func(T) Optional[U]) Optional[U] {
+	if !o.present {
+		return Optional[U]{}
+	}
+
+	if fn == nil {
+		panic(gers.NewErrNilParam("fn"))
+	}
+
+	return fn(o.value)
+}
+
+// Format implements fmt.Formatter. An empty Optional is formatted as "None"; otherwise, the value
+// is formatted as if it were passed directly.
+func (o Optional[T]) Format(s fmt.State, verb rune) {
+	if !o.present {
+		_, _ = fmt.Fprint(s, "None")
+		return
+	}
+
+	_, _ = fmt.Fprintf(s, fmt.FormatString(s, verb), o.value)
+}
+
+// MarshalJSON implements json.Marshaler. An empty Optional is encoded as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A null is decoded as an empty Optional.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if o == nil {
+		return gers.ErrNilReceiver
+	}
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Optional[T]{}
+		return nil
+	}
+
+	var value T
+
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	*o = SomeOf(value)
+
+	return nil
+}
+
+// Scan implements sql.Scanner. A NULL is scanned as an empty Optional; any other value is
+// converted as database/sql would convert it into a T.
+func (o *Optional[T]) Scan(src any) error {
+	if o == nil {
+		return gers.ErrNilReceiver
+	}
+
+	var null sql.Null[T]
+
+	err := null.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	*o = Optional[T]{
+		value:   null.V,
+		present: null.Valid,
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer. An empty Optional is stored as NULL; any other value is
+// converted with driver.DefaultParameterConverter.
+func (o Optional[T]) Value() (driver.Value, error) {
+	if !o.present {
+		return nil, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(o.value)
+}
+
+// ToOptional converts the Index into an Optional.
+//
+// Returns:
+//   - Optional[uint]: The Optional holding the value of the Index, if any.
+func (idx Index) ToOptional() Optional[uint] {
+	underlying := uint(idx)
+	if underlying == MaxUint {
+		return Optional[uint]{}
+	}
+
+	return SomeOf(underlying)
+}
+
+// FromOptional converts the Optional into an Index. Since Index reserves MaxUint to represent
+// an empty index, an Optional holding MaxUint becomes empty.
+//
+// Parameters:
+//   - o: The Optional to convert.
+//
+// Returns:
+//   - Index: The Index holding the value of the Optional, if any.
+func FromOptional(o Optional[uint]) Index {
+	if !o.present {
+		return None()
+	}
+
+	idx, _ := Some(o.value)
+
+	return idx
+}
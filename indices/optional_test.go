@@ -0,0 +1,211 @@
+package indices
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestOptionalGet tests Get, OrElse and MustGet on present and empty Optionals.
+func TestOptionalGet(t *testing.T) {
+	some := SomeOf(0)
+
+	value, err := some.Get()
+	if err != nil || value != 0 || !some.IsPresent() || some.IsZero() {
+		t.Errorf("SomeOf(0).Get() = %d, %v; want 0, nil", value, err)
+	}
+
+	none := NoneOf[int]()
+
+	_, err = none.Get()
+	if !errors.Is(err, ErrMissingValue) || none.IsPresent() || !none.IsZero() {
+		t.Errorf("NoneOf().Get() error = %v; want %v", err, ErrMissingValue)
+	}
+
+	if got := some.OrElse(7); got != 0 {
+		t.Errorf("SomeOf(0).OrElse(7) = %d; want 0", got)
+	}
+
+	if got := none.OrElse(7); got != 7 {
+		t.Errorf("NoneOf().OrElse(7) = %d; want 7", got)
+	}
+
+	defer func() {
+		r := recover()
+		if r != ErrMissingValue {
+			t.Errorf("NoneOf().MustGet() panicked with %v; want %v", r, ErrMissingValue)
+		}
+	}()
+
+	_ = none.MustGet()
+}
+
+// TestOptionalCombinators tests Map, FlatMap and Filter.
+func TestOptionalCombinators(t *testing.T) {
+	double := func(x int) int { return 2 * x }
+	half := func(x int) Optional[int] {
+		if x%2 != 0 {
+			return NoneOf[int]()
+		}
+
+		return SomeOf(x / 2)
+	}
+	positive := func(x int) bool { return x > 0 }
+
+	tests := []struct {
+		name string
+		got  Optional[int]
+		want Optional[int]
+	}{
+		{"Map some", Map(SomeOf(3), double), SomeOf(6)},
+		{"Map none", Map(NoneOf[int](), double), NoneOf[int]()},
+		{"Map none nil", Map[int, int](NoneOf[int](), nil), NoneOf[int]()},
+		{"FlatMap some", FlatMap(SomeOf(4), half), SomeOf(2)},
+		{"FlatMap some to none", FlatMap(SomeOf(3), half), NoneOf[int]()},
+		{"FlatMap none", FlatMap(NoneOf[int](), half), NoneOf[int]()},
+		{"Filter kept", SomeOf(1).Filter(positive), SomeOf(1)},
+		{"Filter dropped", SomeOf(0).Filter(positive), NoneOf[int]()},
+		{"Filter none", NoneOf[int]().Filter(nil), NoneOf[int]()},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v; want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+// TestOptionalFormat tests that an Optional formats as its value or as "None".
+func TestOptionalFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  any
+		want   string
+	}{
+		{"%v", SomeOf(42), "42"},
+		{"%03d", SomeOf(7), "007"},
+		{"%q", SomeOf("a"), `"a"`},
+		{"%v", NoneOf[int](), "None"},
+		{"%d", NoneOf[int](), "None"},
+	}
+
+	for _, test := range tests {
+		got := fmt.Sprintf(test.format, test.value)
+		if got != test.want {
+			t.Errorf("Sprintf(%q, %#v) = %q; want %q", test.format, test.value, got, test.want)
+		}
+	}
+}
+
+// TestOptionalJSON tests that null round-trips as None and zero values as Some.
+func TestOptionalJSON(t *testing.T) {
+	type record struct {
+		A Optional[int]    `json:"a"`
+		B Optional[string] `json:"b"`
+		C Optional[int]    `json:"c,omitzero"`
+	}
+
+	tests := []struct {
+		value record
+		data  string
+	}{
+		{record{SomeOf(0), SomeOf(""), SomeOf(0)}, `{"a":0,"b":"","c":0}`},
+		{record{NoneOf[int](), NoneOf[string](), NoneOf[int]()}, `{"a":null,"b":null}`},
+		{record{SomeOf(5), NoneOf[string](), SomeOf(-1)}, `{"a":5,"b":null,"c":-1}`},
+	}
+
+	for _, test := range tests {
+		data, err := json.Marshal(test.value)
+		if err != nil || string(data) != test.data {
+			t.Errorf("Marshal(%v) = %s, %v; want %s, nil", test.value, data, err, test.data)
+			continue
+		}
+
+		var got record
+
+		err = json.Unmarshal(data, &got)
+		if err != nil || got != test.value {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v, nil", data, got, err, test.value)
+		}
+	}
+
+	var o Optional[int]
+
+	err := json.Unmarshal([]byte(`"x"`), &o)
+	if err == nil {
+		t.Error("Unmarshal of a string into Optional[int] succeeded")
+	}
+}
+
+// TestOptionalSQL tests Scan and Value against sql.Null, which they mirror.
+func TestOptionalSQL(t *testing.T) {
+	tests := []struct {
+		src  any
+		want Optional[int64]
+	}{
+		{nil, NoneOf[int64]()},
+		{int64(0), SomeOf(int64(0))},
+		{int64(42), SomeOf(int64(42))},
+		{[]byte("17"), SomeOf(int64(17))},
+	}
+
+	for _, test := range tests {
+		var got Optional[int64]
+
+		err := got.Scan(test.src)
+		if err != nil || got != test.want {
+			t.Errorf("Scan(%v) = %v, %v; want %v, nil", test.src, got, err, test.want)
+			continue
+		}
+
+		var null sql.Null[int64]
+
+		_ = null.Scan(test.src)
+
+		want, _ := null.Value()
+
+		value, err := got.Value()
+		if err != nil || value != want {
+			t.Errorf("%v.Value() = %v, %v; want %v, nil", got, value, err, want)
+		}
+	}
+
+	var got Optional[int64]
+
+	err := got.Scan("not a number")
+	if err == nil {
+		t.Error("Scan of a non-numeric string succeeded")
+	}
+
+	value, err := SomeOf(uint8(3)).Value()
+	if err != nil || value != driver.Value(int64(3)) {
+		t.Errorf("SomeOf(uint8(3)).Value() = %v, %v; want 3, nil", value, err)
+	}
+}
+
+// TestOptionalIndex tests the conversions between Index and Optional.
+func TestOptionalIndex(t *testing.T) {
+	idx, err := Some(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := idx.ToOptional(); got != SomeOf[uint](3) {
+		t.Errorf("ToOptional() = %v; want 3", got)
+	}
+
+	if got := None().ToOptional(); got.IsPresent() {
+		t.Errorf("None().ToOptional() = %v; want None", got)
+	}
+
+	if got := FromOptional(SomeOf[uint](3)); got != idx {
+		t.Errorf("FromOptional(3) = %v; want %v", got, idx)
+	}
+
+	if got := FromOptional(SomeOf(MaxUint)); got.IsPresent() {
+		t.Errorf("FromOptional(MaxUint) = %v; want None", got)
+	}
+}
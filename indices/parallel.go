@@ -0,0 +1,62 @@
+package indices
+
+import (
+	"context"
+	"runtime"
+
+	gers "github.com/PlayerR9/mygo-lib/errors"
+	"github.com/PlayerR9/mygo-lib/indices/internal"
+)
+
+// ParallelThreshold is the length of a slice below which ParallelFirstIndexOf searches
+// sequentially, since starting workers would cost more than it saves.
+const ParallelThreshold int = 1 << 14
+
+// ParallelFirstIndexOf is FirstIndexOf for large slices and expensive predicates: it runs the
+// predicate on several workers, yet still returns exactly the lowest matching index. Workers
+// stop scanning past the lowest hit found so far.
+//
+// Parameters:
+//   - ctx: The context. When done, the search stops.
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching. Must be safe for concurrent use.
+//   - workers: The number of workers. If not positive, runtime.GOMAXPROCS(0) is used.
+//
+// Returns:
+//   - Index: The first index of the slice that satisfies the predicate, or an empty index if none do.
+//   - error: An error if the search could not complete.
+//
+// Errors:
+//   - *gers.ErrBadParam: If ctx is nil.
+//   - ctx.Err(): If the context is done before the search ends.
+func ParallelFirstIndexOf[S ~[]E, E any](ctx context.Context, s S, predicate func(e E) bool, workers int) (Index, error) {
+	if ctx == nil {
+		return None(), gers.NewErrNilParam("ctx")
+	}
+
+	if len(s) == 0 || predicate == nil {
+		return None(), nil
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var idx uint
+	var ok bool
+	var err error
+
+	if workers == 1 || len(s) < ParallelThreshold {
+		idx, ok, err = internal.FirstIndexOfCtx(ctx, s, predicate)
+	} else {
+		idx, ok, err = internal.ParallelFirstIndexOf(ctx, s, predicate, workers)
+	}
+
+	if err != nil {
+		return None(), err
+	} else if !ok {
+		return None(), nil
+	}
+
+	return Index(idx), nil
+}
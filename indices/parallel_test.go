@@ -0,0 +1,139 @@
+package indices
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParallelFirstIndexOf tests that the lowest matching index wins whichever worker finds a
+// match first, for any number of workers.
+func TestParallelFirstIndexOf(t *testing.T) {
+	s := make([]bool, 1<<18)
+
+	for _, i := range []int{250_000, 100_001, 180_000, 100_002} {
+		s[i] = true
+	}
+
+	is_set := func(b bool) bool { return b }
+
+	for _, workers := range []int{-1, 0, 1, 2, 4, 16} {
+		got, err := ParallelFirstIndexOf(context.Background(), s, is_set, workers)
+		if err != nil || got != 100_001 {
+			t.Errorf("workers = %d: got %v, %v; want 100001, nil", workers, got, err)
+		}
+	}
+
+	got, err := ParallelFirstIndexOf(context.Background(), make([]bool, 1<<18), is_set, 4)
+	if err != nil || got.IsPresent() {
+		t.Errorf("no match: got %v, %v; want None, nil", got, err)
+	}
+
+	got, err = ParallelFirstIndexOf(context.Background(), s, nil, 4)
+	if err != nil || got.IsPresent() {
+		t.Errorf("nil predicate: got %v, %v; want None, nil", got, err)
+	}
+
+	var nil_ctx context.Context
+
+	_, err = ParallelFirstIndexOf(nil_ctx, s, is_set, 4)
+	if err == nil {
+		t.Error("nil ctx: expected an error")
+	}
+}
+
+// TestParallelFirstIndexOfCtx tests that a done context is reported, both before and during the
+// search, and both above and below ParallelThreshold.
+func TestParallelFirstIndexOfCtx(t *testing.T) {
+	never := func(int) bool { return false }
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for _, n := range []int{ParallelThreshold / 2, ParallelThreshold * 4} {
+		s := make([]int, n)
+
+		_, err := ParallelFirstIndexOf(cancelled, s, never, 4)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("len %d, cancelled: got %v; want %v", n, err, context.Canceled)
+		}
+
+		_, err = ParallelFirstIndexOf(expired, s, never, 4)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("len %d, expired: got %v; want %v", n, err, context.DeadlineExceeded)
+		}
+
+		// The context is cancelled by the predicate itself, half-way through the slice.
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var once sync.Once
+
+		cancelling := func(x int) bool {
+			if x >= n/2 {
+				once.Do(cancel)
+			}
+
+			return false
+		}
+
+		for i := range s {
+			s[i] = i
+		}
+
+		got, err := ParallelFirstIndexOf(ctx, s, cancelling, 4)
+		if !errors.Is(err, context.Canceled) || got.IsPresent() {
+			t.Errorf("len %d, cancelled during the search: got %v, %v; want None, %v", n, got, err, context.Canceled)
+		}
+
+		cancel()
+	}
+}
+
+// TestParallelFirstIndexOfSequential tests that slices below ParallelThreshold, or a single
+// worker, are searched in order and stop at the first match.
+func TestParallelFirstIndexOfSequential(t *testing.T) {
+	tests := []struct {
+		n       int
+		workers int
+	}{
+		{ParallelThreshold - 1, 8},
+		{ParallelThreshold * 2, 1},
+	}
+
+	for _, test := range tests {
+		s := make([]int, test.n)
+		for i := range s {
+			s[i] = i
+		}
+
+		var mu sync.Mutex
+		var visited []int
+
+		match := test.n - 10
+
+		pred := func(x int) bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			visited = append(visited, x)
+
+			return x >= match
+		}
+
+		got, err := ParallelFirstIndexOf(context.Background(), s, pred, test.workers)
+		if err != nil || got != Index(match) {
+			t.Errorf("len %d, %d workers: got %v, %v; want %d, nil", test.n, test.workers, got, err, match)
+			continue
+		}
+
+		if !slices.Equal(visited, s[:match+1]) {
+			t.Errorf("len %d, %d workers: visited %d elements out of order or past the match", test.n, test.workers, len(visited))
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package indices
 
-import "github.com/PlayerR9/mygo-lib/indices/internal"
+import (
+	"iter"
+	"slices"
+
+	"github.com/PlayerR9/mygo-lib/indices/internal"
+)
 
 // FirstIndexOf returns the first index of the slice for which the predicate returns true, or an empty index
 // if no element satisfies the predicate.
@@ -21,5 +26,121 @@ func FirstIndexOf[S ~[]E, E any](s S, predicate func(e E) bool) Index {
 		return None()
 	}
 
-	return Some(idx)
+	// A slice index never exceeds math.MaxInt, so it cannot be MaxUint.
+	return Index(idx)
+}
+
+// LastIndexOf returns the last index of the slice for which the predicate returns true, or an empty index
+// if no element satisfies the predicate.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - Index: The last index of the slice that satisfies the predicate, or an empty index if none do.
+func LastIndexOf[S ~[]E, E any](s S, predicate func(e E) bool) Index {
+	if len(s) == 0 || predicate == nil {
+		return None()
+	}
+
+	idx, ok := internal.LastIndexOf(s, predicate)
+	if !ok {
+		return None()
+	}
+
+	return Index(idx)
+}
+
+// NthIndexOf returns the index of the n-th element of the slice, counting from 0, for which the
+// predicate returns true, or an empty index if fewer elements satisfy the predicate.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - n: The number of matches to skip.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - Index: The index of the n-th element that satisfies the predicate, or an empty index.
+func NthIndexOf[S ~[]E, E any](s S, n uint, predicate func(e E) bool) Index {
+	if len(s) == 0 || predicate == nil {
+		return None()
+	}
+
+	idx, ok := internal.NthIndexOf(s, n, predicate)
+	if !ok {
+		return None()
+	}
+
+	return Index(idx)
+}
+
+// AllIndicesOf returns an iterator over the indices of the slice for which the predicate returns true,
+// in increasing order.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - iter.Seq[uint]: The indices of the elements that satisfy the predicate. Never returns nil.
+func AllIndicesOf[S ~[]E, E any](s S, predicate func(e E) bool) iter.Seq[uint] {
+	if predicate == nil {
+		return func(yield func(uint) bool) {}
+	}
+
+	return internal.AllIndicesOf(s, predicate)
+}
+
+// CountMatching returns the number of elements of the slice for which the predicate returns true.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - predicate: The predicate to use when searching.
+//
+// Returns:
+//   - uint: The number of elements that satisfy the predicate. 0 if predicate is nil.
+func CountMatching[S ~[]E, E any](s S, predicate func(e E) bool) uint {
+	if predicate == nil {
+		return 0
+	}
+
+	return internal.CountMatching(s, predicate)
+}
+
+// IndexOfValue returns the first index of the slice whose element equals value, or an empty index
+// if there is none.
+//
+// Parameters:
+//   - s: The slice to search.
+//   - value: The value to search for.
+//
+// Returns:
+//   - Index: The first index of value in the slice, or an empty index if it does not occur.
+func IndexOfValue[S ~[]E, E comparable](s S, value E) Index {
+	idx := slices.Index(s, value)
+	if idx < 0 {
+		return None()
+	}
+
+	return Index(idx)
+}
+
+// IndexOfSubslice returns the first index at which sub occurs in s, or an empty index if it does
+// not occur. An empty sub occurs at 0. Long needles are searched for with the Knuth-Morris-Pratt
+// algorithm, so the search never degrades to O(len(s) * len(sub)).
+//
+// Parameters:
+//   - s: The slice to search.
+//   - sub: The subslice to search for.
+//
+// Returns:
+//   - Index: The first index of sub in s, or an empty index if it does not occur.
+func IndexOfSubslice[S ~[]E, E comparable](s, sub S) Index {
+	idx, ok := internal.IndexOfSubslice(s, sub)
+	if !ok {
+		return None()
+	}
+
+	return Index(idx)
 }
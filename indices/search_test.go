@@ -0,0 +1,156 @@
+package indices
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// isEven is the predicate of the tests.
+func isEven(x int) bool {
+	return x%2 == 0
+}
+
+// TestIndexOf tests FirstIndexOf, LastIndexOf and NthIndexOf, including when nothing matches.
+func TestIndexOf(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5, 6, 7}
+	odd := []int{1, 3, 5}
+
+	tests := []struct {
+		name string
+		got  Index
+		want Index
+	}{
+		{"FirstIndexOf", FirstIndexOf(s, isEven), 1},
+		{"FirstIndexOf no match", FirstIndexOf(odd, isEven), None()},
+		{"FirstIndexOf empty", FirstIndexOf([]int(nil), isEven), None()},
+		{"FirstIndexOf nil predicate", FirstIndexOf(s, nil), None()},
+		{"LastIndexOf", LastIndexOf(s, isEven), 5},
+		{"LastIndexOf no match", LastIndexOf(odd, isEven), None()},
+		{"LastIndexOf nil predicate", LastIndexOf(s, nil), None()},
+		{"NthIndexOf 0", NthIndexOf(s, 0, isEven), 1},
+		{"NthIndexOf 2", NthIndexOf(s, 2, isEven), 5},
+		{"NthIndexOf past the matches", NthIndexOf(s, 3, isEven), None()},
+		{"NthIndexOf no match", NthIndexOf(odd, 0, isEven), None()},
+		{"NthIndexOf nil predicate", NthIndexOf(s, 0, nil), None()},
+		{"IndexOfValue", IndexOfValue(s, 4), 3},
+		{"IndexOfValue first of duplicates", IndexOfValue([]int{7, 7}, 7), 0},
+		{"IndexOfValue missing", IndexOfValue(s, 9), None()},
+		{"IndexOfValue empty", IndexOfValue([]int{}, 0), None()},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v; want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+// TestAllIndicesOf tests AllIndicesOf and CountMatching, and that the iteration stops when asked.
+func TestAllIndicesOf(t *testing.T) {
+	s := []int{2, 3, 4, 6, 7, 8}
+
+	got := slices.Collect(AllIndicesOf(s, isEven))
+	if want := []uint{0, 2, 3, 5}; !slices.Equal(got, want) {
+		t.Errorf("AllIndicesOf = %v; want %v", got, want)
+	}
+
+	if got := slices.Collect(AllIndicesOf(s, nil)); len(got) != 0 {
+		t.Errorf("AllIndicesOf with a nil predicate = %v; want nothing", got)
+	}
+
+	var calls int
+
+	counting := func(x int) bool {
+		calls++
+		return isEven(x)
+	}
+
+	var first []uint
+
+	for idx := range AllIndicesOf(s, counting) {
+		first = append(first, idx)
+
+		if len(first) == 2 {
+			break
+		}
+	}
+
+	if !slices.Equal(first, []uint{0, 2}) || calls != 3 {
+		t.Errorf("AllIndicesOf with a break = %v after %d calls; want [0 2] after 3", first, calls)
+	}
+
+	tests := []struct {
+		s    []int
+		pred func(int) bool
+		want uint
+	}{
+		{s, isEven, 4},
+		{[]int{1, 3}, isEven, 0},
+		{nil, isEven, 0},
+		{s, nil, 0},
+	}
+
+	for _, test := range tests {
+		if got := CountMatching(test.s, test.pred); got != test.want {
+			t.Errorf("CountMatching(%v) = %d; want %d", test.s, got, test.want)
+		}
+	}
+}
+
+// naiveSubslice is the reference IndexOfSubslice is checked against.
+func naiveSubslice(s, sub []byte) Index {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if slices.Equal(s[i:i+len(sub)], sub) {
+			return Index(i)
+		}
+	}
+
+	return None()
+}
+
+// TestIndexOfSubslice tests IndexOfSubslice with short needles and with long ones, which take the
+// Knuth-Morris-Pratt path.
+func TestIndexOfSubslice(t *testing.T) {
+	tests := []struct {
+		s, sub string
+		want   Index
+	}{
+		{"abc", "", 0},
+		{"", "a", None()},
+		{"abcabd", "abd", 3},
+		{"ab", "abc", None()},
+		{"aaaaaaaaaaaaaaaab", "aaaaaaaaab", 7},
+		{"abababababababac", "abababababac", 4},
+		{"abcdefghijklmnop", "abcdefghijklmnoq", None()},
+		{"xxabcdefghijabcdefghijk", "abcdefghijk", 12},
+	}
+
+	for _, test := range tests {
+		got := IndexOfSubslice([]byte(test.s), []byte(test.sub))
+		if got != test.want {
+			t.Errorf("IndexOfSubslice(%q, %q) = %v; want %v", test.s, test.sub, got, test.want)
+		}
+	}
+
+	// A two-letter alphabet makes long partial matches, which exercise the failure function.
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "ab"[rng.IntN(2)]
+		}
+
+		return b
+	}
+
+	for range 500 {
+		s := random(rng.IntN(64))
+		sub := random(8 + rng.IntN(5))
+
+		if got, want := IndexOfSubslice(s, sub), naiveSubslice(s, sub); got != want {
+			t.Fatalf("IndexOfSubslice(%q, %q) = %v; want %v", s, sub, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package indices
+
+// Span is the half-open range of indices [Start, End). A Span is valid when
+// both bounds are present and Start <= End; the methods treat an invalid Span
+// as containing nothing. On failure, the methods return a Span whose bounds
+// are both empty, since the zero Span is the valid empty span [0, 0).
+type Span struct {
+	// Start is the first index of the span.
+	Start Index
+
+	// End is the index past the last one of the span.
+	End Index
+}
+
+// noSpan returns the invalid span that the methods return on failure.
+//
+// Returns:
+//   - Span: The invalid span.
+func noSpan() Span {
+	return Span{Start: None(), End: None()}
+}
+
+// IsValid checks if both bounds of the span are present and ordered.
+//
+// Returns:
+//   - bool: True if the span is valid, false otherwise.
+func (s Span) IsValid() bool {
+	return s.Start.IsPresent() && s.End.IsPresent() && s.Start <= s.End
+}
+
+// Len returns the number of indices in the span.
+//
+// Returns:
+//   - Index: The length of the span, or an empty index if the span is invalid.
+func (s Span) Len() Index {
+	ok := s.IsValid()
+	if !ok {
+		return None()
+	}
+
+	return Index(uint(s.End) - uint(s.Start))
+}
+
+// Contains checks if the index lies within the span.
+//
+// Parameters:
+//   - idx: The index to check.
+//
+// Returns:
+//   - bool: True if the span is valid and contains idx, false otherwise.
+func (s Span) Contains(idx Index) bool {
+	ok := s.IsValid()
+	if !ok || !idx.IsPresent() {
+		return false
+	}
+
+	return s.Start <= idx && idx < s.End
+}
+
+// Intersect returns the indices that lie in both spans.
+//
+// Parameters:
+//   - other: The other span.
+//
+// Returns:
+//   - Span: The intersection.
+//   - bool: False if either span is invalid or the intersection is empty, true otherwise.
+func (s Span) Intersect(other Span) (Span, bool) {
+	if !s.IsValid() || !other.IsValid() {
+		return noSpan(), false
+	}
+
+	inter := Span{
+		Start: max(s.Start, other.Start),
+		End:   min(s.End, other.End),
+	}
+
+	if inter.Start >= inter.End {
+		return noSpan(), false
+	}
+
+	return inter, true
+}
+
+// Union returns the smallest span covering both spans, provided that they
+// overlap or touch; otherwise, the result would include indices from neither.
+//
+// Parameters:
+//   - other: The other span.
+//
+// Returns:
+//   - Span: The union.
+//   - bool: False if either span is invalid or the spans are disjoint, true otherwise.
+func (s Span) Union(other Span) (Span, bool) {
+	if !s.IsValid() || !other.IsValid() {
+		return noSpan(), false
+	}
+
+	if s.End < other.Start || other.End < s.Start {
+		return noSpan(), false
+	}
+
+	union := Span{
+		Start: min(s.Start, other.Start),
+		End:   max(s.End, other.End),
+	}
+
+	return union, true
+}
+
+// Split divides the span at the given index, into [Start, at) and [at, End).
+//
+// Parameters:
+//   - at: The index to split at. Must lie within [Start, End].
+//
+// Returns:
+//   - Span: The part before at.
+//   - Span: The part from at onwards.
+//   - bool: False if the span is invalid or at lies outside of it, true otherwise.
+func (s Span) Split(at Index) (Span, Span, bool) {
+	ok := s.IsValid()
+	if !ok || !at.IsPresent() || at < s.Start || at > s.End {
+		return noSpan(), noSpan(), false
+	}
+
+	return Span{Start: s.Start, End: at}, Span{Start: at, End: s.End}, true
+}
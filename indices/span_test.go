@@ -0,0 +1,179 @@
+package indices
+
+import (
+	"errors"
+	"testing"
+)
+
+// idx returns the index holding value, failing the test if it cannot.
+func idx(t *testing.T, value uint) Index {
+	t.Helper()
+
+	i, err := Some(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return i
+}
+
+// span returns the span [start, end).
+func span(t *testing.T, start, end uint) Span {
+	t.Helper()
+
+	return Span{Start: idx(t, start), End: idx(t, end)}
+}
+
+// isNoSpan checks whether both bounds of the span are empty.
+func isNoSpan(s Span) bool {
+	return !s.Start.IsPresent() && !s.End.IsPresent()
+}
+
+// TestSome tests that Some rejects the sentinel.
+func TestSome(t *testing.T) {
+	i, err := Some(MaxUint)
+	if !errors.Is(err, ErrReservedValue) || i.IsPresent() {
+		t.Errorf("Some(MaxUint) = %v, %v; want None, %v", i, err, ErrReservedValue)
+	}
+
+	i, err = Some(MaxUint - 1)
+	if err != nil || !i.IsPresent() {
+		t.Errorf("Some(MaxUint - 1) = %v, %v; want MaxUint - 1, nil", i, err)
+	}
+}
+
+// TestIndexArith tests that the checked operations return None on overflow,
+// underflow, sentinel collision or an empty operand.
+func TestIndexArith(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Index
+		want Index
+	}{
+		{"Add", idx(t, 2).Add(3), idx(t, 5)},
+		{"Add to sentinel", idx(t, MaxUint-1).Add(1), None()},
+		{"Add none", None().Add(0), None()},
+		{"Sub", idx(t, 5).Sub(5), idx(t, 0)},
+		{"Sub underflow", idx(t, 5).Sub(6), None()},
+		{"Offset back", idx(t, 5).Offset(-2), idx(t, 3)},
+		{"Offset underflow", idx(t, 5).Offset(-6), None()},
+		{"Offset to sentinel", idx(t, MaxUint-1).Offset(1), None()},
+		{"Clamp inside", idx(t, 2).Clamp(3), idx(t, 2)},
+		{"Clamp past end", idx(t, 7).Clamp(3), idx(t, 2)},
+		{"Clamp at end", idx(t, 3).Clamp(3), idx(t, 2)},
+		{"Clamp empty length", idx(t, 0).Clamp(0), None()},
+		{"Clamp none", None().Clamp(3), None()},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v; want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+// TestSpanLenContains tests Len and Contains on valid and invalid spans.
+func TestSpanLenContains(t *testing.T) {
+	s := span(t, 2, 5)
+
+	if got := s.Len(); got != idx(t, 3) {
+		t.Errorf("Len() = %v; want 3", got)
+	}
+
+	for i, want := range []bool{false, false, true, true, true, false} {
+		if got := s.Contains(idx(t, uint(i))); got != want {
+			t.Errorf("Contains(%d) = %t; want %t", i, got, want)
+		}
+	}
+
+	if s.Contains(None()) {
+		t.Error("Contains(None()) = true; want false")
+	}
+
+	invalid := []Span{
+		span(t, 5, 2),
+		{Start: None(), End: idx(t, 5)},
+		{Start: idx(t, 2), End: None()},
+		noSpan(),
+	}
+
+	for _, s := range invalid {
+		if s.IsValid() || s.Len().IsPresent() || s.Contains(idx(t, 3)) {
+			t.Errorf("%v treated as valid", s)
+		}
+	}
+
+	var zero Span
+
+	if !zero.IsValid() || zero.Len() != idx(t, 0) {
+		t.Errorf("zero Span = %v; want the valid empty span", zero)
+	}
+}
+
+// TestSpanIntersectUnion tests Intersect and Union, and that their failures
+// return an invalid span rather than the valid empty span [0, 0).
+func TestSpanIntersectUnion(t *testing.T) {
+	a := span(t, 2, 5)
+
+	tests := []struct {
+		other    Span
+		inter    Span
+		inter_ok bool
+		union    Span
+		union_ok bool
+	}{
+		{span(t, 4, 8), span(t, 4, 5), true, span(t, 2, 8), true},
+		{span(t, 0, 3), span(t, 2, 3), true, span(t, 0, 5), true},
+		{span(t, 3, 4), span(t, 3, 4), true, span(t, 2, 5), true},
+		{span(t, 5, 7), noSpan(), false, span(t, 2, 7), true},
+		{span(t, 6, 7), noSpan(), false, noSpan(), false},
+		{span(t, 7, 6), noSpan(), false, noSpan(), false},
+	}
+
+	for _, test := range tests {
+		inter, ok := a.Intersect(test.other)
+		if ok != test.inter_ok || inter != test.inter {
+			t.Errorf("Intersect(%v) = %v, %t; want %v, %t", test.other, inter, ok, test.inter, test.inter_ok)
+		}
+
+		union, ok := a.Union(test.other)
+		if ok != test.union_ok || union != test.union {
+			t.Errorf("Union(%v) = %v, %t; want %v, %t", test.other, union, ok, test.union, test.union_ok)
+		}
+
+		if !ok && (!isNoSpan(union) || union.Contains(idx(t, 0))) {
+			t.Errorf("failed Union(%v) = %v; want an invalid span", test.other, union)
+		}
+	}
+}
+
+// TestSpanSplit tests Split at the bounds, inside and outside of a span.
+func TestSpanSplit(t *testing.T) {
+	s := span(t, 2, 5)
+
+	tests := []struct {
+		at     Index
+		before Span
+		after  Span
+		ok     bool
+	}{
+		{idx(t, 3), span(t, 2, 3), span(t, 3, 5), true},
+		{idx(t, 2), span(t, 2, 2), span(t, 2, 5), true},
+		{idx(t, 5), span(t, 2, 5), span(t, 5, 5), true},
+		{idx(t, 1), noSpan(), noSpan(), false},
+		{idx(t, 6), noSpan(), noSpan(), false},
+		{None(), noSpan(), noSpan(), false},
+	}
+
+	for _, test := range tests {
+		before, after, ok := s.Split(test.at)
+		if ok != test.ok || before != test.before || after != test.after {
+			t.Errorf("Split(%v) = %v, %v, %t; want %v, %v, %t", test.at, before, after, ok, test.before, test.after, test.ok)
+		}
+	}
+
+	_, _, ok := noSpan().Split(idx(t, 0))
+	if ok {
+		t.Error("Split of an invalid span succeeded")
+	}
+}
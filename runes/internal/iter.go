@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"bytes"
+	"iter"
+	"unicode/utf8"
+)
+
+// Runes returns an iterator over the runes of a byte slice.
+//
+// Parameters:
+//   - data: The byte slice to decode.
+//
+// Returns:
+//   - iter.Seq2[int, rune]: The byte offset and the value of every rune.
+func Runes(data []byte) iter.Seq2[int, rune] {
+	return func(yield func(int, rune) bool) {
+		var offset int
+
+		for offset < len(data) {
+			r, size := utf8.DecodeRune(data[offset:])
+
+			ok := yield(offset, r)
+			if !ok {
+				return
+			}
+
+			offset += size
+		}
+	}
+}
+
+// Lines returns an iterator over the lines of a byte slice.
+//
+// Parameters:
+//   - data: The byte slice to split.
+//
+// Returns:
+//   - iter.Seq2[int, []byte]: The byte offset and the content of every line,
+//     without its line terminator.
+func Lines(data []byte) iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		var offset int
+
+		for offset < len(data) {
+			rest := data[offset:]
+
+			end := bytes.IndexByte(rest, '\n')
+
+			next := offset + end + 1
+
+			if end < 0 {
+				end = len(rest)
+				next = len(data)
+			}
+
+			line := bytes.TrimSuffix(rest[:end:end], []byte{'\r'})
+
+			ok := yield(offset, line)
+			if !ok {
+				return
+			}
+
+			offset = next
+		}
+	}
+}
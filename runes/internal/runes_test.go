@@ -28,3 +28,41 @@ func TestSplit(t *testing.T) {
 		}
 	}
 }
+
+// TestLines tests the Lines function.
+func TestLines(t *testing.T) {
+	var offsets []int
+	var lines []string
+
+	for offset, line := range Lines([]byte("one\r\ntwo\n\nthree")) {
+		offsets = append(offsets, offset)
+		lines = append(lines, string(line))
+	}
+
+	if want := []int{0, 5, 9, 10}; !slices.Equal(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+
+	if want := []string{"one", "two", "", "three"}; !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+// TestRunes tests the Runes function.
+func TestRunes(t *testing.T) {
+	var offsets []int
+	var chars []rune
+
+	for offset, r := range Runes([]byte("aé\xffz")) {
+		offsets = append(offsets, offset)
+		chars = append(chars, r)
+	}
+
+	if want := []int{0, 1, 3, 4}; !slices.Equal(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+
+	if want := []rune{'a', 'é', '�', 'z'}; !slices.Equal(chars, want) {
+		t.Errorf("runes = %q, want %q", chars, want)
+	}
+}
@@ -0,0 +1,36 @@
+package runes
+
+import (
+	"iter"
+
+	"github.com/PlayerR9/mygo-lib/runes/internal"
+)
+
+// Runes returns an iterator over the runes of a byte slice that decodes them
+// in place, without converting the whole slice first. Unlike BytesToUtf8,
+// invalid utf-8 data does not stop the iteration: each invalid byte is yielded
+// as utf8.RuneError, as a range over a string does.
+//
+// Parameters:
+//   - data: The byte slice to decode.
+//
+// Returns:
+//   - iter.Seq2[int, rune]: The byte offset and the value of every rune. Never returns nil.
+func Runes(data []byte) iter.Seq2[int, rune] {
+	return internal.Runes(data)
+}
+
+// Lines returns an iterator over the lines of a byte slice. Lines end with
+// "\n" or "\r\n"; the last line may have no terminator. Each line is a
+// sub-slice of data, not a copy, so it must not be modified and is only valid
+// as long as data is.
+//
+// Parameters:
+//   - data: The byte slice to split.
+//
+// Returns:
+//   - iter.Seq2[int, []byte]: The byte offset and the content of every line,
+//     without its terminator. Never returns nil.
+func Lines(data []byte) iter.Seq2[int, []byte] {
+	return internal.Lines(data)
+}